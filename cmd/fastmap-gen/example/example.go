@@ -0,0 +1,8 @@
+// Package example holds maps generated by fastmap-gen. Its tests run them through the same
+// conformance suite and differential fuzzing as the generic maps.
+package example
+
+//go:generate go run github.com/billowdev/fastmap/cmd/fastmap-gen -map HashMap -key string -value int -name WordCounts
+//go:generate go run github.com/billowdev/fastmap/cmd/fastmap-gen -map ThreadSafeHashMap -key string -value int -features stats,ttl -name SessionCounts
+//go:generate go run github.com/billowdev/fastmap/cmd/fastmap-gen -map RobinHoodMap -key uint16 -value int -features stats -name PortCounts
+//go:generate go run github.com/billowdev/fastmap/cmd/fastmap-gen -map RobinHoodMap -key string -value int -features ttl -name Scores
@@ -0,0 +1,40 @@
+package example_test
+
+import (
+	"testing"
+
+	"github.com/billowdev/fastmap/cmd/fastmap-gen/example"
+	hashmap "github.com/billowdev/fastmap/hashmap"
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+const benchmarkKeys = 1 << 16
+
+func BenchmarkGeneratedGet(b *testing.B) {
+	generated := example.NewPortCounts()
+	generic := robinhood.NewRobinHoodMap[uint16, int]()
+	hashMap := hashmap.NewHashMap[uint16, int]()
+	for i := 0; i < benchmarkKeys; i++ {
+		generated.Put(uint16(i), i)
+		generic.Put(uint16(i), i)
+		hashMap.Put(uint16(i), i)
+	}
+
+	b.Run("Generated", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			generated.Get(uint16(i))
+		}
+	})
+
+	b.Run("RobinHoodMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			generic.Get(uint16(i))
+		}
+	})
+
+	b.Run("HashMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hashMap.Get(uint16(i))
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package example_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/billowdev/fastmap"
+	"github.com/billowdev/fastmap/cmd/fastmap-gen/example"
+	"github.com/billowdev/fastmap/fastmaptest"
+)
+
+var (
+	_ fastmap.Map[string, int] = (*example.WordCounts)(nil)
+	_ fastmap.Map[string, int] = (*example.SessionCounts)(nil)
+	_ fastmap.Map[uint16, int] = (*example.PortCounts)(nil)
+	_ fastmap.Map[string, int] = (*example.Scores)(nil)
+)
+
+func TestGeneratedConformance(t *testing.T) {
+	for name, factory := range map[string]fastmaptest.MapFactory{
+		"HashMap":           func() fastmap.Map[string, int] { return example.NewWordCounts() },
+		"ThreadSafeHashMap": func() fastmap.Map[string, int] { return example.NewSessionCounts() },
+		"RobinHoodMap":      func() fastmap.Map[string, int] { return example.NewScores() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			fastmaptest.RunMapSuite(t, factory)
+		})
+	}
+}
+
+func FuzzGeneratedRobinHoodMap(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fastmaptest.RunOps(t, fastmaptest.DecodeOps(data), map[string]fastmaptest.Target{
+			"generated": example.NewPortCounts(),
+		})
+	})
+}
+
+// fakeClock is a settable time source for the TTL feature
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestGeneratedTTL(t *testing.T) {
+	type ttlMap interface {
+		fastmap.Map[string, int]
+		PutWithTTL(key string, value int, ttl time.Duration)
+		RemoveExpired() int
+	}
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	for name, m := range map[string]ttlMap{
+		"ThreadSafeHashMap": example.NewSessionCountsWithClock(clock.Now),
+		"RobinHoodMap":      example.NewScoresWithClock(clock.Now),
+	} {
+		t.Run(name, func(t *testing.T) {
+			m.Put("forever", 1)
+			m.PutWithTTL("short", 2, time.Second)
+			m.PutWithTTL("long", 3, time.Hour)
+			if value, exists := m.Get("short"); !exists || value != 2 {
+				t.Fatalf("Get(short) = (%d, %v) before expiry", value, exists)
+			}
+
+			clock.Advance(time.Minute)
+			if _, exists := m.Get("short"); exists {
+				t.Error("Get found an expired entry")
+			}
+			if m.Contains("short") {
+				t.Error("Contains found an expired entry")
+			}
+			if keys := m.Keys(); len(keys) != 2 {
+				t.Errorf("Keys = %v, want the two live keys", keys)
+			}
+			if m.Size() != 3 {
+				t.Errorf("Size = %d, want 3 until RemoveExpired", m.Size())
+			}
+			if removed := m.RemoveExpired(); removed != 1 {
+				t.Errorf("RemoveExpired = %d, want 1", removed)
+			}
+			if m.Size() != 2 {
+				t.Errorf("Size = %d after RemoveExpired, want 2", m.Size())
+			}
+
+			// Putting again replaces the expiry, a plain Put never expires
+			m.PutWithTTL("forever", 4, time.Second)
+			m.Put("long", 5)
+			clock.Advance(2 * time.Hour)
+			if m.Contains("forever") {
+				t.Error("PutWithTTL did not set an expiry on an existing key")
+			}
+			if value, exists := m.Get("long"); !exists || value != 5 {
+				t.Errorf("Get(long) = (%d, %v), want 5 without expiry", value, exists)
+			}
+			if m.Remove("forever") {
+				t.Error("Remove reported an expired entry as present")
+			}
+		})
+	}
+}
+
+func TestGeneratedStats(t *testing.T) {
+	m := example.NewPortCounts()
+	for port := uint16(0); port < 100; port++ {
+		m.Put(port, int(port))
+	}
+	m.Put(1, 1)
+	m.Get(1)
+	m.Get(1000)
+	m.Remove(2)
+	m.Remove(2000)
+
+	stats := m.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Puts != 101 || stats.Removes != 1 || stats.Size != 99 {
+		t.Errorf("Stats = %+v", stats)
+	}
+	if stats.Capacity != m.Capacity() || stats.LoadFactor > 0.75 {
+		t.Errorf("Stats = %+v, capacity %d", stats, m.Capacity())
+	}
+	if stats.String() == "" {
+		t.Error("empty Stats string")
+	}
+}
+
+func TestGeneratedThreadSafeConcurrentAccess(t *testing.T) {
+	m := example.NewSessionCounts()
+	const workers, perWorker = 8, 500
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				key := string(rune('a'+w)) + string(rune(i))
+				m.Put(key, i)
+				m.Get(key)
+				m.Contains(key)
+			}
+		}(w)
+	}
+	wg.Wait()
+	if m.Size() != workers*perWorker {
+		t.Errorf("Size = %d, want %d", m.Size(), workers*perWorker)
+	}
+	if stats := m.Stats(); stats.Hits != workers*perWorker || stats.Puts != workers*perWorker {
+		t.Errorf("Stats = %+v", stats)
+	}
+}
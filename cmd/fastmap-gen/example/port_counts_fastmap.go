@@ -0,0 +1,250 @@
+// Code generated by fastmap-gen. DO NOT EDIT.
+// fastmap-gen -map RobinHoodMap -key uint16 -value int -name PortCounts -features stats
+
+package example
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// PortCounts is an open-addressing map from uint16 to int using Robin Hood probing, a
+// specialized copy of fastmap's RobinHoodMap.
+// Get updates the Stats counters, so unlike a built-in map it needs synchronization even between
+// concurrent readers.
+type PortCounts struct {
+	slots []portCountsSlot
+	size  int
+	mask  uint64
+	seed  uint64
+
+	// counters behind Stats
+	hits, misses, puts, removes uint64
+}
+
+type portCountsSlot struct {
+	hash  uint64
+	key   uint16
+	value int
+	// distance is the probe distance plus one, 0 marks an empty slot
+	distance uint32
+}
+
+// NewPortCounts creates a new empty PortCounts
+func NewPortCounts() *PortCounts {
+	m := &PortCounts{seed: rand.Uint64()}
+	m.allocate(8)
+	return m
+}
+
+func (m *PortCounts) allocate(size int) {
+	m.slots = make([]portCountsSlot, size)
+	m.mask = uint64(size - 1)
+	m.size = 0
+}
+
+func (m *PortCounts) hash(key uint16) uint64 {
+	x := uint64(key) ^ m.seed
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Put adds or updates a key-value pair
+func (m *PortCounts) Put(key uint16, value int) {
+	slot := portCountsSlot{key: key, value: value}
+	m.puts++
+	slot.hash = m.hash(key)
+	if index := m.find(slot.hash, key); index >= 0 {
+		slot.distance = m.slots[index].distance
+		m.slots[index] = slot
+		return
+	}
+	if (m.size+1)*4 > len(m.slots)*3 {
+		m.resize(len(m.slots) * 2)
+	}
+	m.insert(slot)
+}
+
+// insert places a slot for a key known to be absent
+func (m *PortCounts) insert(slot portCountsSlot) {
+	index := slot.hash & m.mask
+	slot.distance = 1
+	for {
+		current := &m.slots[index]
+		if current.distance == 0 {
+			*current = slot
+			m.size++
+			return
+		}
+		// Robin Hood: rich (current slot) vs poor (new slot)
+		if slot.distance > current.distance {
+			slot, *current = *current, slot
+		}
+		slot.distance++
+		index = (index + 1) & m.mask
+	}
+}
+
+// find returns the slot index holding key, or -1 if the key is absent
+func (m *PortCounts) find(hash uint64, key uint16) int {
+	index := hash & m.mask
+	for distance := uint32(1); ; distance++ {
+		slot := &m.slots[index]
+		if slot.distance < distance {
+			return -1
+		}
+		if slot.hash == hash && slot.key == key {
+			return int(index)
+		}
+		index = (index + 1) & m.mask
+	}
+}
+
+func (m *PortCounts) resize(newSize int) {
+	old := m.slots
+	m.allocate(newSize)
+	for i := range old {
+		if old[i].distance != 0 {
+			m.insert(old[i])
+		}
+	}
+}
+
+// Get retrieves a value by key and returns whether it exists
+func (m *PortCounts) Get(key uint16) (int, bool) {
+	index := m.find(m.hash(key), key)
+	if index < 0 {
+		m.misses++
+		var zero int
+		return zero, false
+	}
+	m.hits++
+	return m.slots[index].value, true
+}
+
+// Contains checks if a key exists
+func (m *PortCounts) Contains(key uint16) bool {
+	index := m.find(m.hash(key), key)
+	return index >= 0
+}
+
+// Remove deletes a key-value pair and returns whether the key existed
+func (m *PortCounts) Remove(key uint16) bool {
+	index := m.find(m.hash(key), key)
+	if index < 0 {
+		return false
+	}
+	m.size--
+	// Backward shift deletion
+	for {
+		next := (index + 1) & int(m.mask)
+		if m.slots[next].distance <= 1 {
+			m.slots[index] = portCountsSlot{}
+			break
+		}
+		m.slots[index] = m.slots[next]
+		m.slots[index].distance--
+		index = next
+	}
+	m.removes++
+	return true
+}
+
+// Size returns the number of elements
+func (m *PortCounts) Size() int {
+	return m.size
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *PortCounts) IsEmpty() bool {
+	return m.size == 0
+}
+
+// Capacity returns the number of slots in the table
+func (m *PortCounts) Capacity() int {
+	return len(m.slots)
+}
+
+// Clear removes all elements and drops back to the initial capacity
+func (m *PortCounts) Clear() {
+	m.allocate(8)
+}
+
+// Keys returns a slice of all keys
+func (m *PortCounts) Keys() []uint16 {
+	keys := make([]uint16, 0, m.size)
+	for i := range m.slots {
+		if m.slots[i].distance != 0 {
+			keys = append(keys, m.slots[i].key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *PortCounts) Values() []int {
+	values := make([]int, 0, m.size)
+	for i := range m.slots {
+		if m.slots[i].distance != 0 {
+			values = append(values, m.slots[i].value)
+		}
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The callback must not modify the map.
+func (m *PortCounts) ForEach(callback func(uint16, int) error) error {
+	for i := range m.slots {
+		slot := &m.slots[i]
+		if slot.distance == 0 {
+			continue
+		}
+		if err := callback(slot.key, slot.value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", slot.key, err)
+		}
+	}
+	return nil
+}
+
+// Stats returns the operation counters along with the occupancy and longest probe of the table.
+// It is O(capacity).
+func (m *PortCounts) Stats() PortCountsStats {
+	stats := PortCountsStats{
+		Hits:       m.hits,
+		Misses:     m.misses,
+		Puts:       m.puts,
+		Removes:    m.removes,
+		Size:       m.size,
+		Capacity:   len(m.slots),
+		LoadFactor: float64(m.size) / float64(len(m.slots)),
+	}
+	for i := range m.slots {
+		if distance := int(m.slots[i].distance); distance != 0 {
+			stats.MaxProbeLength = max(stats.MaxProbeLength, distance-1)
+		}
+	}
+	return stats
+}
+
+// PortCountsStats holds the operation counters of a PortCounts and its current size
+type PortCountsStats struct {
+	Hits           uint64
+	Misses         uint64
+	Puts           uint64
+	Removes        uint64
+	Size           int
+	Capacity       int
+	LoadFactor     float64
+	MaxProbeLength int
+}
+
+// String renders the stats on one line
+func (s PortCountsStats) String() string {
+	return fmt.Sprintf("size=%d capacity=%d load=%.2f maxProbe=%d hits=%d misses=%d puts=%d removes=%d",
+		s.Size, s.Capacity, s.LoadFactor, s.MaxProbeLength, s.Hits, s.Misses, s.Puts, s.Removes)
+}
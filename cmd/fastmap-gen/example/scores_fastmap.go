@@ -0,0 +1,242 @@
+// Code generated by fastmap-gen. DO NOT EDIT.
+// fastmap-gen -map RobinHoodMap -key string -value int -name Scores -features ttl
+
+package example
+
+import (
+	"fmt"
+	"hash/maphash"
+	"time"
+)
+
+// Scores is an open-addressing map from string to int using Robin Hood probing, a
+// specialized copy of fastmap's RobinHoodMap.
+// Like a built-in map it is safe for any number of concurrent readers as long as nobody writes.
+// Entries put with PutWithTTL expire after their TTL. Expired entries are invisible to reads
+// and keep counting towards Size until RemoveExpired drops them.
+type Scores struct {
+	slots []scoresSlot
+	size  int
+	mask  uint64
+	seed  maphash.Seed
+	now   func() time.Time
+}
+
+type scoresSlot struct {
+	hash  uint64
+	key   string
+	value int
+	// expiresAt is the expiry in Unix nanoseconds, zero for entries that never expire
+	expiresAt int64
+	// distance is the probe distance plus one, 0 marks an empty slot
+	distance uint32
+}
+
+// NewScores creates a new empty Scores
+func NewScores() *Scores {
+	return NewScoresWithClock(time.Now)
+}
+
+// NewScoresWithClock creates a new empty Scores that reads the time from now
+func NewScoresWithClock(now func() time.Time) *Scores {
+	m := &Scores{seed: maphash.MakeSeed(), now: now}
+	m.allocate(8)
+	return m
+}
+
+func (m *Scores) allocate(size int) {
+	m.slots = make([]scoresSlot, size)
+	m.mask = uint64(size - 1)
+	m.size = 0
+}
+
+func (m *Scores) hash(key string) uint64 {
+	return maphash.String(m.seed, key)
+}
+
+// Put adds or updates a key-value pair
+func (m *Scores) Put(key string, value int) {
+	m.put(scoresSlot{key: key, value: value})
+}
+
+// PutWithTTL adds or updates a key-value pair that expires after ttl
+func (m *Scores) PutWithTTL(key string, value int, ttl time.Duration) {
+	m.put(scoresSlot{key: key, value: value, expiresAt: m.now().Add(ttl).UnixNano()})
+}
+
+func (m *Scores) put(slot scoresSlot) {
+	key := slot.key
+	slot.hash = m.hash(key)
+	if index := m.find(slot.hash, key); index >= 0 {
+		slot.distance = m.slots[index].distance
+		m.slots[index] = slot
+		return
+	}
+	if (m.size+1)*4 > len(m.slots)*3 {
+		m.resize(len(m.slots) * 2)
+	}
+	m.insert(slot)
+}
+
+// insert places a slot for a key known to be absent
+func (m *Scores) insert(slot scoresSlot) {
+	index := slot.hash & m.mask
+	slot.distance = 1
+	for {
+		current := &m.slots[index]
+		if current.distance == 0 {
+			*current = slot
+			m.size++
+			return
+		}
+		// Robin Hood: rich (current slot) vs poor (new slot)
+		if slot.distance > current.distance {
+			slot, *current = *current, slot
+		}
+		slot.distance++
+		index = (index + 1) & m.mask
+	}
+}
+
+// find returns the slot index holding key, or -1 if the key is absent
+func (m *Scores) find(hash uint64, key string) int {
+	index := hash & m.mask
+	for distance := uint32(1); ; distance++ {
+		slot := &m.slots[index]
+		if slot.distance < distance {
+			return -1
+		}
+		if slot.hash == hash && slot.key == key {
+			return int(index)
+		}
+		index = (index + 1) & m.mask
+	}
+}
+
+func (m *Scores) resize(newSize int) {
+	old := m.slots
+	m.allocate(newSize)
+	for i := range old {
+		if old[i].distance != 0 {
+			m.insert(old[i])
+		}
+	}
+}
+
+// Get retrieves a value by key and returns whether it exists
+func (m *Scores) Get(key string) (int, bool) {
+	index := m.find(m.hash(key), key)
+	if index >= 0 && m.expired(&m.slots[index], m.now().UnixNano()) {
+		index = -1
+	}
+	if index < 0 {
+		var zero int
+		return zero, false
+	}
+	return m.slots[index].value, true
+}
+
+// Contains checks if a key exists
+func (m *Scores) Contains(key string) bool {
+	index := m.find(m.hash(key), key)
+	return index >= 0 && !m.expired(&m.slots[index], m.now().UnixNano())
+}
+
+func (m *Scores) expired(slot *scoresSlot, now int64) bool {
+	return slot.expiresAt != 0 && slot.expiresAt <= now
+}
+
+// Remove deletes a key-value pair and returns whether the key existed
+func (m *Scores) Remove(key string) bool {
+	index := m.find(m.hash(key), key)
+	if index < 0 {
+		return false
+	}
+	live := !m.expired(&m.slots[index], m.now().UnixNano())
+	m.size--
+	// Backward shift deletion
+	for {
+		next := (index + 1) & int(m.mask)
+		if m.slots[next].distance <= 1 {
+			m.slots[index] = scoresSlot{}
+			break
+		}
+		m.slots[index] = m.slots[next]
+		m.slots[index].distance--
+		index = next
+	}
+	return live
+}
+
+// RemoveExpired drops every expired entry and returns how many it dropped
+func (m *Scores) RemoveExpired() int {
+	now := m.now().UnixNano()
+	old, size := m.slots, m.size
+	m.allocate(len(old))
+	for i := range old {
+		if old[i].distance != 0 && !m.expired(&old[i], now) {
+			m.insert(old[i])
+		}
+	}
+	return size - m.size
+}
+
+// Size returns the number of elements
+func (m *Scores) Size() int {
+	return m.size
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *Scores) IsEmpty() bool {
+	return m.size == 0
+}
+
+// Capacity returns the number of slots in the table
+func (m *Scores) Capacity() int {
+	return len(m.slots)
+}
+
+// Clear removes all elements and drops back to the initial capacity
+func (m *Scores) Clear() {
+	m.allocate(8)
+}
+
+// Keys returns a slice of all keys
+func (m *Scores) Keys() []string {
+	keys := make([]string, 0, m.size)
+	now := m.now().UnixNano()
+	for i := range m.slots {
+		if m.slots[i].distance != 0 && !m.expired(&m.slots[i], now) {
+			keys = append(keys, m.slots[i].key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *Scores) Values() []int {
+	values := make([]int, 0, m.size)
+	now := m.now().UnixNano()
+	for i := range m.slots {
+		if m.slots[i].distance != 0 && !m.expired(&m.slots[i], now) {
+			values = append(values, m.slots[i].value)
+		}
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The callback must not modify the map.
+func (m *Scores) ForEach(callback func(string, int) error) error {
+	now := m.now().UnixNano()
+	for i := range m.slots {
+		slot := &m.slots[i]
+		if slot.distance == 0 || m.expired(slot, now) {
+			continue
+		}
+		if err := callback(slot.key, slot.value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", slot.key, err)
+		}
+	}
+	return nil
+}
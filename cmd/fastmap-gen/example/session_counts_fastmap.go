@@ -0,0 +1,205 @@
+// Code generated by fastmap-gen. DO NOT EDIT.
+// fastmap-gen -map ThreadSafeHashMap -key string -value int -name SessionCounts -features stats,ttl
+
+package example
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SessionCounts is a thread-safe map from string to int, a specialized copy of fastmap's ThreadSafeHashMap.
+// Entries put with PutWithTTL expire after their TTL. Expired entries are invisible to reads
+// and keep counting towards Size until RemoveExpired drops them.
+type SessionCounts struct {
+	mutex sync.RWMutex
+	data  map[string]sessionCountsEntry
+	now   func() time.Time
+
+	// counters behind Stats
+	hits, misses, puts, removes atomic.Uint64
+}
+
+type sessionCountsEntry struct {
+	value int
+	// expiresAt is the expiry in Unix nanoseconds, zero for entries that never expire
+	expiresAt int64
+}
+
+// NewSessionCounts creates a new empty SessionCounts
+func NewSessionCounts() *SessionCounts {
+	return NewSessionCountsWithClock(time.Now)
+}
+
+// NewSessionCountsWithClock creates a new empty SessionCounts that reads the time from now
+func NewSessionCountsWithClock(now func() time.Time) *SessionCounts {
+	return &SessionCounts{data: make(map[string]sessionCountsEntry), now: now}
+}
+
+// Put adds or updates a key-value pair
+func (m *SessionCounts) Put(key string, value int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.puts.Add(1)
+	m.data[key] = sessionCountsEntry{value: value}
+}
+
+// PutWithTTL adds or updates a key-value pair that expires after ttl
+func (m *SessionCounts) PutWithTTL(key string, value int, ttl time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.puts.Add(1)
+	m.data[key] = sessionCountsEntry{value: value, expiresAt: m.now().Add(ttl).UnixNano()}
+}
+
+// Get retrieves a value by key and returns whether it exists
+func (m *SessionCounts) Get(key string) (int, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	value, exists := m.lookup(key)
+	if exists {
+		m.hits.Add(1)
+	} else {
+		m.misses.Add(1)
+	}
+	return value, exists
+}
+
+func (m *SessionCounts) lookup(key string) (int, bool) {
+	entry, exists := m.data[key]
+	if !exists || entry.expiresAt != 0 && entry.expiresAt <= m.now().UnixNano() {
+		var zero int
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Contains checks if a key exists
+func (m *SessionCounts) Contains(key string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	_, exists := m.lookup(key)
+	return exists
+}
+
+// Remove deletes a key-value pair and returns whether the key existed
+func (m *SessionCounts) Remove(key string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, exists := m.data[key]
+	if !exists {
+		return false
+	}
+	delete(m.data, key)
+	if entry.expiresAt != 0 && entry.expiresAt <= m.now().UnixNano() {
+		return false
+	}
+	m.removes.Add(1)
+	return true
+}
+
+// RemoveExpired drops every expired entry and returns how many it dropped
+func (m *SessionCounts) RemoveExpired() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := m.now().UnixNano()
+	removed := 0
+	for key, entry := range m.data {
+		if entry.expiresAt != 0 && entry.expiresAt <= now {
+			delete(m.data, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Size returns the number of elements
+func (m *SessionCounts) Size() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.data)
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *SessionCounts) IsEmpty() bool {
+	return m.Size() == 0
+}
+
+// Clear removes all elements
+func (m *SessionCounts) Clear() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	clear(m.data)
+}
+
+// Keys returns a slice of all keys
+func (m *SessionCounts) Keys() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	keys := make([]string, 0, len(m.data))
+	now := m.now().UnixNano()
+	for key, entry := range m.data {
+		if entry.expiresAt == 0 || entry.expiresAt > now {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *SessionCounts) Values() []int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	values := make([]int, 0, len(m.data))
+	now := m.now().UnixNano()
+	for _, entry := range m.data {
+		if entry.expiresAt == 0 || entry.expiresAt > now {
+			values = append(values, entry.value)
+		}
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The read lock is held during the iteration, so the callback must not modify the map.
+func (m *SessionCounts) ForEach(callback func(string, int) error) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	now := m.now().UnixNano()
+	for key, entry := range m.data {
+		if entry.expiresAt != 0 && entry.expiresAt <= now {
+			continue
+		}
+		if err := callback(key, entry.value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Stats returns the operation counters and the current size
+func (m *SessionCounts) Stats() SessionCountsStats {
+	return SessionCountsStats{
+		Hits:    m.hits.Load(),
+		Misses:  m.misses.Load(),
+		Puts:    m.puts.Load(),
+		Removes: m.removes.Load(),
+		Size:    m.Size(),
+	}
+}
+
+// SessionCountsStats holds the operation counters of a SessionCounts and its current size
+type SessionCountsStats struct {
+	Hits    uint64
+	Misses  uint64
+	Puts    uint64
+	Removes uint64
+	Size    int
+}
+
+// String renders the stats on one line
+func (s SessionCountsStats) String() string {
+	return fmt.Sprintf("size=%d hits=%d misses=%d puts=%d removes=%d", s.Size, s.Hits, s.Misses, s.Puts, s.Removes)
+}
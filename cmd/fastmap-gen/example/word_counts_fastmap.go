@@ -0,0 +1,89 @@
+// Code generated by fastmap-gen. DO NOT EDIT.
+// fastmap-gen -map HashMap -key string -value int -name WordCounts
+
+package example
+
+import "fmt"
+
+// WordCounts is a map from string to int, a specialized copy of fastmap's HashMap
+type WordCounts struct {
+	data map[string]int
+}
+
+// NewWordCounts creates a new empty WordCounts
+func NewWordCounts() *WordCounts {
+	return &WordCounts{data: make(map[string]int)}
+}
+
+// Put adds or updates a key-value pair
+func (m *WordCounts) Put(key string, value int) {
+	m.data[key] = value
+}
+
+// Get retrieves a value by key and returns whether it exists
+func (m *WordCounts) Get(key string) (int, bool) {
+	return m.lookup(key)
+}
+
+func (m *WordCounts) lookup(key string) (int, bool) {
+	value, exists := m.data[key]
+	return value, exists
+}
+
+// Contains checks if a key exists
+func (m *WordCounts) Contains(key string) bool {
+	_, exists := m.lookup(key)
+	return exists
+}
+
+// Remove deletes a key-value pair and returns whether the key existed
+func (m *WordCounts) Remove(key string) bool {
+	if _, exists := m.data[key]; !exists {
+		return false
+	}
+	delete(m.data, key)
+	return true
+}
+
+// Size returns the number of elements
+func (m *WordCounts) Size() int {
+	return len(m.data)
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *WordCounts) IsEmpty() bool {
+	return m.Size() == 0
+}
+
+// Clear removes all elements
+func (m *WordCounts) Clear() {
+	clear(m.data)
+}
+
+// Keys returns a slice of all keys
+func (m *WordCounts) Keys() []string {
+	keys := make([]string, 0, len(m.data))
+	for key := range m.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *WordCounts) Values() []int {
+	values := make([]int, 0, len(m.data))
+	for _, value := range m.data {
+		values = append(values, value)
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails
+func (m *WordCounts) ForEach(callback func(string, int) error) error {
+	for key, value := range m.data {
+		if err := callback(key, value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"inc":  func(threadSafe bool, field string) string { return count(threadSafe, field, "Add(1)", "++") },
+	"load": func(threadSafe bool, field string) string { return count(threadSafe, field, "Load()", "") },
+}).ParseFS(templateFiles, "templates/*.tmpl"))
+
+func count(threadSafe bool, field, atomic, plain string) string {
+	if threadSafe {
+		return "m." + field + "." + atomic
+	}
+	return "m." + field + plain
+}
+
+// Map kinds the generator can specialize
+const (
+	HashMap           = "HashMap"
+	ThreadSafeHashMap = "ThreadSafeHashMap"
+	RobinHoodMap      = "RobinHoodMap"
+)
+
+// Key kinds decide how a RobinHoodMap hashes its keys
+const (
+	StringKey  = "string"
+	IntegerKey = "integer"
+)
+
+// Config describes one generated map type
+//   - Map: the map to specialize, HashMap, ThreadSafeHashMap or RobinHoodMap
+//   - Key, Value: Go type expressions for the key and value types
+//   - Name: the name of the generated type
+//   - Package: the package clause of the generated file
+//   - Imports: import paths the key or value types need
+//   - KeyKind: string or integer, required by RobinHoodMap for key types that are not predeclared
+//   - Stats: count hits, misses, puts and removes and report them through Stats
+//   - TTL: add PutWithTTL and RemoveExpired for entries that expire
+type Config struct {
+	Map     string
+	Key     string
+	Value   string
+	Name    string
+	Package string
+	Imports []string
+	KeyKind string
+	Stats   bool
+	TTL     bool
+}
+
+// templateData is what the templates see
+type templateData struct {
+	Config
+	Command    string
+	AllImports []string
+	ThreadSafe bool
+	// Stored is the type of the map values, the value itself or an entry carrying its expiry
+	Stored string
+	// Entry and Slot are the names of the unexported helper types
+	Entry string
+	Slot  string
+	// HashKey converts a key to what the hash function takes
+	HashKey string
+}
+
+var integerTypes = []string{
+	"int", "int8", "int16", "int32", "int64",
+	"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+	"byte", "rune",
+}
+
+// Generate renders the source of the map described by config. command is recorded in the file
+// header so the output says how to reproduce it.
+func Generate(config Config, command string) ([]byte, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	lower := lowerFirst(config.Name)
+	data := templateData{
+		Config:     config,
+		Command:    command,
+		ThreadSafe: config.Map == ThreadSafeHashMap,
+		Stored:     config.Value,
+		Entry:      lower + "Entry",
+		Slot:       lower + "Slot",
+		HashKey:    "key",
+	}
+	if config.TTL {
+		data.Stored = data.Entry
+	}
+	if config.Map == RobinHoodMap {
+		switch {
+		case config.KeyKind == StringKey && config.Key != "string":
+			data.HashKey = "string(key)"
+		case config.KeyKind == IntegerKey && config.Key != "uint64":
+			data.HashKey = "uint64(key)"
+		}
+	}
+	data.AllImports = config.imports()
+
+	var out bytes.Buffer
+	if err := templates.ExecuteTemplate(&out, "file.tmpl", data); err != nil {
+		return nil, fmt.Errorf("render %s: %w", config.Name, err)
+	}
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format %s: %w", config.Name, err)
+	}
+	return source, nil
+}
+
+func (c *Config) validate() error {
+	switch c.Map {
+	case HashMap, ThreadSafeHashMap, RobinHoodMap:
+	default:
+		return fmt.Errorf("unknown map %q, want %s, %s or %s", c.Map, HashMap, ThreadSafeHashMap, RobinHoodMap)
+	}
+	if !token.IsIdentifier(c.Name) || !token.IsExported(c.Name) {
+		return fmt.Errorf("name %q is not an exported identifier", c.Name)
+	}
+	if !token.IsIdentifier(c.Package) {
+		return fmt.Errorf("package %q is not an identifier", c.Package)
+	}
+	for _, typ := range []string{c.Key, c.Value} {
+		if _, err := parser.ParseExpr(typ); typ == "" || err != nil {
+			return fmt.Errorf("%q is not a type expression", typ)
+		}
+	}
+
+	if c.KeyKind == "" {
+		switch {
+		case c.Key == "string":
+			c.KeyKind = StringKey
+		case slices.Contains(integerTypes, c.Key):
+			c.KeyKind = IntegerKey
+		}
+	}
+	switch c.KeyKind {
+	case "", StringKey, IntegerKey:
+	default:
+		return fmt.Errorf("unknown key kind %q, want %s or %s", c.KeyKind, StringKey, IntegerKey)
+	}
+	if c.Map == RobinHoodMap && c.KeyKind == "" {
+		return fmt.Errorf("%s cannot hash key type %s, pass -keykind for string or integer types", RobinHoodMap, c.Key)
+	}
+	return nil
+}
+
+// imports returns every import path the generated file needs, sorted
+func (c *Config) imports() []string {
+	paths := append([]string{"fmt"}, c.Imports...)
+	if c.Map == ThreadSafeHashMap {
+		paths = append(paths, "sync")
+		if c.Stats {
+			paths = append(paths, "sync/atomic")
+		}
+	}
+	if c.Map == RobinHoodMap {
+		if c.KeyKind == StringKey {
+			paths = append(paths, "hash/maphash")
+		} else {
+			paths = append(paths, "math/rand/v2")
+		}
+	}
+	if c.TTL {
+		paths = append(paths, "time")
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	// Leading initialisms are lowered as a whole, IDIndex becomes idIndex
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) {
+		i--
+	}
+	return strings.ToLower(string(runes[:i])) + string(runes[i:])
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var goldenCases = []struct {
+	golden string
+	args   []string
+}{
+	{"hashmap.golden", []string{"-package", "golden", "-map", "HashMap", "-key", "string", "-value", "int", "-name", "WordCounts"}},
+	{"hashmap_stats.golden", []string{"-package", "golden", "-map", "HashMap", "-key", "int64", "-value", "[]string", "-features", "stats", "-name", "TagsByID"}},
+	{"threadsafe.golden", []string{"-package", "golden", "-map", "ThreadSafeHashMap", "-key", "string", "-value", "float64", "-name", "Prices"}},
+	{"threadsafe_stats_ttl.golden", []string{"-package", "golden", "-map", "ThreadSafeHashMap", "-key", "string", "-value", "*bytes.Buffer", "-import", "bytes", "-features", "stats,ttl", "-name", "BufferCache"}},
+	{"robinhood_integer_stats.golden", []string{"-package", "golden", "-map", "RobinHoodMap", "-key", "uint64", "-value", "string", "-features", "stats", "-name", "IDIndex"}},
+	{"robinhood_string_ttl.golden", []string{"-package", "golden", "-map", "RobinHoodMap", "-key", "string", "-value", "[]byte", "-features", "ttl", "-name", "SessionStore"}},
+	{"robinhood_named_key.golden", []string{"-package", "golden", "-map", "RobinHoodMap", "-key", "time.Month", "-value", "int", "-import", "time", "-keykind", "integer", "-name", "MonthTotals"}},
+}
+
+func TestGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(strings.TrimSuffix(tc.golden, ".golden"), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "out.go")
+			if err := run(append(tc.args, "-output", output)); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v, run go test -update to create it", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s, run go test -update after checking the change:\n%s", path, got)
+			}
+		})
+	}
+}
+
+// TestGoldenTypeChecks makes sure every golden file is valid Go, the golden files share one
+// package so this also catches helper names that clash between generated types
+func TestGoldenTypeChecks(t *testing.T) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, tc := range goldenCases {
+		file, err := parser.ParseFile(fset, filepath.Join("testdata", tc.golden), nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ast.IsGenerated(file) {
+			t.Errorf("%s lacks the generated code header", tc.golden)
+		}
+		files = append(files, file)
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("golden", fset, files, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateRejectsInvalidConfig(t *testing.T) {
+	valid := Config{Map: HashMap, Key: "string", Value: "int", Name: "Valid", Package: "p"}
+	if _, err := Generate(valid, "fastmap-gen"); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	cases := map[string]func(*Config){
+		"unknown map":            func(c *Config) { c.Map = "TreeMap" },
+		"unexported name":        func(c *Config) { c.Name = "valid" },
+		"missing name":           func(c *Config) { c.Name = "" },
+		"missing package":        func(c *Config) { c.Package = "" },
+		"missing key":            func(c *Config) { c.Key = "" },
+		"bad value":              func(c *Config) { c.Value = "map[string" },
+		"unknown key kind":       func(c *Config) { c.KeyKind = "float" },
+		"unhashable robinhood":   func(c *Config) { c.Map, c.Key = RobinHoodMap, "UserID" },
+		"struct robinhood value": func(c *Config) { c.Map, c.Key = RobinHoodMap, "[16]byte" },
+	}
+	for name, mutate := range cases {
+		config := valid
+		mutate(&config)
+		if _, err := Generate(config, "fastmap-gen"); err == nil {
+			t.Errorf("%s: Generate succeeded", name)
+		}
+	}
+}
+
+func TestRunRejectsInvalidFlags(t *testing.T) {
+	base := []string{"-package", "p", "-key", "string", "-value", "int", "-name", "Valid", "-output", filepath.Join(t.TempDir(), "out.go")}
+	for _, args := range [][]string{
+		append([]string{"-features", "lru"}, base...),
+		append([]string{"-nosuchflag"}, base...),
+		append(base, "extra"),
+	} {
+		if err := run(args); err == nil {
+			t.Errorf("run(%q) succeeded", args)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for name, want := range map[string][2]string{
+		"WordCounts":   {"word_counts", "wordCounts"},
+		"IDIndex":      {"id_index", "idIndex"},
+		"UsersByID":    {"users_by_id", "usersByID"},
+		"HTTPHeaders":  {"http_headers", "httpHeaders"},
+		"Cache":        {"cache", "cache"},
+		"URL":          {"url", "url"},
+		"TagsByID2023": {"tags_by_id2023", "tagsByID2023"},
+	} {
+		if got := snakeCase(name); got != want[0] {
+			t.Errorf("snakeCase(%s) = %s, want %s", name, got, want[0])
+		}
+		if got := lowerFirst(name); got != want[1] {
+			t.Errorf("lowerFirst(%s) = %s, want %s", name, got, want[1])
+		}
+	}
+}
+
+// TestExampleUpToDate regenerates the maps of the example package from its go:generate lines
+func TestExampleUpToDate(t *testing.T) {
+	const directive = "//go:generate go run github.com/billowdev/fastmap/cmd/fastmap-gen "
+	source, err := os.ReadFile(filepath.Join("example", "example.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := 0
+	for _, line := range strings.Split(string(source), "\n") {
+		args, ok := strings.CutPrefix(line, directive)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(args)
+		name := fields[slices.Index(fields, "-name")+1]
+		output := filepath.Join(t.TempDir(), "out.go")
+		if err := run(append(fields, "-package", "example", "-output", output)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join("example", snakeCase(name)+"_fastmap.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("example/%s_fastmap.go is stale, run go generate ./cmd/fastmap-gen/example", snakeCase(name))
+		}
+		generated++
+	}
+	if generated == 0 {
+		t.Fatal("no go:generate lines found in example/example.go")
+	}
+}
@@ -0,0 +1,136 @@
+// Command fastmap-gen generates specialized, non-generic copies of fastmap's HashMap,
+// ThreadSafeHashMap and RobinHoodMap for concrete key and value types. It is meant to be run
+// through go:generate, which sets the package name:
+//
+//	//go:generate go run github.com/billowdev/fastmap/cmd/fastmap-gen -map RobinHoodMap -key uint64 -value *User -name UsersByID -features stats
+//
+// Flags:
+//   - -map: HashMap, ThreadSafeHashMap or RobinHoodMap
+//   - -key, -value: key and value types, e.g. string, []byte or *models.User
+//   - -name: name of the generated type
+//   - -import: import path needed by the key or value type, may be repeated
+//   - -keykind: string or integer, how RobinHoodMap hashes a named key type such as UserID
+//   - -features: comma separated optional features, stats and ttl
+//   - -package: package of the generated file, defaults to $GOPACKAGE
+//   - -output: file to write, defaults to <name>_fastmap.go in snake case
+//
+// The stats feature counts hits, misses, puts and removes and adds a Stats method. The ttl
+// feature adds PutWithTTL for entries that expire and RemoveExpired to drop them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// importFlags collects repeated -import flags
+type importFlags []string
+
+func (i *importFlags) String() string {
+	return strings.Join(*i, ",")
+}
+
+func (i *importFlags) Set(path string) error {
+	*i = append(*i, path)
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "fastmap-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("fastmap-gen", flag.ContinueOnError)
+	config := Config{}
+	var imports importFlags
+	flags.StringVar(&config.Map, "map", HashMap, "map to specialize: HashMap, ThreadSafeHashMap or RobinHoodMap")
+	flags.StringVar(&config.Key, "key", "", "key type")
+	flags.StringVar(&config.Value, "value", "", "value type")
+	flags.StringVar(&config.Name, "name", "", "name of the generated type")
+	flags.Var(&imports, "import", "import path needed by the key or value type, may be repeated")
+	flags.StringVar(&config.KeyKind, "keykind", "", "string or integer, how RobinHoodMap hashes a named key type")
+	features := flags.String("features", "", "comma separated optional features: stats, ttl")
+	flags.StringVar(&config.Package, "package", os.Getenv("GOPACKAGE"), "package of the generated file")
+	output := flags.String("output", "", "output file, defaults to <name>_fastmap.go")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	config.Imports = imports
+
+	for _, feature := range strings.Split(*features, ",") {
+		switch strings.TrimSpace(feature) {
+		case "":
+		case "stats":
+			config.Stats = true
+		case "ttl":
+			config.TTL = true
+		default:
+			return fmt.Errorf("unknown feature %q, want stats or ttl", feature)
+		}
+	}
+
+	source, err := Generate(config, command(config))
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		*output = snakeCase(config.Name) + "_fastmap.go"
+	}
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		return err
+	}
+	return nil
+}
+
+// command renders the flags that reproduce config for the generated file header
+func command(config Config) string {
+	parts := []string{"fastmap-gen", "-map", config.Map, "-key", quote(config.Key), "-value", quote(config.Value), "-name", config.Name}
+	for _, path := range config.Imports {
+		parts = append(parts, "-import", quote(path))
+	}
+	if config.KeyKind != "" {
+		parts = append(parts, "-keykind", config.KeyKind)
+	}
+	var features []string
+	if config.Stats {
+		features = append(features, "stats")
+	}
+	if config.TTL {
+		features = append(features, "ttl")
+	}
+	if len(features) > 0 {
+		parts = append(parts, "-features", strings.Join(features, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quote quotes arguments a shell would otherwise split or expand
+func quote(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\"'*[]{}()") {
+		return fmt.Sprintf("%q", arg)
+	}
+	return arg
+}
+
+// snakeCase turns UsersByID into users_by_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
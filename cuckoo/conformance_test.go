@@ -0,0 +1,71 @@
+package fastmap_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/billowdev/fastmap"
+	cuckoo "github.com/billowdev/fastmap/cuckoo"
+	"github.com/billowdev/fastmap/fastmaptest"
+)
+
+var _ fastmap.Map[string, int] = (*cuckoo.CuckooMap[string, int])(nil)
+
+func TestCuckooMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return cuckoo.NewCuckooMap[string, int]()
+	})
+}
+
+func FuzzCuckooMap(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fastmaptest.RunOps(t, fastmaptest.DecodeOps(data), map[string]fastmaptest.Target{
+			"cuckoo": cuckoo.NewCuckooMap[uint16, int](),
+		})
+	})
+}
+
+func FuzzCuckooMapFloatKeys(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Keys with a zero low byte become NaN, a builtin map stores every such Put separately
+		floatKey := func(key uint16) float64 {
+			if key&0xff == 0 {
+				return math.NaN()
+			}
+			return float64(key)
+		}
+		m := cuckoo.NewCuckooMap[float64, int]()
+		reference := make(map[float64]int)
+		for step, op := range fastmaptest.DecodeOps(data) {
+			key := floatKey(op.Key)
+			switch op.Kind {
+			case fastmaptest.OpPut:
+				m.Put(key, op.Value)
+				reference[key] = op.Value
+			case fastmaptest.OpGet:
+				want, wantExists := reference[key]
+				if value, exists := m.Get(key); exists != wantExists || value != want {
+					t.Fatalf("step %d: Get(%v) = (%d, %v), want (%d, %v)", step, key, value, exists, want, wantExists)
+				}
+			case fastmaptest.OpRemove:
+				_, existed := reference[key]
+				delete(reference, key)
+				if m.Remove(key) != existed {
+					t.Fatalf("step %d: Remove(%v) disagreed with the builtin map", step, key)
+				}
+			case fastmaptest.OpClear:
+				m.Clear()
+				clear(reference)
+			}
+			if m.Size() != len(reference) {
+				t.Fatalf("step %d: Size = %d, want %d", step, m.Size(), len(reference))
+			}
+		}
+	})
+}
@@ -0,0 +1,469 @@
+package fastmap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+const (
+	bucketSize     = 4
+	defaultBuckets = 2
+
+	// maxEvictions bounds an insert's eviction chain, a longer chain rebuilds the table
+	maxEvictions = 128
+	// maxLoadFactor grows the table before eviction chains get long
+	maxLoadFactor = 0.9
+	// rehashBelow is the load factor under which a failed insert picks a new seed instead of
+	// growing, a sparse table only fails because of an unlucky seed
+	rehashBelow = 0.5
+	// maxRehashes is how many new seeds a rebuild tries before it stashes the entries that
+	// still have no slot in a sparse table, or doubles a full one
+	maxRehashes = 8
+
+	// tagMultiplier spreads an 8-bit tag over the bucket index bits
+	tagMultiplier = 0xc6a4a7935bd1e995
+)
+
+// CuckooMap is a bucketized cuckoo hash map. Every key may live in exactly two buckets of four
+// slots, so a lookup compares at most eight slots however full the table is. Inserts make room
+// by evicting entries to their other bucket, a chain longer than 128 evictions rebuilds the
+// table with a new seed or twice the buckets. Each slot keeps an 8-bit tag of the key's hash,
+// keys are only compared on tag matches and an entry's other bucket is derived from its bucket
+// and tag without rehashing the key.
+// Keys whose buckets stay full under every seed, such as values of different types behind an
+// interface that hash alike, go to a small stash that lookups scan after both buckets. Keys not
+// equal to themselves, such as NaN, can never be found again, like in a built-in map they are
+// only kept for iteration and Size.
+// Like a built-in map it is safe for concurrent readers as long as nobody writes.
+// Example:
+//
+//	m := NewCuckooMap[string, Quote]()
+//	m.Put("AAPL", quote)
+type CuckooMap[K comparable, V any] struct {
+	buckets []bucket[K, V]
+	mask    uint64
+	size    int
+	seed    uint64
+	hash    hashing.Func[K]
+	// victim is xorshift state choosing which slot an eviction takes over
+	victim   uint64
+	maxChain int
+	grows    int
+	rehashes int
+	// stash holds entries that found no slot in the table under several seeds
+	stash []entry[K, V]
+	// lost holds entries whose key is not equal to itself
+	lost []entry[K, V]
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+type bucket[K comparable, V any] struct {
+	// tags is 0 for an empty slot
+	tags   [bucketSize]uint8
+	keys   [bucketSize]K
+	values [bucketSize]V
+}
+
+// Stats describes the occupancy and worst-case costs of a CuckooMap
+type Stats struct {
+	Capacity   int
+	Size       int
+	LoadFactor float64
+	// AlternateEntries counts entries stored in their second bucket
+	AlternateEntries int
+	// MaxLookupBuckets is the most buckets any present key needs, never more than two
+	MaxLookupBuckets int
+	// MaxEvictionChain is the longest eviction chain a successful insert needed
+	MaxEvictionChain int
+	// Stashed counts entries kept outside the table because their buckets stayed full
+	Stashed  int
+	Grows    int
+	Rehashes int
+}
+
+// String renders the stats on one line
+func (s Stats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "capacity=%d size=%d load=%.2f", s.Capacity, s.Size, s.LoadFactor)
+	fmt.Fprintf(&b, " alternate=%d max-buckets=%d max-evictions=%d", s.AlternateEntries, s.MaxLookupBuckets, s.MaxEvictionChain)
+	fmt.Fprintf(&b, " stashed=%d grows=%d rehashes=%d", s.Stashed, s.Grows, s.Rehashes)
+	return b.String()
+}
+
+// NewCuckooMap creates a new empty CuckooMap
+// Example:
+//
+//	m := NewCuckooMap[string, Quote]()
+func NewCuckooMap[K comparable, V any]() *CuckooMap[K, V] {
+	m := &CuckooMap[K, V]{hash: hashing.For[K]()}
+	m.reseed()
+	m.allocate(defaultBuckets)
+	return m
+}
+
+func (m *CuckooMap[K, V]) reseed() {
+	m.seed = hashing.RandomSeed()
+	m.victim = m.seed | 1
+}
+
+func (m *CuckooMap[K, V]) allocate(buckets int) {
+	m.buckets = make([]bucket[K, V], buckets)
+	m.mask = uint64(buckets - 1)
+	m.size = 0
+}
+
+// locate returns a key's first bucket and tag
+func (m *CuckooMap[K, V]) locate(key K) (uint64, uint8) {
+	hash := m.hash(m.seed, key)
+	tag := uint8(hash >> 56)
+	if tag == 0 {
+		tag = 1
+	}
+	return hash & m.mask, tag
+}
+
+// alternate returns the other bucket of an entry in bucket index, it is its own inverse and
+// never returns index itself
+func (m *CuckooMap[K, V]) alternate(index uint64, tag uint8) uint64 {
+	return (index ^ (uint64(tag)*tagMultiplier | 1)) & m.mask
+}
+
+// find returns the bucket and slot holding key, or a nil bucket
+func (m *CuckooMap[K, V]) find(key K) (*bucket[K, V], int) {
+	index, tag := m.locate(key)
+	for range 2 {
+		b := &m.buckets[index]
+		for slot := range bucketSize {
+			if b.tags[slot] == tag && b.keys[slot] == key {
+				return b, slot
+			}
+		}
+		index = m.alternate(index, tag)
+	}
+	return nil, 0
+}
+
+// stashed returns the stash position of key, or -1
+func (m *CuckooMap[K, V]) stashed(key K) int {
+	for i := range m.stash {
+		if m.stash[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// add stores an entry in a free slot and reports whether there was one
+func (b *bucket[K, V]) add(tag uint8, key K, value V) bool {
+	for slot := range bucketSize {
+		if b.tags[slot] == 0 {
+			b.tags[slot], b.keys[slot], b.values[slot] = tag, key, value
+			return true
+		}
+	}
+	return false
+}
+
+// Put adds or updates a key-value pair
+// Example:
+//
+//	m.Put("AAPL", quote)
+func (m *CuckooMap[K, V]) Put(key K, value V) {
+	if b, slot := m.find(key); b != nil {
+		b.values[slot] = value
+		return
+	}
+	if i := m.stashed(key); i >= 0 {
+		m.stash[i].value = value
+		return
+	}
+	if key != key {
+		// NaN and keys containing it are never found, they would fill their buckets for nothing
+		m.lost = append(m.lost, entry[K, V]{key, value})
+		return
+	}
+	if float64(m.size+1) > float64(len(m.buckets)*bucketSize)*maxLoadFactor {
+		m.rebuild(len(m.buckets)*2, false, nil, nil)
+	}
+	if homelessKey, homelessValue, ok := m.insert(key, value); !ok {
+		m.rebuildAfterFailure(homelessKey, homelessValue)
+	}
+}
+
+// insert places a key known to be absent. When the eviction chain runs out it returns the
+// entry left without a slot, which may be a different one than it was given.
+func (m *CuckooMap[K, V]) insert(key K, value V) (K, V, bool) {
+	index, tag := m.locate(key)
+	alt := m.alternate(index, tag)
+	if m.buckets[index].add(tag, key, value) || m.buckets[alt].add(tag, key, value) {
+		m.size++
+		return key, value, true
+	}
+
+	if m.nextVictim()&1 == 0 {
+		index = alt
+	}
+	for chain := 1; chain <= maxEvictions; chain++ {
+		b := &m.buckets[index]
+		slot := m.nextVictim() % bucketSize
+		tag, b.tags[slot] = b.tags[slot], tag
+		key, b.keys[slot] = b.keys[slot], key
+		value, b.values[slot] = b.values[slot], value
+
+		index = m.alternate(index, tag)
+		if m.buckets[index].add(tag, key, value) {
+			m.size++
+			m.maxChain = max(m.maxChain, chain)
+			return key, value, true
+		}
+	}
+	return key, value, false
+}
+
+func (m *CuckooMap[K, V]) nextVictim() uint64 {
+	m.victim ^= m.victim << 13
+	m.victim ^= m.victim >> 7
+	m.victim ^= m.victim << 17
+	return m.victim
+}
+
+// rebuildAfterFailure rebuilds the table after an eviction chain ran out, picking a new seed
+// for a sparse table and doubling a full one
+func (m *CuckooMap[K, V]) rebuildAfterFailure(homelessKey K, homelessValue V) {
+	buckets := len(m.buckets)
+	if float64(m.size+1) >= float64(buckets*bucketSize)*rehashBelow {
+		buckets *= 2
+	}
+	m.rebuild(buckets, true, []K{homelessKey}, []V{homelessValue})
+}
+
+// rebuild moves every entry plus the extra ones into a table of the given number of buckets.
+// Until every entry fits it retries with new seeds, and with twice the buckets when the table
+// is not sparse. Entries that find no slot in a sparse table under several seeds are stashed.
+func (m *CuckooMap[K, V]) rebuild(buckets int, reseed bool, keys []K, values []V) {
+	for _, b := range m.buckets {
+		for slot := range bucketSize {
+			if b.tags[slot] != 0 {
+				keys = append(keys, b.keys[slot])
+				values = append(values, b.values[slot])
+			}
+		}
+	}
+	for _, e := range m.stash {
+		keys = append(keys, e.key)
+		values = append(values, e.value)
+	}
+	m.stash = m.stash[:0]
+
+	var homeless []entry[K, V]
+	for attempt := 1; ; attempt++ {
+		if reseed {
+			m.rehashes++
+			m.reseed()
+		}
+		if buckets > len(m.buckets) {
+			m.grows++
+		}
+		m.allocate(buckets)
+		homeless = homeless[:0]
+		for i := range keys {
+			if key, value, ok := m.insert(keys[i], values[i]); !ok {
+				homeless = append(homeless, entry[K, V]{key, value})
+			}
+		}
+		if len(homeless) == 0 {
+			return
+		}
+
+		reseed = true
+		sparse := float64(len(keys)) < float64(buckets*bucketSize)*rehashBelow
+		if sparse && attempt >= maxRehashes {
+			// These keys collide whatever the seed, more buckets would not separate them
+			m.stash = append(m.stash, homeless...)
+			return
+		}
+		if !sparse {
+			buckets *= 2
+		}
+	}
+}
+
+// Get retrieves a value by key and returns whether it exists, it reads at most two buckets
+// Example:
+//
+//	if quote, exists := m.Get("AAPL"); exists {
+//	    fmt.Printf("Found quote: %v\n", quote)
+//	}
+func (m *CuckooMap[K, V]) Get(key K) (V, bool) {
+	if b, slot := m.find(key); b != nil {
+		return b.values[slot], true
+	}
+	if len(m.stash) > 0 {
+		if i := m.stashed(key); i >= 0 {
+			return m.stash[i].value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Contains checks if a key exists
+func (m *CuckooMap[K, V]) Contains(key K) bool {
+	_, exists := m.Get(key)
+	return exists
+}
+
+// Remove deletes a key and returns whether it existed
+// Example:
+//
+//	if m.Remove("AAPL") {
+//	    fmt.Println("removed")
+//	}
+func (m *CuckooMap[K, V]) Remove(key K) bool {
+	b, slot := m.find(key)
+	if b == nil {
+		i := m.stashed(key)
+		if i < 0 {
+			return false
+		}
+		last := len(m.stash) - 1
+		m.stash[i] = m.stash[last]
+		m.stash[last] = entry[K, V]{}
+		m.stash = m.stash[:last]
+		return true
+	}
+	var zeroKey K
+	var zeroValue V
+	b.tags[slot], b.keys[slot], b.values[slot] = 0, zeroKey, zeroValue
+	m.size--
+	return true
+}
+
+// Size returns the number of elements
+func (m *CuckooMap[K, V]) Size() int {
+	return m.size + len(m.stash) + len(m.lost)
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *CuckooMap[K, V]) IsEmpty() bool {
+	return m.Size() == 0
+}
+
+// Capacity returns the number of slots in the table
+func (m *CuckooMap[K, V]) Capacity() int {
+	return len(m.buckets) * bucketSize
+}
+
+// Clear removes all elements and drops back to the initial capacity
+func (m *CuckooMap[K, V]) Clear() {
+	m.allocate(defaultBuckets)
+	m.stash = nil
+	m.lost = nil
+}
+
+// Keys returns a slice of all keys
+func (m *CuckooMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Size())
+	for i := range m.buckets {
+		b := &m.buckets[i]
+		for slot := range bucketSize {
+			if b.tags[slot] != 0 {
+				keys = append(keys, b.keys[slot])
+			}
+		}
+	}
+	for _, e := range m.stash {
+		keys = append(keys, e.key)
+	}
+	for _, e := range m.lost {
+		keys = append(keys, e.key)
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *CuckooMap[K, V]) Values() []V {
+	values := make([]V, 0, m.Size())
+	for i := range m.buckets {
+		b := &m.buckets[i]
+		for slot := range bucketSize {
+			if b.tags[slot] != 0 {
+				values = append(values, b.values[slot])
+			}
+		}
+	}
+	for _, e := range m.stash {
+		values = append(values, e.value)
+	}
+	for _, e := range m.lost {
+		values = append(values, e.value)
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The callback must not modify the map.
+// Example:
+//
+//	err := m.ForEach(func(symbol string, quote Quote) error {
+//	    return publish(symbol, quote)
+//	})
+func (m *CuckooMap[K, V]) ForEach(callback func(K, V) error) error {
+	for i := range m.buckets {
+		b := &m.buckets[i]
+		for slot := range bucketSize {
+			if b.tags[slot] == 0 {
+				continue
+			}
+			if err := callback(b.keys[slot], b.values[slot]); err != nil {
+				return fmt.Errorf("ForEach operation failed at key %v: %w", b.keys[slot], err)
+			}
+		}
+	}
+	for _, outside := range [][]entry[K, V]{m.stash, m.lost} {
+		for _, e := range outside {
+			if err := callback(e.key, e.value); err != nil {
+				return fmt.Errorf("ForEach operation failed at key %v: %w", e.key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Stats scans the table and reports occupancy and worst-case costs. It is O(capacity) and
+// hashes every key.
+// Example:
+//
+//	stats := m.Stats()
+//	fmt.Println(stats.MaxLookupBuckets, stats.MaxEvictionChain)
+func (m *CuckooMap[K, V]) Stats() Stats {
+	stats := Stats{
+		Capacity:         m.Capacity(),
+		Size:             m.Size(),
+		LoadFactor:       float64(m.size) / float64(m.Capacity()),
+		MaxEvictionChain: m.maxChain,
+		Stashed:          len(m.stash),
+		Grows:            m.grows,
+		Rehashes:         m.rehashes,
+	}
+	for i := range m.buckets {
+		b := &m.buckets[i]
+		for slot := range bucketSize {
+			if b.tags[slot] == 0 {
+				continue
+			}
+			stats.MaxLookupBuckets = max(stats.MaxLookupBuckets, 1)
+			if first, _ := m.locate(b.keys[slot]); first != uint64(i) {
+				stats.AlternateEntries++
+				stats.MaxLookupBuckets = 2
+			}
+		}
+	}
+	return stats
+}
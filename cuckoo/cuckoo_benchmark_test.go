@@ -0,0 +1,102 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"testing"
+
+	cuckoo "github.com/billowdev/fastmap/cuckoo"
+	robinhood "github.com/billowdev/fastmap/robinhood"
+	swiss "github.com/billowdev/fastmap/swiss"
+)
+
+const benchmarkKeys = 1 << 16
+
+func BenchmarkCuckooPut(b *testing.B) {
+	b.Run("Cuckoo", func(b *testing.B) {
+		m := cuckoo.NewCuckooMap[int, int]()
+		for i := 0; i < b.N; i++ {
+			m.Put(i%benchmarkKeys, i)
+		}
+	})
+
+	b.Run("RobinHood", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMap[int, int]()
+		for i := 0; i < b.N; i++ {
+			m.Put(i%benchmarkKeys, i)
+		}
+	})
+
+	b.Run("Swiss", func(b *testing.B) {
+		m := swiss.NewSwissMap[int, int]()
+		for i := 0; i < b.N; i++ {
+			m.Put(i%benchmarkKeys, i)
+		}
+	})
+}
+
+func BenchmarkCuckooGet(b *testing.B) {
+	cm := cuckoo.NewCuckooMap[int, int]()
+	rm := robinhood.NewRobinHoodMap[int, int]()
+	sm := swiss.NewSwissMap[int, int]()
+	for i := 0; i < benchmarkKeys; i++ {
+		cm.Put(i, i)
+		rm.Put(i, i)
+		sm.Put(i, i)
+	}
+
+	// Half of the lookups miss
+	b.Run("Cuckoo", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cm.Get(i % (2 * benchmarkKeys))
+		}
+	})
+
+	b.Run("RobinHood", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rm.Get(i % (2 * benchmarkKeys))
+		}
+	})
+
+	b.Run("Swiss", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sm.Get(i % (2 * benchmarkKeys))
+		}
+	})
+}
+
+// BenchmarkWorstCaseProbes fills each map with the same keys and reports the load and how many
+// slots the worst lookup has to compare, the largest size is just below the cuckoo growth threshold
+func BenchmarkWorstCaseProbes(b *testing.B) {
+	for _, keys := range []int{1000, 50000, 235000} {
+		b.Run(fmt.Sprintf("Cuckoo/%d", keys), func(b *testing.B) {
+			m := cuckoo.NewCuckooMap[int, int]()
+			for i := 0; i < keys; i++ {
+				m.Put(i, i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.Get(i % keys)
+			}
+			b.StopTimer()
+			stats := m.Stats()
+			b.ReportMetric(stats.LoadFactor, "load")
+			b.ReportMetric(float64(stats.MaxLookupBuckets*4), "max-slots")
+			b.ReportMetric(float64(stats.MaxEvictionChain), "max-evictions")
+		})
+
+		b.Run(fmt.Sprintf("RobinHood/%d", keys), func(b *testing.B) {
+			m := robinhood.NewRobinHoodMap[int, int]()
+			for i := 0; i < keys; i++ {
+				m.Put(i, i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.Get(i % keys)
+			}
+			b.StopTimer()
+			stats := m.Stats()
+			b.ReportMetric(stats.LoadFactor, "load")
+			b.ReportMetric(float64(stats.MaxProbeLength+1), "max-slots")
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package fastmap
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCuckooMapBasicOperations(t *testing.T) {
+	m := NewCuckooMap[string, int]()
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("a", 3)
+	if value, exists := m.Get("a"); !exists || value != 3 || m.Size() != 2 {
+		t.Errorf("Get(a) = (%d, %v), size %d", value, exists, m.Size())
+	}
+	if !m.Remove("a") || m.Remove("a") || m.Contains("a") {
+		t.Error("Remove reported the wrong result")
+	}
+	stop := errors.New("stop")
+	if err := m.ForEach(func(string, int) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ForEach error = %v, want wrapped stop", err)
+	}
+}
+
+func TestCuckooMapMatchesReference(t *testing.T) {
+	m := NewCuckooMap[int, int]()
+	reference := make(map[int]int)
+	rng := rand.New(rand.NewPCG(4, 4))
+	for i := 0; i < 100000; i++ {
+		key := rng.IntN(20000)
+		if rng.IntN(4) == 0 {
+			_, want := reference[key]
+			if m.Remove(key) != want {
+				t.Fatalf("Remove(%d) disagreed with reference at op %d", key, i)
+			}
+			delete(reference, key)
+		} else {
+			m.Put(key, i)
+			reference[key] = i
+		}
+	}
+	if m.Size() != len(reference) {
+		t.Errorf("Size = %d, want %d", m.Size(), len(reference))
+	}
+	for k, v := range reference {
+		if value, exists := m.Get(k); !exists || value != v {
+			t.Fatalf("Get(%d) = (%d, %v), want %d", k, value, exists, v)
+		}
+	}
+	if stats := m.Stats(); stats.MaxLookupBuckets > 2 || stats.MaxEvictionChain > maxEvictions {
+		t.Errorf("stats out of bounds: %v", stats)
+	}
+}
+
+func TestCuckooMapSharedFirstBucket(t *testing.T) {
+	m := NewCuckooMap[int, int]()
+	// Every key starts in bucket 0 and only the tag tells their second buckets apart
+	m.hash = func(_ uint64, key int) uint64 { return uint64(key+1) << 56 }
+	for i := 0; i < 200; i++ {
+		m.Put(i, i)
+	}
+	for i := 0; i < 200; i++ {
+		if value, exists := m.Get(i); !exists || value != i {
+			t.Fatalf("Get(%d) = (%d, %v)", i, value, exists)
+		}
+	}
+	stats := m.Stats()
+	if stats.Size != 200 || stats.AlternateEntries < 196 || stats.Grows == 0 {
+		t.Errorf("unexpected stats %v", stats)
+	}
+}
+
+func TestCuckooMapRehashesUnluckySeed(t *testing.T) {
+	m := NewCuckooMap[int, int]()
+	for i := 100; i < 220; i++ {
+		m.Put(i, i)
+	}
+	// Removals leave the table sparse, growing would not be needed
+	for i := 100; i < 180; i++ {
+		m.Remove(i)
+	}
+	// Until the first rehash the keys below 9 share both of their buckets
+	hash := m.hash
+	m.hash = func(seed uint64, key int) uint64 {
+		if m.rehashes == 0 && key < 9 {
+			return 7 << 56
+		}
+		return hash(seed, key)
+	}
+	capacity := m.Capacity()
+	for i := 0; i < 9; i++ {
+		m.Put(i, i)
+	}
+	if stats := m.Stats(); stats.Rehashes == 0 || stats.Capacity != capacity {
+		t.Errorf("expected a sparse table to pick a new seed without growing, got %v", stats)
+	}
+	for i := 0; i < 9; i++ {
+		if !m.Contains(i) {
+			t.Errorf("key %d lost in the rehash", i)
+		}
+	}
+}
+
+func TestCuckooMapStashesSeedIndependentCollisions(t *testing.T) {
+	m := NewCuckooMap[int, int]()
+	for i := 100; i < 200; i++ {
+		m.Put(i, i)
+	}
+	hash := m.hash
+	m.hash = func(seed uint64, key int) uint64 {
+		if key < 40 {
+			return 7 << 56
+		}
+		return hash(seed, key)
+	}
+	for i := 0; i < 40; i++ {
+		m.Put(i, i)
+	}
+	m.Put(3, 300)
+	if !m.Remove(20) || m.Remove(20) {
+		t.Error("Remove of a stashed key reported the wrong result")
+	}
+	if m.Size() != 139 || len(m.Keys()) != 139 {
+		t.Errorf("Size = %d, Keys has %d, want 139", m.Size(), len(m.Keys()))
+	}
+	for i := 0; i < 200; i++ {
+		want := i
+		if i == 3 {
+			want = 300
+		}
+		value, exists := m.Get(i)
+		if i == 20 || (i >= 40 && i < 100) {
+			if exists {
+				t.Errorf("Get(%d) found a key that is not there", i)
+			}
+		} else if !exists || value != want {
+			t.Fatalf("Get(%d) = (%d, %v), want %d", i, value, exists, want)
+		}
+	}
+	if stats := m.Stats(); stats.Stashed == 0 || stats.Capacity > 1024 {
+		t.Errorf("expected colliding keys to be stashed without growing the table, got %v", stats)
+	}
+}
+
+func TestCuckooMapNaNKeys(t *testing.T) {
+	m := NewCuckooMap[float64, int]()
+	reference := make(map[float64]int)
+	for i := 0; i < 1000; i++ {
+		m.Put(math.NaN(), i)
+		reference[math.NaN()] = i
+		m.Put(float64(i), i)
+		reference[float64(i)] = i
+	}
+	if m.Size() != len(reference) {
+		t.Errorf("Size = %d, want %d", m.Size(), len(reference))
+	}
+	if _, exists := m.Get(math.NaN()); exists || m.Remove(math.NaN()) {
+		t.Error("a NaN key was found")
+	}
+	for i := 0; i < 1000; i++ {
+		if value, exists := m.Get(float64(i)); !exists || value != i {
+			t.Fatalf("Get(%d) = (%d, %v)", i, value, exists)
+		}
+	}
+	nans := 0
+	m.ForEach(func(key float64, _ int) error {
+		if math.IsNaN(key) {
+			nans++
+		}
+		return nil
+	})
+	if nans != 1000 {
+		t.Errorf("ForEach visited %d NaN keys, want 1000", nans)
+	}
+	m.Clear()
+	if !m.IsEmpty() {
+		t.Errorf("Size after Clear = %d", m.Size())
+	}
+}
@@ -71,6 +71,15 @@
 //   - Write operations use Lock for exclusive access
 //   - Nested operations (like PutAll) handle multiple locks correctly
 //
+// Interfaces:
+//   - Map is satisfied by HashMap, ThreadSafeHashMap, SmallHashMap, RobinHoodMap,
+//     ThreadSafeRobinHoodMap, CompactRobinHoodMap, StringMap, SwissMap, IntMap and
+//     CuckooMap, so callers can swap backends
+//   - ReadOnlyMap is also satisfied by ImmutableHashMap, Snapshot, StaticMap and FileMap
+//   - The fastmaptest package runs the same conformance suite against every backend, and
+//     its operation decoder drives differential fuzz targets comparing each Map backend
+//     and StaticMap with a builtin map
+//
 // For more examples and detailed API documentation, see the package tests
 // and individual method documentation.
 //
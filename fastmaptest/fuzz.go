@@ -0,0 +1,222 @@
+package fastmaptest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/billowdev/fastmap"
+)
+
+// OpKind is the kind of a single fuzzed map operation
+type OpKind uint8
+
+const (
+	OpPut OpKind = iota
+	OpGet
+	OpRemove
+	OpClear
+)
+
+// Op is one step of an operation sequence, Value is only used by OpPut
+type Op struct {
+	Kind  OpKind
+	Key   uint16
+	Value int
+}
+
+// maxOps bounds a decoded sequence so single fuzz inputs stay fast
+const maxOps = 4096
+
+// Target is the part of a map that RunOps drives. Every fastmap.Map[uint16, int] is a Target,
+// maps with a different API such as MultiKeyHashMap can be adapted to it.
+type Target interface {
+	Put(key uint16, value int)
+	Get(key uint16) (int, bool)
+	Remove(key uint16) bool
+	Size() int
+	Clear()
+}
+
+// DecodeOps turns a fuzzer byte stream into operations, three bytes per operation: a kind
+// byte followed by the key in big endian. A kind byte of 0xFF clears the map, otherwise its
+// value mod 4 picks Put (0 and 1), Get (2) or Remove (3). Trailing bytes are ignored.
+// Example:
+//
+//	f.Fuzz(func(t *testing.T, data []byte) {
+//	    fastmaptest.RunOps(t, fastmaptest.DecodeOps(data), map[string]fastmaptest.Target{
+//	        "mymap": NewMyMap[uint16, int](),
+//	    })
+//	})
+func DecodeOps(data []byte) []Op {
+	ops := make([]Op, 0, min(len(data)/3, maxOps))
+	for i := 0; i+3 <= len(data) && len(ops) < maxOps; i += 3 {
+		op := Op{Key: uint16(data[i+1])<<8 | uint16(data[i+2]), Value: len(ops) + 1}
+		switch kind := data[i]; {
+		case kind == 0xFF:
+			op.Kind = OpClear
+		case kind%4 == 2:
+			op.Kind = OpGet
+		case kind%4 == 3:
+			op.Kind = OpRemove
+		default:
+			op.Kind = OpPut
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// EncodeOps is the inverse of DecodeOps, it is used to build seed corpora
+// Example:
+//
+//	f.Add(fastmaptest.EncodeOps(fastmaptest.Op{Kind: fastmaptest.OpPut, Key: 1}))
+func EncodeOps(ops ...Op) []byte {
+	data := make([]byte, 0, len(ops)*3)
+	for _, op := range ops {
+		kind := byte(0)
+		switch op.Kind {
+		case OpGet:
+			kind = 2
+		case OpRemove:
+			kind = 3
+		case OpClear:
+			kind = 0xFF
+		}
+		data = append(data, kind, byte(op.Key>>8), byte(op.Key))
+	}
+	return data
+}
+
+// SeedCorpus returns operation sequences worth starting a fuzzer from. Most of them are
+// collision heavy for hashers that only look at the high byte of a key: long runs of keys with
+// one high byte, neighbouring high bytes forming clusters, and high bytes at the end of small
+// tables so probes wrap around.
+func SeedCorpus() [][]byte {
+	var corpus [][]byte
+	sameBucket := func(high uint16, n int) []Op {
+		ops := make([]Op, 0, n)
+		for i := 0; i < n; i++ {
+			ops = append(ops, Op{Kind: OpPut, Key: high<<8 | uint16(i)})
+		}
+		return ops
+	}
+
+	// Full collisions, removed from the middle and the front so deletion shifts entries back
+	collide := sameBucket(1, 64)
+	for i := 0; i < 64; i += 3 {
+		collide = append(collide, Op{Kind: OpRemove, Key: 1<<8 | uint16(i)})
+	}
+	for i := 0; i < 64; i++ {
+		collide = append(collide, Op{Kind: OpGet, Key: 1<<8 | uint16(i)})
+	}
+	corpus = append(corpus, EncodeOps(collide...))
+
+	// Interleaved clusters from neighbouring home slots, then removals that cross clusters
+	var clusters []Op
+	for i := 0; i < 32; i++ {
+		for high := uint16(0); high < 8; high++ {
+			clusters = append(clusters, Op{Kind: OpPut, Key: high<<8 | uint16(i)})
+		}
+	}
+	for i := 0; i < 32; i += 2 {
+		clusters = append(clusters, Op{Kind: OpRemove, Key: 3<<8 | uint16(i)})
+		clusters = append(clusters, Op{Kind: OpRemove, Key: 4<<8 | uint16(i+1)})
+	}
+	corpus = append(corpus, EncodeOps(clusters...))
+
+	// Home slots at the end of small tables, probes wrap to the start
+	var wrap []Op
+	for _, high := range []uint16{0x07, 0x0F, 0x1F, 0xFF} {
+		wrap = append(wrap, sameBucket(high, 6)...)
+	}
+	wrap = append(wrap, Op{Kind: OpRemove, Key: 0x0700}, Op{Kind: OpRemove, Key: 0xFF05})
+	corpus = append(corpus, EncodeOps(wrap...))
+
+	// Growth past several resizes, a clear, and reuse
+	grow := sameBucket(2, 200)
+	grow = append(grow, Op{Kind: OpClear})
+	grow = append(grow, sameBucket(2, 20)...)
+	for i := 0; i < 20; i++ {
+		grow = append(grow, Op{Kind: OpRemove, Key: 2<<8 | uint16(i)}, Op{Kind: OpPut, Key: 2<<8 | uint16(i)})
+	}
+	corpus = append(corpus, EncodeOps(grow...))
+
+	// Overwrites and removals of keys that were never present
+	corpus = append(corpus, EncodeOps(
+		Op{Kind: OpRemove, Key: 0},
+		Op{Kind: OpPut, Key: 0},
+		Op{Kind: OpPut, Key: 0},
+		Op{Kind: OpGet, Key: 0},
+		Op{Kind: OpRemove, Key: 0},
+		Op{Kind: OpRemove, Key: 0},
+		Op{Kind: OpGet, Key: 0xFFFF},
+	))
+	return corpus
+}
+
+// RunOps applies ops to every target and to a builtin map, failing at the first step where a
+// target disagrees with the builtin map. The touched key and Size are compared after every step
+// and every key is checked periodically and at the end. Targets that implement
+// fastmap.ReadOnlyMap also have their Keys compared.
+func RunOps(t testing.TB, ops []Op, targets map[string]Target) {
+	t.Helper()
+	reference := make(map[uint16]int)
+	for step, op := range ops {
+		_, existed := reference[op.Key]
+		switch op.Kind {
+		case OpPut:
+			reference[op.Key] = op.Value
+		case OpRemove:
+			delete(reference, op.Key)
+		case OpClear:
+			clear(reference)
+		}
+
+		for name, target := range targets {
+			switch op.Kind {
+			case OpPut:
+				target.Put(op.Key, op.Value)
+			case OpRemove:
+				if removed := target.Remove(op.Key); removed != existed {
+					t.Fatalf("%s: step %d: Remove(%#x) = %v, want %v", name, step, op.Key, removed, existed)
+				}
+			case OpClear:
+				target.Clear()
+			}
+
+			want, wantExists := reference[op.Key]
+			if got, exists := target.Get(op.Key); got != want || exists != wantExists {
+				t.Fatalf("%s: step %d: Get(%#x) = (%d, %v), want (%d, %v)", name, step, op.Key, got, exists, want, wantExists)
+			}
+			if target.Size() != len(reference) {
+				t.Fatalf("%s: step %d: Size = %d, want %d", name, step, target.Size(), len(reference))
+			}
+			if op.Kind == OpClear || step%64 == 63 || step == len(ops)-1 {
+				checkTarget(t, name, step, target, reference)
+			}
+		}
+	}
+}
+
+func checkTarget(t testing.TB, name string, step int, target Target, reference map[uint16]int) {
+	t.Helper()
+	for k, v := range reference {
+		if got, exists := target.Get(k); !exists || got != v {
+			t.Fatalf("%s: step %d: Get(%#x) = (%d, %v), want %d", name, step, k, got, exists, v)
+		}
+	}
+	readable, ok := target.(fastmap.ReadOnlyMap[uint16, int])
+	if !ok {
+		return
+	}
+	keys := readable.Keys()
+	slices.Sort(keys)
+	want := make([]uint16, 0, len(reference))
+	for k := range reference {
+		want = append(want, k)
+	}
+	slices.Sort(want)
+	if !slices.Equal(keys, want) {
+		t.Fatalf("%s: step %d: Keys = %v, want %v", name, step, keys, want)
+	}
+}
@@ -0,0 +1,254 @@
+// Package fastmaptest provides conformance tests that every fastmap.Map and
+// fastmap.ReadOnlyMap implementation must pass.
+//
+// Usage from a backend's tests:
+//
+//	func TestConformance(t *testing.T) {
+//	    fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+//	        return NewMyMap[string, int]()
+//	    })
+//	}
+package fastmaptest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/billowdev/fastmap"
+)
+
+// MapFactory returns a new empty map for every call
+type MapFactory func() fastmap.Map[string, int]
+
+// ReadOnlyMapFactory returns a new map holding exactly entries for every call
+type ReadOnlyMapFactory func(entries map[string]int) fastmap.ReadOnlyMap[string, int]
+
+// RunMapSuite runs the mutable map conformance tests as subtests of t
+func RunMapSuite(t *testing.T, factory MapFactory) {
+	t.Run("ReadOnly", func(t *testing.T) {
+		RunReadOnlyMapSuite(t, func(entries map[string]int) fastmap.ReadOnlyMap[string, int] {
+			m := factory()
+			for k, v := range entries {
+				m.Put(k, v)
+			}
+			return m
+		})
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		m := factory()
+		if m.Size() != 0 || !m.IsEmpty() {
+			t.Errorf("new map has size %d", m.Size())
+		}
+		if _, exists := m.Get("missing"); exists {
+			t.Error("Get on an empty map reported a key")
+		}
+		if m.Remove("missing") {
+			t.Error("Remove on an empty map reported a key")
+		}
+	})
+
+	t.Run("PutOverwrites", func(t *testing.T) {
+		m := factory()
+		m.Put("key", 1)
+		m.Put("key", 2)
+		if value, exists := m.Get("key"); !exists || value != 2 {
+			t.Errorf("Get after overwrite = (%d, %v), want 2", value, exists)
+		}
+		if m.Size() != 1 {
+			t.Errorf("overwrite changed size to %d", m.Size())
+		}
+	})
+
+	t.Run("ZeroValues", func(t *testing.T) {
+		m := factory()
+		m.Put("", 0)
+		if value, exists := m.Get(""); !exists || value != 0 {
+			t.Error("zero key with zero value should be stored")
+		}
+		if !m.Contains("") || m.Size() != 1 {
+			t.Error("zero key should be counted and found")
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		m := factory()
+		m.Put("a", 1)
+		m.Put("b", 2)
+		if !m.Remove("a") {
+			t.Error("Remove of an existing key returned false")
+		}
+		if m.Remove("a") {
+			t.Error("second Remove of the same key returned true")
+		}
+		if m.Contains("a") || !m.Contains("b") || m.Size() != 1 {
+			t.Errorf("unexpected contents after Remove: %v", m.Keys())
+		}
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		m := factory()
+		for i := 0; i < 100; i++ {
+			m.Put(fmt.Sprintf("key%d", i), i)
+		}
+		m.Clear()
+		if !m.IsEmpty() || m.Contains("key1") || len(m.Keys()) != 0 {
+			t.Error("Clear left entries behind")
+		}
+		m.Put("after", 1)
+		if value, _ := m.Get("after"); value != 1 || m.Size() != 1 {
+			t.Error("map unusable after Clear")
+		}
+	})
+
+	t.Run("Growth", func(t *testing.T) {
+		m := factory()
+		for i := 0; i < 10000; i++ {
+			m.Put(fmt.Sprintf("key%d", i), i)
+		}
+		for i := 0; i < 10000; i++ {
+			if value, exists := m.Get(fmt.Sprintf("key%d", i)); !exists || value != i {
+				t.Fatalf("Get(key%d) = (%d, %v) after growth", i, value, exists)
+			}
+		}
+	})
+
+	t.Run("RandomOperations", func(t *testing.T) {
+		m := factory()
+		reference := make(map[string]int)
+		rng := rand.New(rand.NewPCG(42, 42))
+		for i := 0; i < 20000; i++ {
+			key := fmt.Sprintf("key%d", rng.IntN(500))
+			switch rng.IntN(10) {
+			case 0, 1, 2:
+				_, want := reference[key]
+				if got := m.Remove(key); got != want {
+					t.Fatalf("op %d: Remove(%s) = %v, want %v", i, key, got, want)
+				}
+				delete(reference, key)
+			case 3:
+				if i%500 == 3 {
+					m.Clear()
+					clear(reference)
+				}
+			default:
+				m.Put(key, i)
+				reference[key] = i
+			}
+			want, wantExists := reference[key]
+			if got, exists := m.Get(key); got != want || exists != wantExists {
+				t.Fatalf("op %d: Get(%s) = (%d, %v), want (%d, %v)", i, key, got, exists, want, wantExists)
+			}
+			if m.Size() != len(reference) {
+				t.Fatalf("op %d: Size = %d, want %d", i, m.Size(), len(reference))
+			}
+		}
+		assertContents(t, m, reference)
+	})
+}
+
+// RunReadOnlyMapSuite runs the read-only conformance tests as subtests of t
+func RunReadOnlyMapSuite(t *testing.T, factory ReadOnlyMapFactory) {
+	t.Run("Contents", func(t *testing.T) {
+		entries := map[string]int{"one": 1, "two": 2, "three": 3, "zero": 0}
+		m := factory(entries)
+		assertContents(t, m, entries)
+		if m.Contains("four") {
+			t.Error("Contains reported a missing key")
+		}
+		if _, exists := m.Get("four"); exists {
+			t.Error("Get reported a missing key")
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		m := factory(map[string]int{})
+		if !m.IsEmpty() || m.Size() != 0 || len(m.Keys()) != 0 || len(m.Values()) != 0 {
+			t.Error("empty map reported entries")
+		}
+		err := m.ForEach(func(string, int) error {
+			t.Error("ForEach called back on an empty map")
+			return nil
+		})
+		if err != nil {
+			t.Errorf("ForEach on an empty map returned %v", err)
+		}
+	})
+
+	t.Run("ForEachError", func(t *testing.T) {
+		m := factory(map[string]int{"a": 1, "b": 2, "c": 3})
+		stop := errors.New("stop")
+		calls := 0
+		err := m.ForEach(func(string, int) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("ForEach error = %v, want it to wrap the callback error", err)
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), "ForEach operation failed at key ") {
+			t.Errorf("ForEach error %q does not name the failing key", err)
+		}
+		if calls != 1 {
+			t.Errorf("ForEach kept going after an error, %d calls", calls)
+		}
+	})
+}
+
+func assertContents(t *testing.T, m fastmap.ReadOnlyMap[string, int], want map[string]int) {
+	t.Helper()
+	if m.Size() != len(want) || m.IsEmpty() != (len(want) == 0) {
+		t.Errorf("Size = %d, IsEmpty = %v, want %d entries", m.Size(), m.IsEmpty(), len(want))
+	}
+	for k, v := range want {
+		if got, exists := m.Get(k); !exists || got != v {
+			t.Errorf("Get(%s) = (%d, %v), want %d", k, got, exists, v)
+		}
+		if !m.Contains(k) {
+			t.Errorf("Contains(%s) = false", k)
+		}
+	}
+
+	keys := m.Keys()
+	slices.Sort(keys)
+	wantKeys := make([]string, 0, len(want))
+	wantValues := make([]int, 0, len(want))
+	for k, v := range want {
+		wantKeys = append(wantKeys, k)
+		wantValues = append(wantValues, v)
+	}
+	slices.Sort(wantKeys)
+	if !slices.Equal(keys, wantKeys) {
+		t.Errorf("Keys = %v, want %v", keys, wantKeys)
+	}
+	values := m.Values()
+	slices.Sort(values)
+	slices.Sort(wantValues)
+	if !slices.Equal(values, wantValues) {
+		t.Errorf("Values = %v, want %v", values, wantValues)
+	}
+
+	visited := make(map[string]int)
+	err := m.ForEach(func(k string, v int) error {
+		if _, dup := visited[k]; dup {
+			t.Errorf("ForEach visited %s twice", k)
+		}
+		visited[k] = v
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ForEach returned %v", err)
+	}
+	if len(visited) != len(want) {
+		t.Errorf("ForEach visited %d entries, want %d", len(visited), len(want))
+	}
+	for k, v := range want {
+		if visited[k] != v {
+			t.Errorf("ForEach passed %s=%d, want %d", k, visited[k], v)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package fastmap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+// FileMap is a read-only map served straight from a file written by FileMapWriter. On Linux the
+// file is memory mapped, so opening costs no deserialization, lookups read only the pages they
+// touch and every process that opens the same file shares one copy in the page cache.
+// Values returned by Get, Values and ForEach alias the file: they must not be modified and are
+// only valid until Close. A FileMap is safe for concurrent use until it is closed.
+// Example:
+//
+//	dictionary, err := OpenFileMap("/var/lib/app/dictionary.fmap")
+//	if err != nil {
+//	    return err
+//	}
+//	defer dictionary.Close()
+//	definition, exists := dictionary.Get("apple")
+type FileMap struct {
+	data     []byte
+	body     []byte
+	records  []byte
+	table    []byte
+	mask     uint64
+	seed     uint64
+	count    int
+	checksum uint32
+	release  func() error
+}
+
+// OpenFileMap maps the file at path and verifies it, which reads the whole file once. Use
+// OpenFileMapUnverified to open trusted files without reading more than their last page.
+// Example:
+//
+//	dictionary, err := OpenFileMap("/var/lib/app/dictionary.fmap")
+func OpenFileMap(path string) (*FileMap, error) {
+	return openFileMap(path, true)
+}
+
+// OpenFileMapUnverified maps the file at path and checks only its footer, opening touches just
+// the last page of the file whatever its size. Lookups stay in bounds on damaged data but may
+// miss keys or return garbage, call Verify before trusting the contents.
+// Example:
+//
+//	dictionary, err := OpenFileMapUnverified("/var/lib/app/dictionary.fmap")
+func OpenFileMapUnverified(path string) (*FileMap, error) {
+	return openFileMap(path, false)
+}
+
+func openFileMap(path string, verify bool) (*FileMap, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file map: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("open file map: %w", err)
+	}
+	data, release, err := mapFile(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("open file map %s: %w", path, err)
+	}
+	m, err := NewFileMapUnverified(data)
+	if err == nil && verify {
+		err = m.Verify()
+	}
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("open file map %s: %w", path, err)
+	}
+	m.release = release
+	return m, nil
+}
+
+// NewFileMap serves lookups from data in the file map format without copying, after verifying
+// it in one pass. data must not change while the FileMap is in use.
+// Example:
+//
+//	dictionary, err := NewFileMap(embeddedDictionary)
+func NewFileMap(data []byte) (*FileMap, error) {
+	m, err := NewFileMapUnverified(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Verify(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewFileMapUnverified is NewFileMap without Verify: only the footer and the table bounds are
+// checked, in constant time. Lookups and iteration stay in bounds on damaged data but may miss
+// keys or return garbage until Verify succeeds.
+// Example:
+//
+//	dictionary, err := NewFileMapUnverified(embeddedDictionary)
+func NewFileMapUnverified(data []byte) (*FileMap, error) {
+	if len(data) < footerSize {
+		return nil, fmt.Errorf("%w: %d bytes is too short", ErrCorrupt, len(data))
+	}
+	body := data[:len(data)-footerSize]
+	f, ok := decodeFooter(data[len(body):])
+	if !ok {
+		return nil, fmt.Errorf("%w: bad footer", ErrCorrupt)
+	}
+	size := uint64(len(body))
+	if f.tableSlots < 2 || f.tableSlots&(f.tableSlots-1) != 0 || f.count >= f.tableSlots ||
+		f.tableSlots > size/slotSize || f.tableOffset != size-f.tableSlots*slotSize {
+		return nil, fmt.Errorf("%w: table of %d slots at %d in %d bytes", ErrCorrupt, f.tableSlots, f.tableOffset, size)
+	}
+	return &FileMap{
+		data:     data,
+		body:     body,
+		records:  body[:f.tableOffset],
+		table:    body[f.tableOffset:],
+		mask:     f.tableSlots - 1,
+		seed:     f.seed,
+		count:    int(f.count),
+		checksum: f.checksum,
+	}, nil
+}
+
+// Verify reads the whole file, checks its checksum and that the records tile the record area
+// with every table slot pointing at one of them. It returns an error wrapping ErrCorrupt on
+// damaged data. Verify costs one pass over the file and also brings it into the page cache.
+// Example:
+//
+//	dictionary, err := OpenFileMapUnverified(path)
+//	if err != nil {
+//	    return err
+//	}
+//	if err := dictionary.Verify(); err != nil {
+//	    dictionary.Close()
+//	    return err
+//	}
+func (m *FileMap) Verify() error {
+	if crc32.ChecksumIEEE(m.body) != m.checksum {
+		return fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+	records := 0
+	for offset := uint64(0); offset < uint64(len(m.records)); records++ {
+		key, value, ok := m.record(offset)
+		if !ok {
+			return fmt.Errorf("%w: record at %d overruns the record area", ErrCorrupt, offset)
+		}
+		offset += recordHeaderSize + uint64(len(key)) + uint64(len(value))
+	}
+	if records != m.count {
+		return fmt.Errorf("%w: %d records, footer says %d", ErrCorrupt, records, m.count)
+	}
+
+	used := 0
+	for index := uint64(0); index <= m.mask; index++ {
+		if _, offset := m.slot(index); offset != 0 {
+			if _, _, ok := m.record(offset - 1); !ok {
+				return fmt.Errorf("%w: slot %d points outside the record area", ErrCorrupt, index)
+			}
+			used++
+		}
+	}
+	if used != m.count {
+		return fmt.Errorf("%w: %d table entries, footer says %d", ErrCorrupt, used, m.count)
+	}
+	return nil
+}
+
+// slot returns the hash and record offset plus one stored in a table slot
+func (m *FileMap) slot(index uint64) (uint64, uint64) {
+	entry := m.table[index*slotSize : index*slotSize+slotSize]
+	return binary.LittleEndian.Uint64(entry), binary.LittleEndian.Uint64(entry[8:])
+}
+
+// record returns the key and value of the record at offset
+func (m *FileMap) record(offset uint64) ([]byte, []byte, bool) {
+	size := uint64(len(m.records))
+	if offset > size || size-offset < recordHeaderSize {
+		return nil, nil, false
+	}
+	keyLength := uint64(binary.LittleEndian.Uint32(m.records[offset:]))
+	valueLength := uint64(binary.LittleEndian.Uint32(m.records[offset+4:]))
+	start := offset + recordHeaderSize
+	if size-start < keyLength+valueLength {
+		return nil, nil, false
+	}
+	end := start + keyLength
+	return m.records[start:end:end], m.records[end : end+valueLength : end+valueLength], true
+}
+
+// Get returns the value stored for key without copying it
+// Example:
+//
+//	if definition, exists := dictionary.Get("apple"); exists {
+//	    fmt.Printf("apple: %s\n", definition)
+//	}
+func (m *FileMap) Get(key string) ([]byte, bool) {
+	if m.count == 0 {
+		return nil, false
+	}
+	hash := hashing.String(m.seed, key)
+	// The probe count is bounded so that a damaged table without empty slots cannot loop forever
+	index := hash & m.mask
+	for probes := uint64(0); probes <= m.mask; probes++ {
+		slotHash, offset := m.slot(index)
+		if offset == 0 {
+			return nil, false
+		}
+		if slotHash == hash {
+			if k, value, ok := m.record(offset - 1); ok && string(k) == key {
+				return value, true
+			}
+		}
+		index = (index + 1) & m.mask
+	}
+	return nil, false
+}
+
+// Contains checks if a key exists
+func (m *FileMap) Contains(key string) bool {
+	_, exists := m.Get(key)
+	return exists
+}
+
+// Size returns the number of elements
+func (m *FileMap) Size() int {
+	return m.count
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *FileMap) IsEmpty() bool {
+	return m.count == 0
+}
+
+// Keys returns a slice of all keys in the order they were written
+func (m *FileMap) Keys() []string {
+	keys := make([]string, 0, m.count)
+	m.each(func(key, _ []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	return keys
+}
+
+// Values returns a slice of all values in the order they were written, the values alias the file
+func (m *FileMap) Values() [][]byte {
+	values := make([][]byte, 0, m.count)
+	m.each(func(_, value []byte) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair in the order they were written and
+// returns an error if the callback fails
+// Example:
+//
+//	err := dictionary.ForEach(func(word string, definition []byte) error {
+//	    fmt.Printf("%s: %s\n", word, definition)
+//	    return nil
+//	})
+func (m *FileMap) ForEach(callback func(string, []byte) error) error {
+	var err error
+	complete := m.each(func(key, value []byte) bool {
+		if callbackErr := callback(string(key), value); callbackErr != nil {
+			err = fmt.Errorf("ForEach operation failed at key %v: %w", string(key), callbackErr)
+			return false
+		}
+		return true
+	})
+	if err == nil && !complete {
+		err = fmt.Errorf("%w: record overruns the record area", ErrCorrupt)
+	}
+	return err
+}
+
+// each walks the records in file order and reports false if it stopped at a damaged record
+func (m *FileMap) each(yield func(key, value []byte) bool) bool {
+	for offset := uint64(0); offset < uint64(len(m.records)); {
+		key, value, ok := m.record(offset)
+		if !ok {
+			return false
+		}
+		if !yield(key, value) {
+			return true
+		}
+		offset += recordHeaderSize + uint64(len(key)) + uint64(len(value))
+	}
+	return true
+}
+
+// Close unmaps the file, values obtained from the map must not be used afterwards. The map is
+// empty after Close.
+func (m *FileMap) Close() error {
+	release := m.release
+	*m = FileMap{}
+	if release != nil {
+		if err := release(); err != nil {
+			return fmt.Errorf("close file map: %w", err)
+		}
+	}
+	return nil
+}
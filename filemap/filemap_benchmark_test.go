@@ -0,0 +1,70 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	filemap "github.com/billowdev/fastmap/filemap"
+)
+
+const benchmarkEntries = 1 << 20
+
+func BenchmarkFileMapGet(b *testing.B) {
+	want := dictionary(benchmarkEntries)
+	path := filepath.Join(b.TempDir(), "dictionary.fmap")
+	if err := filemap.SaveFileMap(path, want); err != nil {
+		b.Fatal(err)
+	}
+	m, err := filemap.OpenFileMap(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer m.Close()
+	keys := make([]string, benchmarkEntries)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("word-%d", i)
+	}
+
+	b.Run("FileMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			m.Get(keys[i%benchmarkEntries])
+		}
+	})
+
+	b.Run("HashMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			want.Get(keys[i%benchmarkEntries])
+		}
+	})
+}
+
+func BenchmarkOpenFileMap(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "dictionary.fmap")
+	if err := filemap.SaveFileMap(path, dictionary(benchmarkEntries)); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m, err := filemap.OpenFileMap(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		m.Close()
+	}
+}
+
+func BenchmarkOpenFileMapUnverified(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "dictionary.fmap")
+	if err := filemap.SaveFileMap(path, dictionary(benchmarkEntries)); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m, err := filemap.OpenFileMapUnverified(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		m.Close()
+	}
+}
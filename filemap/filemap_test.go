@@ -0,0 +1,252 @@
+package fastmap_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/billowdev/fastmap"
+	filemap "github.com/billowdev/fastmap/filemap"
+	hashmap "github.com/billowdev/fastmap/hashmap"
+)
+
+var _ fastmap.ReadOnlyMap[string, []byte] = (*filemap.FileMap)(nil)
+
+func dictionary(n int) *hashmap.HashMap[string, []byte] {
+	m := hashmap.NewHashMap[string, []byte]()
+	for i := 0; i < n; i++ {
+		m.Put(fmt.Sprintf("word-%d", i), []byte(fmt.Sprintf("definition of word %d", i)))
+	}
+	m.Put("", []byte("empty key"))
+	m.Put("empty value", []byte{})
+	return m
+}
+
+func encode(t testing.TB, m *hashmap.HashMap[string, []byte]) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := filemap.WriteFileMap(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func assertSameContents(t *testing.T, got *filemap.FileMap, want *hashmap.HashMap[string, []byte]) {
+	t.Helper()
+	if got.Size() != want.Size() {
+		t.Fatalf("Size = %d, want %d", got.Size(), want.Size())
+	}
+	want.ForEach(func(key string, value []byte) error {
+		if v, exists := got.Get(key); !exists || !bytes.Equal(v, value) {
+			t.Fatalf("Get(%q) = (%q, %v), want %q", key, v, exists, value)
+		}
+		return nil
+	})
+	keys := got.Keys()
+	slices.Sort(keys)
+	wantKeys := want.Keys()
+	slices.Sort(wantKeys)
+	if !slices.Equal(keys, wantKeys) {
+		t.Errorf("Keys = %v, want %v", keys, wantKeys)
+	}
+	if len(got.Values()) != want.Size() {
+		t.Errorf("len(Values) = %d, want %d", len(got.Values()), want.Size())
+	}
+}
+
+func TestFileMapSaveAndOpen(t *testing.T) {
+	want := dictionary(10000)
+	path := filepath.Join(t.TempDir(), "dictionary.fmap")
+	if err := filemap.SaveFileMap(path, want); err != nil {
+		t.Fatal(err)
+	}
+	m, err := filemap.OpenFileMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameContents(t, m, want)
+	if m.Contains("missing") {
+		t.Error("Contains reported a missing key")
+	}
+	if value, exists := m.Get("missing"); exists || value != nil {
+		t.Errorf("Get(missing) = (%q, %v)", value, exists)
+	}
+
+	// Replacing the file leaves the open map intact
+	if err := filemap.SaveFileMap(path, dictionary(10)); err != nil {
+		t.Fatal(err)
+	}
+	assertSameContents(t, m, want)
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Size() != 0 || m.Contains("word-1") {
+		t.Error("closed map still has entries")
+	}
+	if _, err := filemap.OpenFileMap(filepath.Join(t.TempDir(), "missing.fmap")); err == nil {
+		t.Error("opening a missing file succeeded")
+	}
+}
+
+func TestFileMapEmpty(t *testing.T) {
+	m, err := filemap.NewFileMap(encode(t, hashmap.NewHashMap[string, []byte]()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.IsEmpty() || m.Size() != 0 || m.Contains("") || len(m.Keys()) != 0 {
+		t.Error("empty file map has entries")
+	}
+}
+
+func TestFileMapGetDoesNotAllocate(t *testing.T) {
+	m, err := filemap.NewFileMap(encode(t, dictionary(1000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		m.Get("word-500")
+		m.Get("missing")
+	})
+	if allocs != 0 {
+		t.Errorf("Get allocated %.0f times", allocs)
+	}
+}
+
+func TestFileMapWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := filemap.NewFileMapWriter(&buf)
+	for _, key := range []string{"c", "a", "b"} {
+		if err := w.Put(key, []byte(key+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Put("a", nil); !errors.Is(err, filemap.ErrDuplicateKey) {
+		t.Errorf("duplicate Put error = %v, want ErrDuplicateKey", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Put("d", nil); !errors.Is(err, filemap.ErrWriterClosed) {
+		t.Errorf("Put after Close error = %v, want ErrWriterClosed", err)
+	}
+	if err := w.Close(); !errors.Is(err, filemap.ErrWriterClosed) {
+		t.Errorf("second Close error = %v, want ErrWriterClosed", err)
+	}
+
+	m, err := filemap.NewFileMap(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := m.Keys(); !slices.Equal(keys, []string{"c", "a", "b"}) {
+		t.Errorf("Keys = %v, want write order", keys)
+	}
+	stop := errors.New("stop")
+	err = m.ForEach(func(key string, value []byte) error {
+		if key == "a" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("ForEach error = %v, want %v", err, stop)
+	}
+}
+
+func TestFileMapRejectsCorruption(t *testing.T) {
+	valid := encode(t, dictionary(20))
+	for i := range valid {
+		for bit := 0; bit < 8; bit++ {
+			data := bytes.Clone(valid)
+			data[i] ^= 1 << bit
+			if _, err := filemap.NewFileMap(data); !errors.Is(err, filemap.ErrCorrupt) {
+				t.Fatalf("flipping bit %d of byte %d: error = %v, want ErrCorrupt", bit, i, err)
+			}
+		}
+	}
+	for n := 0; n < len(valid); n++ {
+		if _, err := filemap.NewFileMap(valid[:n]); !errors.Is(err, filemap.ErrCorrupt) {
+			t.Fatalf("truncated to %d bytes: error = %v, want ErrCorrupt", n, err)
+		}
+	}
+	if _, err := filemap.NewFileMap(append(bytes.Clone(valid), 0)); !errors.Is(err, filemap.ErrCorrupt) {
+		t.Errorf("trailing byte: error = %v, want ErrCorrupt", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "damaged.fmap")
+	data := bytes.Clone(valid)
+	data[0] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filemap.OpenFileMap(path); !errors.Is(err, filemap.ErrCorrupt) {
+		t.Errorf("OpenFileMap of a damaged record area: error = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestFileMapUnverified(t *testing.T) {
+	valid := encode(t, dictionary(20))
+	m, err := filemap.NewFileMapUnverified(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(); err != nil {
+		t.Errorf("Verify of a valid map failed: %v", err)
+	}
+
+	// Damage inside the records is only found by Verify, lookups stay in bounds meanwhile
+	data := bytes.Clone(valid)
+	data[0] = 0xff
+	m, err = filemap.NewFileMapUnverified(data)
+	if err != nil {
+		t.Fatalf("NewFileMapUnverified checked more than the footer: %v", err)
+	}
+	if err := m.Verify(); !errors.Is(err, filemap.ErrCorrupt) {
+		t.Errorf("Verify error = %v, want ErrCorrupt", err)
+	}
+	for _, key := range dictionary(20).Keys() {
+		m.Get(key)
+	}
+	if err := m.ForEach(func(string, []byte) error { return nil }); !errors.Is(err, filemap.ErrCorrupt) {
+		t.Errorf("ForEach over a damaged record area: error = %v, want ErrCorrupt", err)
+	}
+}
+
+func FuzzNewFileMap(f *testing.F) {
+	f.Add(encode(f, dictionary(3)))
+	f.Add(encode(f, hashmap.NewHashMap[string, []byte]()))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, verifiedErr := filemap.NewFileMap(data)
+		m, err := filemap.NewFileMapUnverified(data)
+		if err != nil {
+			if !errors.Is(err, filemap.ErrCorrupt) {
+				t.Fatalf("error %v is not ErrCorrupt", err)
+			}
+			return
+		}
+		// Unverified data must never make lookups or iteration read out of bounds
+		for _, key := range m.Keys() {
+			m.Get(key)
+		}
+		m.Get("missing")
+		m.ForEach(func(string, []byte) error { return nil })
+		if err := m.Verify(); err != nil {
+			if verifiedErr == nil {
+				t.Fatalf("NewFileMap accepted data that Verify rejects: %v", err)
+			}
+			return
+		}
+		if verifiedErr != nil {
+			t.Fatalf("NewFileMap rejected data that Verify accepts: %v", verifiedErr)
+		}
+		for _, key := range m.Keys() {
+			if _, exists := m.Get(key); !exists {
+				t.Fatalf("verified map is missing key %q", key)
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package fastmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// A file map is laid out as records, then the hash table, then a fixed size footer, so it can
+// be written in one pass to any io.Writer:
+//
+//	record: key length u32 | value length u32 | key | value
+//	slot:   key hash u64 | record offset + 1 u64, zero marks an empty slot
+//	footer: magic | seed u64 | count u64 | table offset u64 | table slots u64 |
+//	        crc32 of records and table u32 | crc32 of the footer before it u32
+//
+// Integers are little endian and keys are hashed with the seed stored in the footer.
+const (
+	recordHeaderSize = 8
+	slotSize         = 16
+	footerSize       = 48
+)
+
+// footerMagic identifies a file map, the last byte is the format version
+var footerMagic = [8]byte{'F', 'M', 'F', 'I', 'L', 'E', 'M', 1}
+
+var (
+	// ErrCorrupt is returned when opening data that is not an intact file map
+	ErrCorrupt = errors.New("file map: corrupt file")
+	// ErrDuplicateKey is returned when the same key is written twice
+	ErrDuplicateKey = errors.New("file map: duplicate key")
+	// ErrWriterClosed is returned by writes after Close
+	ErrWriterClosed = errors.New("file map: writer is closed")
+)
+
+type footer struct {
+	seed        uint64
+	count       uint64
+	tableOffset uint64
+	tableSlots  uint64
+	checksum    uint32
+}
+
+func (f footer) encode() []byte {
+	data := make([]byte, 0, footerSize)
+	data = append(data, footerMagic[:]...)
+	data = binary.LittleEndian.AppendUint64(data, f.seed)
+	data = binary.LittleEndian.AppendUint64(data, f.count)
+	data = binary.LittleEndian.AppendUint64(data, f.tableOffset)
+	data = binary.LittleEndian.AppendUint64(data, f.tableSlots)
+	data = binary.LittleEndian.AppendUint32(data, f.checksum)
+	return binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+}
+
+func decodeFooter(data []byte) (footer, bool) {
+	if len(data) != footerSize || [8]byte(data[:8]) != footerMagic {
+		return footer{}, false
+	}
+	if crc32.ChecksumIEEE(data[:footerSize-4]) != binary.LittleEndian.Uint32(data[footerSize-4:]) {
+		return footer{}, false
+	}
+	return footer{
+		seed:        binary.LittleEndian.Uint64(data[8:]),
+		count:       binary.LittleEndian.Uint64(data[16:]),
+		tableOffset: binary.LittleEndian.Uint64(data[24:]),
+		tableSlots:  binary.LittleEndian.Uint64(data[32:]),
+		checksum:    binary.LittleEndian.Uint32(data[40:]),
+	}, true
+}
+
+// tableSlots returns the table size for count keys, a power of two at most half full
+func tableSlots(count int) uint64 {
+	slots := uint64(2)
+	for slots < 2*uint64(count) {
+		slots <<= 1
+	}
+	return slots
+}
@@ -0,0 +1,28 @@
+package fastmap
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of file read-only and shared, so processes opening the same file share
+// its pages
+func mapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	if size == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	if int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("file of %d bytes is too large to map", size)
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mmap: %w", err)
+	}
+	// Lookups jump around the table, read-ahead would mostly fetch pages nobody asked for
+	if err := syscall.Madvise(data, syscall.MADV_RANDOM); err != nil {
+		syscall.Munmap(data)
+		return nil, nil, fmt.Errorf("madvise: %w", err)
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
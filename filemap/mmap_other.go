@@ -0,0 +1,18 @@
+//go:build !linux
+
+package fastmap
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// mapFile reads the whole file into memory on platforms without a memory mapped reader
+func mapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, nil, fmt.Errorf("read: %w", err)
+	}
+	return data, func() error { return nil }, nil
+}
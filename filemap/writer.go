@@ -0,0 +1,188 @@
+package fastmap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	hashmap "github.com/billowdev/fastmap/hashmap"
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+// FileMapWriter streams entries into the file map format. Records are written as they come,
+// the hash table and footer are written by Close, so memory use is 16 bytes per key plus the set
+// of keys used to reject duplicates.
+// Example:
+//
+//	writer := NewFileMapWriter(file)
+//	for word, definition := range dictionary {
+//	    if err := writer.Put(word, definition); err != nil {
+//	        return err
+//	    }
+//	}
+//	err := writer.Close()
+type FileMapWriter struct {
+	out      *bufio.Writer
+	checksum hash.Hash32
+	seed     uint64
+	offset   uint64
+	slots    []fileSlot
+	seen     map[string]struct{}
+	closed   bool
+}
+
+type fileSlot struct {
+	hash   uint64
+	offset uint64
+}
+
+// NewFileMapWriter creates a writer that writes a file map to w. Close must be called to finish
+// the file, it does not close w.
+// Example:
+//
+//	writer := NewFileMapWriter(file)
+func NewFileMapWriter(w io.Writer) *FileMapWriter {
+	checksum := crc32.NewIEEE()
+	return &FileMapWriter{
+		out:      bufio.NewWriter(io.MultiWriter(w, checksum)),
+		checksum: checksum,
+		seed:     hashing.RandomSeed(),
+		seen:     make(map[string]struct{}),
+	}
+}
+
+// Put appends an entry, returning ErrDuplicateKey for a key that was already written
+// Example:
+//
+//	err := writer.Put("apple", []byte("a round fruit"))
+func (w *FileMapWriter) Put(key string, value []byte) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if _, exists := w.seen[key]; exists {
+		return fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+	}
+	if uint64(len(key)) > math.MaxUint32 || uint64(len(value)) > math.MaxUint32 {
+		return fmt.Errorf("file map entry %q: key or value larger than 4 GiB", key)
+	}
+
+	var header [recordHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[0:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(value)))
+	if _, err := w.out.Write(header[:]); err != nil {
+		return fmt.Errorf("write file map record: %w", err)
+	}
+	if _, err := w.out.WriteString(key); err != nil {
+		return fmt.Errorf("write file map record: %w", err)
+	}
+	if _, err := w.out.Write(value); err != nil {
+		return fmt.Errorf("write file map record: %w", err)
+	}
+
+	w.seen[key] = struct{}{}
+	w.slots = append(w.slots, fileSlot{hash: hashing.String(w.seed, key), offset: w.offset})
+	w.offset += recordHeaderSize + uint64(len(key)) + uint64(len(value))
+	return nil
+}
+
+// Close writes the hash table and the footer and flushes the output
+// Example:
+//
+//	if err := writer.Close(); err != nil {
+//	    return err
+//	}
+func (w *FileMapWriter) Close() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	w.closed = true
+	w.seen = nil
+
+	slots := tableSlots(len(w.slots))
+	table := make([]byte, slots*slotSize)
+	mask := slots - 1
+	for _, slot := range w.slots {
+		index := slot.hash & mask
+		for binary.LittleEndian.Uint64(table[index*slotSize+8:]) != 0 {
+			index = (index + 1) & mask
+		}
+		binary.LittleEndian.PutUint64(table[index*slotSize:], slot.hash)
+		binary.LittleEndian.PutUint64(table[index*slotSize+8:], slot.offset+1)
+	}
+	if _, err := w.out.Write(table); err != nil {
+		return fmt.Errorf("write file map table: %w", err)
+	}
+	if err := w.out.Flush(); err != nil {
+		return fmt.Errorf("write file map table: %w", err)
+	}
+
+	f := footer{
+		seed:        w.seed,
+		count:       uint64(len(w.slots)),
+		tableOffset: w.offset,
+		tableSlots:  slots,
+		checksum:    w.checksum.Sum32(),
+	}
+	w.slots = nil
+	if _, err := w.out.Write(f.encode()); err != nil {
+		return fmt.Errorf("write file map footer: %w", err)
+	}
+	if err := w.out.Flush(); err != nil {
+		return fmt.Errorf("write file map footer: %w", err)
+	}
+	return nil
+}
+
+// WriteFileMap writes the contents of m to w as a file map
+// Example:
+//
+//	err := WriteFileMap(file, dictionary)
+func WriteFileMap(w io.Writer, m *hashmap.HashMap[string, []byte]) error {
+	writer := NewFileMapWriter(w)
+	err := m.ForEach(func(key string, value []byte) error {
+		return writer.Put(key, value)
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// SaveFileMap writes m to a temporary file next to path and renames it into place, so readers
+// that have the old file mapped keep a consistent view and new readers see the complete file
+// Example:
+//
+//	err := SaveFileMap("/var/lib/app/dictionary.fmap", dictionary)
+func SaveFileMap(path string, m *hashmap.HashMap[string, []byte]) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("save file map: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save file map: %w", err)
+	}
+	if err := WriteFileMap(tmp, m); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save file map: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save file map: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save file map: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("save file map: %w", err)
+	}
+	return nil
+}
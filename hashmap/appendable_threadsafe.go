@@ -48,6 +48,7 @@ func (t *ThreadSafeAppendableHashMap[K, V]) AppendValues(key K, values ...V) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
+	t.recordPreimage(key)
 	if existing, exists := t.data.Get(key); exists {
 		t.data.Put(key, append(existing, values...))
 	} else {
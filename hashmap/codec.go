@@ -0,0 +1,58 @@
+package fastmap
+
+import "encoding/json"
+
+// Codec converts keys or values to and from bytes for persistent map types
+// Example:
+//
+//	var keyCodec Codec[string] = StringCodec{}
+//	var valueCodec Codec[User] = JSONCodec[User]{}
+type Codec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// StringCodec encodes strings as their raw bytes
+type StringCodec struct{}
+
+// Encode returns the bytes of the string
+func (StringCodec) Encode(value string) ([]byte, error) {
+	return []byte(value), nil
+}
+
+// Decode returns the bytes as a string
+func (StringCodec) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// BytesCodec stores byte slices unchanged, Decode returns a copy of the input
+type BytesCodec struct{}
+
+// Encode returns the byte slice as is
+func (BytesCodec) Encode(value []byte) ([]byte, error) {
+	return value, nil
+}
+
+// Decode returns a copy of the input bytes
+func (BytesCodec) Decode(data []byte) ([]byte, error) {
+	return append([]byte(nil), data...), nil
+}
+
+// JSONCodec encodes any JSON-serializable type using encoding/json
+// Example:
+//
+//	codec := JSONCodec[User]{}
+//	data, err := codec.Encode(User{Name: "John"})
+type JSONCodec[T any] struct{}
+
+// Encode marshals the value to JSON
+func (JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode unmarshals JSON into a new value
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
@@ -43,6 +43,7 @@ func (t *ThreadSafeHashMap[K, V]) ApplyFieldConfig(
 ) bool {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	t.recordPreimage(key)
 	return t.data.ApplyFieldConfig(key, config, data)
 }
 
@@ -69,5 +70,8 @@ func (t *ThreadSafeHashMap[K, V]) ProcessFieldConfigs(
 ) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	for key := range configs {
+		t.recordPreimage(key)
+	}
 	t.data.ProcessFieldConfigs(configs, data, processor)
 }
@@ -0,0 +1,67 @@
+package fastmap_test
+
+import (
+	"testing"
+
+	"github.com/billowdev/fastmap"
+	"github.com/billowdev/fastmap/fastmaptest"
+	hashmap "github.com/billowdev/fastmap/hashmap"
+)
+
+var (
+	_ fastmap.Map[string, int]         = (*hashmap.HashMap[string, int])(nil)
+	_ fastmap.Map[string, int]         = (*hashmap.ThreadSafeHashMap[string, int])(nil)
+	_ fastmap.Map[string, int]         = (*hashmap.SmallHashMap[string, int])(nil)
+	_ fastmap.ReadOnlyMap[string, int] = (*hashmap.ImmutableHashMap[string, int])(nil)
+	_ fastmap.ReadOnlyMap[string, int] = (*hashmap.Snapshot[string, int])(nil)
+)
+
+func TestHashMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return hashmap.NewHashMap[string, int]()
+	})
+}
+
+func TestThreadSafeHashMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return hashmap.NewThreadSafeHashMap[string, int]()
+	})
+}
+
+func TestSmallHashMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return hashmap.NewSmallHashMap[string, int]()
+	})
+	t.Run("Shrinking", func(t *testing.T) {
+		fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+			return hashmap.NewSmallHashMapWithShrink[string, int](4)
+		})
+	})
+}
+
+func TestImmutableHashMapConformance(t *testing.T) {
+	fastmaptest.RunReadOnlyMapSuite(t, func(entries map[string]int) fastmap.ReadOnlyMap[string, int] {
+		builder := hashmap.NewImmutableHashMap[string, int]().Transient()
+		for k, v := range entries {
+			builder.Put(k, v)
+		}
+		return builder.Persistent()
+	})
+}
+
+func TestSnapshotConformance(t *testing.T) {
+	fastmaptest.RunReadOnlyMapSuite(t, func(entries map[string]int) fastmap.ReadOnlyMap[string, int] {
+		m := hashmap.NewThreadSafeHashMap[string, int]()
+		for k, v := range entries {
+			m.Put(k, v)
+		}
+		snapshot := m.Snapshot()
+		// Writes after the snapshot must not show through it
+		m.Put("after-snapshot", 1)
+		for k := range entries {
+			m.Remove(k)
+		}
+		t.Cleanup(snapshot.Release)
+		return snapshot
+	})
+}
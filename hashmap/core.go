@@ -39,12 +39,21 @@ func (h *HashMap[K, V]) Get(key K) (V, bool) {
 	return value, exists
 }
 
-// Remove deletes a key-value pair from the HashMap
+// Remove deletes a key-value pair from the HashMap and returns whether the key existed.
+// Breaking change: Remove returned nothing before the Map interface was introduced. Calls
+// that ignore the result compile unchanged, method values and interfaces declaring
+// Remove(K) without a result must be updated.
 // Example:
 //
-//	hashMap.Remove("user123")
-func (h *HashMap[K, V]) Remove(key K) {
+//	if hashMap.Remove("user123") {
+//	    fmt.Println("User removed")
+//	}
+func (h *HashMap[K, V]) Remove(key K) bool {
+	if _, exists := h.data[key]; !exists {
+		return false
+	}
 	delete(h.data, key)
+	return true
 }
 
 // Size returns the number of elements in the HashMap
@@ -10,8 +10,9 @@ import (
 //	safeMap := NewThreadSafeHashMap[string, User]()
 //	safeMap.Put("user1", User{Name: "John"})
 type ThreadSafeHashMap[K comparable, V any] struct {
-	mutex sync.RWMutex
-	data  *HashMap[K, V]
+	mutex     sync.RWMutex
+	data      *HashMap[K, V]
+	snapshots map[*snapshotState[K, V]]struct{}
 }
 
 // NewThreadSafeHashMap creates a new thread-safe HashMap
@@ -31,6 +32,7 @@ func NewThreadSafeHashMap[K comparable, V any]() *ThreadSafeHashMap[K, V] {
 func (t *ThreadSafeHashMap[K, V]) Put(key K, value V) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	t.recordPreimage(key)
 	t.data.Put(key, value)
 }
 
@@ -46,14 +48,18 @@ func (t *ThreadSafeHashMap[K, V]) Get(key K) (V, bool) {
 	return t.data.Get(key)
 }
 
-// Remove deletes a key-value pair from the ThreadSafeHashMap with write lock
+// Remove deletes a key-value pair from the ThreadSafeHashMap with write lock and returns whether the key existed.
+// Breaking change: Remove returned nothing before the Map interface was introduced, see HashMap.Remove.
 // Example:
 //
-//	safeMap.Remove("user123")
-func (t *ThreadSafeHashMap[K, V]) Remove(key K) {
+//	if safeMap.Remove("user123") {
+//	    fmt.Println("User removed")
+//	}
+func (t *ThreadSafeHashMap[K, V]) Remove(key K) bool {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	t.data.Remove(key)
+	t.recordPreimage(key)
+	return t.data.Remove(key)
 }
 
 // Clear removes all elements from the ThreadSafeHashMap with write lock
@@ -64,6 +70,7 @@ func (t *ThreadSafeHashMap[K, V]) Remove(key K) {
 func (t *ThreadSafeHashMap[K, V]) Clear() {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	t.detachSnapshots()
 	t.data.Clear()
 }
 
@@ -0,0 +1,1033 @@
+package fastmap
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDiskMapClosed is returned by operations on a closed DiskHashMap
+var ErrDiskMapClosed = errors.New("disk map is closed")
+
+// ErrDiskMapBroken is returned by writes after a failed append could not be undone
+var ErrDiskMapBroken = errors.New("disk map is broken")
+
+// ErrCorruptSegment is returned on open when a damaged record is followed by further data in a
+// segment, such a segment is not the result of an interrupted append and is never truncated
+var ErrCorruptSegment = errors.New("disk map segment is corrupt")
+
+const (
+	segmentExt  = ".data"
+	hintExt     = ".hint"
+	hintTempExt = ".hint.tmp"
+
+	defaultMaxSegmentSize = 64 << 20
+
+	// segment record: crc32 | seq | key length | value length | key | value
+	segmentHeaderSize = 20
+	// hint record: seq | key length | value size | value offset | key
+	hintHeaderSize = 24
+
+	tombstoneMarker = ^uint32(0)
+	clearMarker     = ^uint32(0) - 1
+)
+
+// DiskConfig configures a DiskHashMap
+//   - Dir: directory holding the segment and hint files, created if missing
+//   - KeyCodec, ValueCodec: serialization of keys and values
+//   - MaxSegmentSize: size in bytes after which a new segment is started, defaults to 64 MiB
+//   - MergeInterval: period of background merges, zero disables them
+//   - ReadCacheSize: number of recently read values kept in memory, zero disables the cache
+//   - SyncWrites: fsync the active segment after every write
+type DiskConfig[K comparable, V any] struct {
+	Dir            string
+	KeyCodec       Codec[K]
+	ValueCodec     Codec[V]
+	MaxSegmentSize int64
+	MergeInterval  time.Duration
+	ReadCacheSize  int
+	SyncWrites     bool
+}
+
+// valueLocation points at the value bytes of the latest record for a key
+type valueLocation struct {
+	segment uint64
+	offset  int64
+	size    uint32
+	seq     uint64
+}
+
+// DiskHashMap is a Bitcask-style map that keeps its keys in an in-memory HashMap index and
+// its values in append-only segment files. Every write appends a record, Merge rewrites the
+// live values of older segments and writes hint files so that reopening does not scan values.
+// Method names follow HashMap, operations that touch the disk also return an error.
+// Example:
+//
+//	store, err := OpenDiskHashMap(DiskConfig[string, []byte]{
+//	    Dir:        "/var/lib/blobs",
+//	    KeyCodec:   StringCodec{},
+//	    ValueCodec: BytesCodec{},
+//	})
+//	defer store.Close()
+//	err = store.Put("image1", data)
+type DiskHashMap[K comparable, V any] struct {
+	config DiskConfig[K, V]
+
+	mutex      sync.RWMutex
+	index      *HashMap[K, valueLocation]
+	segments   map[uint64]*os.File
+	active     logFile
+	activeID   uint64
+	activeSize int64
+	nextID     uint64
+	nextSeq    uint64
+	stale      int64
+	closed     bool
+
+	mergeMutex sync.Mutex
+	cache      *readCache[K, V]
+	stop       chan struct{}
+	done       sync.WaitGroup
+
+	// mergeErr holds the last failure of a background merge until Sync or Close reports it
+	mergeErr error
+	// broken is set when a failed append could not be rolled back
+	broken error
+}
+
+// OpenDiskHashMap opens or creates a DiskHashMap in config.Dir and rebuilds the key index
+// from hint files, or from the segments themselves where no hint file exists. A partially
+// written record at the end of a segment is truncated, a damaged record followed by further
+// data fails with ErrCorruptSegment and leaves the segment untouched.
+// Example:
+//
+//	store, err := OpenDiskHashMap(DiskConfig[string, Document]{
+//	    Dir:           dir,
+//	    KeyCodec:      StringCodec{},
+//	    ValueCodec:    JSONCodec[Document]{},
+//	    MergeInterval: time.Hour,
+//	    ReadCacheSize: 1024,
+//	})
+func OpenDiskHashMap[K comparable, V any](config DiskConfig[K, V]) (*DiskHashMap[K, V], error) {
+	if config.Dir == "" {
+		return nil, errors.New("open disk map: Dir is empty")
+	}
+	if config.KeyCodec == nil || config.ValueCodec == nil {
+		return nil, errors.New("open disk map: KeyCodec and ValueCodec are required")
+	}
+	if config.MaxSegmentSize <= 0 {
+		config.MaxSegmentSize = defaultMaxSegmentSize
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("open disk map: %w", err)
+	}
+
+	d := &DiskHashMap[K, V]{
+		config:   config,
+		index:    NewHashMap[K, valueLocation](),
+		segments: make(map[uint64]*os.File),
+		stop:     make(chan struct{}),
+	}
+	if config.ReadCacheSize > 0 {
+		d.cache = newReadCache[K, V](config.ReadCacheSize)
+	}
+	if err := d.load(); err != nil {
+		d.closeFiles()
+		return nil, fmt.Errorf("open disk map: %w", err)
+	}
+	if err := d.rotate(); err != nil {
+		d.closeFiles()
+		return nil, fmt.Errorf("open disk map: %w", err)
+	}
+	if config.MergeInterval > 0 {
+		d.done.Add(1)
+		go d.mergeLoop()
+	}
+	return d, nil
+}
+
+// Put appends the key-value pair to the active segment and updates the index.
+// Returns ErrRecordTooLarge when the encoded key and value exceed 1 GiB. A failed append is cut
+// off the segment again, ErrDiskMapBroken is returned from then on if that is not possible.
+// Example:
+//
+//	if err := store.Put("image1", data); err != nil {
+//	    return err
+//	}
+func (d *DiskHashMap[K, V]) Put(key K, value V) error {
+	keyData, err := d.config.KeyCodec.Encode(key)
+	if err != nil {
+		return fmt.Errorf("encode key %v: %w", key, err)
+	}
+	valueData, err := d.config.ValueCodec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("encode value for key %v: %w", key, err)
+	}
+	if err := checkSegmentRecord(keyData, valueData); err != nil {
+		return fmt.Errorf("put key %v: %w", key, err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.closed {
+		return ErrDiskMapClosed
+	}
+	location, err := d.appendLocked(keyData, valueData, uint32(len(valueData)))
+	if err != nil {
+		return err
+	}
+	d.markStale(key)
+	d.index.Put(key, location)
+	if d.cache != nil {
+		d.cache.remove(key)
+	}
+	return nil
+}
+
+// Get reads the value for a key from its segment, or from the read cache
+// Example:
+//
+//	data, exists, err := store.Get("image1")
+//	if err != nil {
+//	    return err
+//	}
+func (d *DiskHashMap[K, V]) Get(key K) (V, bool, error) {
+	var zero V
+	if d.cache != nil {
+		if value, exists := d.cache.get(key); exists {
+			return value, true, nil
+		}
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.closed {
+		return zero, false, ErrDiskMapClosed
+	}
+	location, exists := d.index.Get(key)
+	if !exists {
+		return zero, false, nil
+	}
+	data, err := d.readValue(location)
+	if err != nil {
+		return zero, false, fmt.Errorf("read key %v: %w", key, err)
+	}
+
+	value, err := d.config.ValueCodec.Decode(data)
+	if err != nil {
+		return zero, false, fmt.Errorf("decode value for key %v: %w", key, err)
+	}
+	// Filling the cache under the read lock keeps it from racing with a concurrent Put
+	if d.cache != nil {
+		d.cache.put(key, value)
+	}
+	return value, true, nil
+}
+
+// Remove appends a tombstone for the key and drops it from the index, removing a missing key is a no-op
+// Example:
+//
+//	err := store.Remove("image1")
+func (d *DiskHashMap[K, V]) Remove(key K) error {
+	keyData, err := d.config.KeyCodec.Encode(key)
+	if err != nil {
+		return fmt.Errorf("encode key %v: %w", key, err)
+	}
+	if err := checkSegmentRecord(keyData, nil); err != nil {
+		return fmt.Errorf("remove key %v: %w", key, err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.closed {
+		return ErrDiskMapClosed
+	}
+	if !d.index.Contains(key) {
+		return nil
+	}
+	if _, err := d.appendLocked(keyData, nil, tombstoneMarker); err != nil {
+		return err
+	}
+	d.markStale(key)
+	d.index.Remove(key)
+	if d.cache != nil {
+		d.cache.remove(key)
+	}
+	return nil
+}
+
+// Clear removes all elements by writing a clear marker and deleting the older segments
+// Example:
+//
+//	err := store.Clear()
+func (d *DiskHashMap[K, V]) Clear() error {
+	d.mergeMutex.Lock()
+	defer d.mergeMutex.Unlock()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.closed {
+		return ErrDiskMapClosed
+	}
+	if err := d.rotate(); err != nil {
+		return err
+	}
+	// The marker hides every older record on replay, even if deleting a segment below fails
+	if _, err := d.appendLocked(nil, nil, clearMarker); err != nil {
+		return err
+	}
+	if err := d.active.Sync(); err != nil {
+		return fmt.Errorf("sync segment: %w", err)
+	}
+	for id := range d.segments {
+		if id != d.activeID {
+			d.removeSegment(id)
+		}
+	}
+	d.index.Clear()
+	d.stale = 0
+	if d.cache != nil {
+		d.cache.clear()
+	}
+	return nil
+}
+
+// Contains checks if a key exists without reading its value
+func (d *DiskHashMap[K, V]) Contains(key K) bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.index.Contains(key)
+}
+
+// Size returns the number of keys
+func (d *DiskHashMap[K, V]) Size() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.index.Size()
+}
+
+// IsEmpty returns true if the map has no elements
+func (d *DiskHashMap[K, V]) IsEmpty() bool {
+	return d.Size() == 0
+}
+
+// Keys returns a slice of all keys without reading any value
+func (d *DiskHashMap[K, V]) Keys() []K {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.index.Keys()
+}
+
+// ForEach reads every value from disk and executes the callback for each key-value pair
+// Example:
+//
+//	err := store.ForEach(func(key string, data []byte) error {
+//	    fmt.Printf("%s: %d bytes\n", key, len(data))
+//	    return nil
+//	})
+func (d *DiskHashMap[K, V]) ForEach(callback func(K, V) error) error {
+	for _, key := range d.Keys() {
+		value, exists, err := d.Get(key)
+		if err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", key, err)
+		}
+		if !exists {
+			continue
+		}
+		if err := callback(key, value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Sync flushes the active segment to stable storage and reports a failed background merge
+// if one occurred since the last Sync
+func (d *DiskHashMap[K, V]) Sync() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.closed {
+		return ErrDiskMapClosed
+	}
+	if err := d.active.Sync(); err != nil {
+		return fmt.Errorf("sync segment: %w", err)
+	}
+	return d.takeMergeErr()
+}
+
+// Merge rewrites the live values of all inactive segments into new segments with hint files
+// and deletes the old ones. Reads and writes continue while values are being copied.
+// Example:
+//
+//	if err := store.Merge(); err != nil {
+//	    log.Printf("merge failed: %v", err)
+//	}
+func (d *DiskHashMap[K, V]) Merge() error {
+	d.mergeMutex.Lock()
+	defer d.mergeMutex.Unlock()
+
+	// Seal the active segment so that everything written so far can be merged
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return ErrDiskMapClosed
+	}
+	if err := d.rotate(); err != nil {
+		d.mutex.Unlock()
+		return err
+	}
+	merging := make(map[uint64]bool, len(d.segments))
+	for id := range d.segments {
+		if id != d.activeID {
+			merging[id] = true
+		}
+	}
+	type liveEntry struct {
+		key      K
+		location valueLocation
+	}
+	live := make([]liveEntry, 0, d.index.Size())
+	for key, location := range d.index.data {
+		if merging[location.segment] {
+			live = append(live, liveEntry{key, location})
+		}
+	}
+	mergedStale := d.stale
+	d.mutex.Unlock()
+
+	if len(merging) == 0 {
+		return nil
+	}
+
+	writer := &mergeWriter{dir: d.config.Dir, maxSize: d.config.MaxSegmentSize, allocate: d.allocateID}
+	moved := make(map[K]valueLocation, len(live))
+	for _, entry := range live {
+		d.mutex.RLock()
+		data, err := d.readValue(entry.location)
+		d.mutex.RUnlock()
+		if err != nil {
+			writer.abort()
+			return fmt.Errorf("merge: read key %v: %w", entry.key, err)
+		}
+		keyData, err := d.config.KeyCodec.Encode(entry.key)
+		if err != nil {
+			writer.abort()
+			return fmt.Errorf("merge: encode key %v: %w", entry.key, err)
+		}
+		location, err := writer.write(keyData, data, entry.location.seq)
+		if err != nil {
+			writer.abort()
+			return fmt.Errorf("merge: %w", err)
+		}
+		moved[entry.key] = location
+	}
+	files, err := writer.finish()
+	if err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for id, file := range files {
+		d.segments[id] = file
+	}
+	for _, entry := range live {
+		// Keys written or removed during the merge keep their newer location
+		if current, exists := d.index.Get(entry.key); exists && current == entry.location {
+			d.index.Put(entry.key, moved[entry.key])
+		}
+	}
+	for id := range merging {
+		d.removeSegment(id)
+	}
+	// Bytes made stale while values were copied may still sit in the active segment
+	d.stale -= mergedStale
+	if d.stale < 0 {
+		d.stale = 0
+	}
+	return nil
+}
+
+// Close stops background merges and closes all segment files, reporting a failed background
+// merge that no Sync has returned yet
+// Example:
+//
+//	defer store.Close()
+func (d *DiskHashMap[K, V]) Close() error {
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.stop)
+	d.mutex.Unlock()
+	d.done.Wait()
+
+	d.mergeMutex.Lock()
+	defer d.mergeMutex.Unlock()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	syncErr := d.active.Sync()
+	if err := d.closeFiles(); err != nil {
+		return err
+	}
+	if syncErr != nil {
+		return fmt.Errorf("sync segment: %w", syncErr)
+	}
+	return d.takeMergeErr()
+}
+
+func (d *DiskHashMap[K, V]) mergeLoop() {
+	defer d.done.Done()
+	ticker := time.NewTicker(d.config.MergeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.mutex.RLock()
+			needed := d.stale > 0
+			d.mutex.RUnlock()
+			if !needed {
+				continue
+			}
+			if err := d.Merge(); err != nil && !errors.Is(err, ErrDiskMapClosed) {
+				d.mutex.Lock()
+				d.mergeErr = fmt.Errorf("background merge: %w", err)
+				d.mutex.Unlock()
+			}
+		}
+	}
+}
+
+// takeMergeErr returns and clears the last background merge failure, d.mutex must be held
+func (d *DiskHashMap[K, V]) takeMergeErr() error {
+	err := d.mergeErr
+	d.mergeErr = nil
+	return err
+}
+
+// appendLocked writes a record to the active segment, rotating it first when it is full
+func (d *DiskHashMap[K, V]) appendLocked(key, value []byte, valueLength uint32) (valueLocation, error) {
+	if d.broken != nil {
+		return valueLocation{}, d.broken
+	}
+	if d.activeSize >= d.config.MaxSegmentSize {
+		if err := d.rotate(); err != nil {
+			return valueLocation{}, err
+		}
+	}
+	seq := d.nextSeq
+	record := encodeSegmentRecord(seq, key, value, valueLength)
+	if _, err := d.active.Write(record); err != nil {
+		if rollbackErr := d.rollbackLocked(); rollbackErr != nil {
+			return valueLocation{}, fmt.Errorf("append to segment: %w (rollback failed: %v)", err, rollbackErr)
+		}
+		return valueLocation{}, fmt.Errorf("append to segment: %w", err)
+	}
+	if d.config.SyncWrites {
+		if err := d.active.Sync(); err != nil {
+			if rollbackErr := d.rollbackLocked(); rollbackErr != nil {
+				return valueLocation{}, fmt.Errorf("sync segment: %w (rollback failed: %v)", err, rollbackErr)
+			}
+			return valueLocation{}, fmt.Errorf("sync segment: %w", err)
+		}
+	}
+	location := valueLocation{
+		segment: d.activeID,
+		offset:  d.activeSize + segmentHeaderSize + int64(len(key)),
+		size:    uint32(len(value)),
+		seq:     seq,
+	}
+	d.activeSize += int64(len(record))
+	d.nextSeq++
+	return location, nil
+}
+
+// rollbackLocked cuts a partially written record off the active segment so that the next
+// append starts at activeSize, if that fails the segment position is unknown and every
+// later write is refused
+func (d *DiskHashMap[K, V]) rollbackLocked() error {
+	err := d.active.Truncate(d.activeSize)
+	if err == nil {
+		_, err = d.active.Seek(d.activeSize, io.SeekStart)
+	}
+	if err != nil {
+		d.broken = fmt.Errorf("%w: segment %d could not be rolled back: %v", ErrDiskMapBroken, d.activeID, err)
+	}
+	return err
+}
+
+// markStale accounts for the bytes made obsolete by overwriting or removing key
+func (d *DiskHashMap[K, V]) markStale(key K) {
+	if location, exists := d.index.Get(key); exists {
+		d.stale += int64(location.size)
+	}
+}
+
+// rotate starts a new active segment
+func (d *DiskHashMap[K, V]) rotate() error {
+	if d.active != nil && d.activeSize == 0 {
+		return nil
+	}
+	if d.active != nil {
+		if err := d.active.Sync(); err != nil {
+			return fmt.Errorf("sync segment: %w", err)
+		}
+	}
+	id := d.nextID
+	d.nextID++
+	file, err := os.OpenFile(segmentPath(d.config.Dir, id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create segment: %w", err)
+	}
+	d.segments[id] = file
+	d.active = file
+	d.activeID = id
+	d.activeSize = 0
+	return nil
+}
+
+func (d *DiskHashMap[K, V]) allocateID() uint64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	id := d.nextID
+	d.nextID++
+	return id
+}
+
+func (d *DiskHashMap[K, V]) readValue(location valueLocation) ([]byte, error) {
+	file, exists := d.segments[location.segment]
+	if !exists {
+		return nil, fmt.Errorf("segment %d is missing", location.segment)
+	}
+	data := make([]byte, location.size)
+	if _, err := file.ReadAt(data, location.offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (d *DiskHashMap[K, V]) removeSegment(id uint64) {
+	if file, exists := d.segments[id]; exists {
+		file.Close()
+		delete(d.segments, id)
+	}
+	os.Remove(segmentPath(d.config.Dir, id))
+	os.Remove(hintPath(d.config.Dir, id))
+}
+
+func (d *DiskHashMap[K, V]) closeFiles() error {
+	var firstErr error
+	for id, file := range d.segments {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("close segment %d: %w", id, err)
+		}
+	}
+	return firstErr
+}
+
+// load rebuilds the index from all segments, keeping the record with the highest sequence per key
+func (d *DiskHashMap[K, V]) load() error {
+	ids, err := listSegments(d.config.Dir)
+	if err != nil {
+		return err
+	}
+
+	deleted := make(map[K]uint64)
+	var clearedBefore uint64
+	apply := func(key K, location valueLocation, valueLength uint32) {
+		if location.seq >= d.nextSeq {
+			d.nextSeq = location.seq + 1
+		}
+		current, exists := d.index.Get(key)
+		if exists && current.seq > location.seq {
+			return
+		}
+		if removedAt, removed := deleted[key]; removed && removedAt > location.seq {
+			return
+		}
+		if valueLength == tombstoneMarker {
+			d.index.Remove(key)
+			deleted[key] = location.seq
+			return
+		}
+		d.index.Put(key, location)
+	}
+
+	for _, id := range ids {
+		if id >= d.nextID {
+			d.nextID = id + 1
+		}
+		path := segmentPath(d.config.Dir, id)
+		if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+			os.Remove(path)
+			os.Remove(hintPath(d.config.Dir, id))
+			continue
+		}
+		file, err := os.OpenFile(path, os.O_RDWR, 0o644)
+		if err != nil {
+			return err
+		}
+		d.segments[id] = file
+
+		if d.loadHint(id, apply) {
+			continue
+		}
+		valid, err := scanSegment(file, func(seq uint64, offset int64, keyData []byte, valueLength uint32) error {
+			if valueLength == clearMarker {
+				if seq+1 > clearedBefore {
+					clearedBefore = seq + 1
+				}
+				if seq >= d.nextSeq {
+					d.nextSeq = seq + 1
+				}
+				return nil
+			}
+			key, err := d.config.KeyCodec.Decode(keyData)
+			if err != nil {
+				return fmt.Errorf("decode key in segment %d: %w", id, err)
+			}
+			size := valueLength
+			if valueLength == tombstoneMarker {
+				size = 0
+			}
+			apply(key, valueLocation{segment: id, offset: offset, size: size, seq: seq}, valueLength)
+			return nil
+		})
+		if errors.Is(err, errTornRecord) {
+			// A crash mid-append leaves a partial record at the end of the segment
+			if err := file.Truncate(valid); err != nil {
+				return fmt.Errorf("truncate torn segment %d: %w", id, err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("segment %d: %w", id, err)
+		}
+	}
+
+	if clearedBefore > 0 {
+		for key, location := range d.index.data {
+			if location.seq < clearedBefore {
+				d.index.Remove(key)
+			}
+		}
+	}
+	return nil
+}
+
+// loadHint feeds the entries of a segment's hint file to apply, returns false if there is no usable hint file
+func (d *DiskHashMap[K, V]) loadHint(id uint64, apply func(K, valueLocation, uint32)) bool {
+	data, err := os.ReadFile(hintPath(d.config.Dir, id))
+	if err != nil || len(data) < 4 {
+		return false
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return false
+	}
+
+	type hintEntry struct {
+		key      K
+		location valueLocation
+	}
+	entries := make([]hintEntry, 0)
+	for len(body) > 0 {
+		if len(body) < hintHeaderSize {
+			return false
+		}
+		seq := binary.LittleEndian.Uint64(body[0:8])
+		keySize := binary.LittleEndian.Uint32(body[8:12])
+		valueSize := binary.LittleEndian.Uint32(body[12:16])
+		offset := int64(binary.LittleEndian.Uint64(body[16:24]))
+		if uint64(len(body)-hintHeaderSize) < uint64(keySize) {
+			return false
+		}
+		key, err := d.config.KeyCodec.Decode(body[hintHeaderSize : hintHeaderSize+int(keySize)])
+		if err != nil {
+			return false
+		}
+		entries = append(entries, hintEntry{key, valueLocation{segment: id, offset: offset, size: valueSize, seq: seq}})
+		body = body[hintHeaderSize+int(keySize):]
+	}
+	for _, entry := range entries {
+		apply(entry.key, entry.location, entry.location.size)
+	}
+	return true
+}
+
+// mergeWriter writes merged values into fresh segments, each with a hint file
+type mergeWriter struct {
+	dir      string
+	maxSize  int64
+	allocate func() uint64
+
+	file  *os.File
+	id    uint64
+	size  int64
+	hint  []byte
+	files map[uint64]*os.File
+}
+
+func (w *mergeWriter) write(key, value []byte, seq uint64) (valueLocation, error) {
+	if w.file == nil || w.size >= w.maxSize {
+		if err := w.seal(); err != nil {
+			return valueLocation{}, err
+		}
+		w.id = w.allocate()
+		file, err := os.OpenFile(segmentPath(w.dir, w.id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			return valueLocation{}, err
+		}
+		if w.files == nil {
+			w.files = make(map[uint64]*os.File)
+		}
+		w.files[w.id] = file
+		w.file = file
+		w.size = 0
+		w.hint = w.hint[:0]
+	}
+
+	record := encodeSegmentRecord(seq, key, value, uint32(len(value)))
+	if _, err := w.file.Write(record); err != nil {
+		return valueLocation{}, err
+	}
+	location := valueLocation{
+		segment: w.id,
+		offset:  w.size + segmentHeaderSize + int64(len(key)),
+		size:    uint32(len(value)),
+		seq:     seq,
+	}
+	w.size += int64(len(record))
+
+	var header [hintHeaderSize]byte
+	binary.LittleEndian.PutUint64(header[0:8], seq)
+	binary.LittleEndian.PutUint32(header[8:12], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[12:16], location.size)
+	binary.LittleEndian.PutUint64(header[16:24], uint64(location.offset))
+	w.hint = append(w.hint, header[:]...)
+	w.hint = append(w.hint, key...)
+	return location, nil
+}
+
+// seal syncs the current segment and atomically installs its hint file
+func (w *mergeWriter) seal() error {
+	if w.file == nil {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	var checksum [4]byte
+	binary.LittleEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(w.hint))
+	tmpPath := filepath.Join(w.dir, segmentName(w.id)+hintTempExt)
+	if err := os.WriteFile(tmpPath, append(w.hint, checksum[:]...), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, hintPath(w.dir, w.id)); err != nil {
+		return err
+	}
+	w.file = nil
+	return nil
+}
+
+func (w *mergeWriter) finish() (map[uint64]*os.File, error) {
+	if err := w.seal(); err != nil {
+		w.abort()
+		return nil, err
+	}
+	if err := syncDir(w.dir); err != nil {
+		w.abort()
+		return nil, err
+	}
+	return w.files, nil
+}
+
+func (w *mergeWriter) abort() {
+	for id, file := range w.files {
+		file.Close()
+		os.Remove(segmentPath(w.dir, id))
+		os.Remove(hintPath(w.dir, id))
+	}
+	w.files = nil
+}
+
+// checkSegmentRecord rejects records that scanSegment would read back as corrupt, and value
+// lengths that would be mistaken for tombstoneMarker or clearMarker
+func checkSegmentRecord(key, value []byte) error {
+	if uint64(len(value)) >= uint64(clearMarker) {
+		return fmt.Errorf("%w: value length %d collides with a record marker", ErrRecordTooLarge, len(value))
+	}
+	if size := uint64(len(key)) + uint64(len(value)); size > maxRecordSize {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrRecordTooLarge, size, maxRecordSize)
+	}
+	return nil
+}
+
+// encodeSegmentRecord frames a record as crc32 | seq | key length | value length | key | value
+func encodeSegmentRecord(seq uint64, key, value []byte, valueLength uint32) []byte {
+	record := make([]byte, segmentHeaderSize+len(key)+len(value))
+	binary.LittleEndian.PutUint64(record[4:12], seq)
+	binary.LittleEndian.PutUint32(record[12:16], uint32(len(key)))
+	binary.LittleEndian.PutUint32(record[16:20], valueLength)
+	copy(record[segmentHeaderSize:], key)
+	copy(record[segmentHeaderSize+len(key):], value)
+	binary.LittleEndian.PutUint32(record[0:4], crc32.ChecksumIEEE(record[4:]))
+	return record
+}
+
+// scanSegment decodes every record of a segment and returns the number of bytes of valid records.
+// An incomplete or corrupt record yields errTornRecord.
+func scanSegment(file *os.File, visit func(seq uint64, offset int64, key []byte, valueLength uint32) error) (int64, error) {
+	r := bufio.NewReader(io.NewSectionReader(file, 0, 1<<62))
+	var valid int64
+	header := make([]byte, segmentHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return valid, nil
+		} else if err != nil {
+			return valid, errTornRecord
+		}
+		seq := binary.LittleEndian.Uint64(header[4:12])
+		keySize := binary.LittleEndian.Uint32(header[12:16])
+		valueLength := binary.LittleEndian.Uint32(header[16:20])
+		valueSize := valueLength
+		if valueLength == tombstoneMarker || valueLength == clearMarker {
+			valueSize = 0
+		}
+		if uint64(keySize)+uint64(valueSize) > maxRecordSize {
+			// A length no append writes, the record is torn only if it runs past the end
+			if _, err := io.CopyN(io.Discard, r, int64(keySize)+int64(valueSize)); err != nil {
+				return valid, errTornRecord
+			}
+			return valid, fmt.Errorf("%w: invalid record length at offset %d", ErrCorruptSegment, valid)
+		}
+		body := make([]byte, int(keySize)+int(valueSize))
+		if _, err := io.ReadFull(r, body); err != nil {
+			return valid, errTornRecord
+		}
+		checksum := crc32.Update(crc32.ChecksumIEEE(header[4:]), crc32.IEEETable, body)
+		if checksum != binary.LittleEndian.Uint32(header[0:4]) {
+			if atEOF(r) {
+				return valid, errTornRecord
+			}
+			return valid, fmt.Errorf("%w: damaged record at offset %d", ErrCorruptSegment, valid)
+		}
+		if err := visit(seq, valid+segmentHeaderSize+int64(keySize), body[:keySize], valueLength); err != nil {
+			return valid, err
+		}
+		valid += int64(segmentHeaderSize + len(body))
+	}
+}
+
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, hintTempExt) {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func segmentName(id uint64) string {
+	return fmt.Sprintf("%010d", id)
+}
+
+func segmentPath(dir string, id uint64) string {
+	return filepath.Join(dir, segmentName(id)+segmentExt)
+}
+
+func hintPath(dir string, id uint64) string {
+	return filepath.Join(dir, segmentName(id)+hintExt)
+}
+
+// readCache is a small LRU cache of decoded values
+type readCache[K comparable, V any] struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[K]*list.Element
+}
+
+type readCacheItem[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newReadCache[K comparable, V any](capacity int) *readCache[K, V] {
+	return &readCache[K, V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[K]*list.Element, capacity),
+	}
+}
+
+func (c *readCache[K, V]) get(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, exists := c.items[key]; exists {
+		c.order.MoveToFront(element)
+		return element.Value.(*readCacheItem[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (c *readCache[K, V]) put(key K, value V) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, exists := c.items[key]; exists {
+		element.Value.(*readCacheItem[K, V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.order.PushFront(&readCacheItem[K, V]{key: key, value: value})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*readCacheItem[K, V]).key)
+	}
+}
+
+func (c *readCache[K, V]) remove(key K) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, exists := c.items[key]; exists {
+		c.order.Remove(element)
+		delete(c.items, key)
+	}
+}
+
+func (c *readCache[K, V]) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.order.Init()
+	c.items = make(map[K]*list.Element, c.capacity)
+}
@@ -0,0 +1,80 @@
+package fastmap
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// stuckLog fails every write and cannot be truncated either
+type stuckLog struct {
+	*os.File
+}
+
+func (l stuckLog) Write(p []byte) (int, error) {
+	n, _ := l.File.Write(p[:len(p)/2])
+	return n, errors.New("disk full")
+}
+
+func (l stuckLog) Truncate(size int64) error {
+	return errors.New("read-only file system")
+}
+
+func openInternalDiskMap(t *testing.T, dir string) *DiskHashMap[string, []byte] {
+	t.Helper()
+	store, err := OpenDiskHashMap(DiskConfig[string, []byte]{
+		Dir:        dir,
+		KeyCodec:   StringCodec{},
+		ValueCodec: BytesCodec{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestDiskHashMapFailedAppendRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	store := openInternalDiskMap(t, dir)
+	store.Put("a", []byte("1"))
+
+	file := store.active.(*os.File)
+	store.active = shortWriteLog{file}
+	if err := store.Put("b", []byte("2")); err == nil {
+		t.Fatal("expected the failed append to be reported")
+	}
+	store.active = file
+	store.Put("c", []byte("3"))
+	for key, want := range map[string]string{"a": "1", "c": "3"} {
+		if value, _, err := store.Get(key); err != nil || string(value) != want {
+			t.Errorf("Get(%s) = %q, %v, want %s", key, value, err, want)
+		}
+	}
+	store.Close()
+
+	reopened := openInternalDiskMap(t, dir)
+	defer reopened.Close()
+	if reopened.Size() != 2 || !reopened.Contains("a") || !reopened.Contains("c") {
+		t.Errorf("expected a and c after reopen, got %v", reopened.Keys())
+	}
+	if value, _, _ := reopened.Get("c"); string(value) != "3" {
+		t.Errorf("Get(c) = %q after reopen, want 3", value)
+	}
+}
+
+func TestDiskHashMapFailedRollbackBreaksMap(t *testing.T) {
+	store := openInternalDiskMap(t, t.TempDir())
+	defer store.Close()
+	store.Put("a", []byte("1"))
+
+	file := store.active.(*os.File)
+	store.active = stuckLog{file}
+	store.Put("b", []byte("2"))
+	store.active = file
+	if err := store.Put("c", []byte("3")); !errors.Is(err, ErrDiskMapBroken) {
+		t.Errorf("expected ErrDiskMapBroken after a failed rollback, got %v", err)
+	}
+	if value, _, _ := store.Get("a"); string(value) != "1" {
+		t.Errorf("Get(a) = %q, want 1", value)
+	}
+}
@@ -0,0 +1,390 @@
+package fastmap_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+func openTestDiskMap(t *testing.T, dir string, cacheSize int) *fastmap.DiskHashMap[string, []byte] {
+	t.Helper()
+	store, err := fastmap.OpenDiskHashMap(fastmap.DiskConfig[string, []byte]{
+		Dir:            dir,
+		KeyCodec:       fastmap.StringCodec{},
+		ValueCodec:     fastmap.BytesCodec{},
+		MaxSegmentSize: 256,
+		ReadCacheSize:  cacheSize,
+	})
+	if err != nil {
+		t.Fatalf("OpenDiskHashMap failed: %v", err)
+	}
+	return store
+}
+
+func countFiles(t *testing.T, dir, pattern string) int {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(matches)
+}
+
+func TestDiskHashMapBasicOperations(t *testing.T) {
+	store := openTestDiskMap(t, t.TempDir(), 0)
+	defer store.Close()
+
+	store.Put("key1", []byte("value1"))
+	store.Put("key2", []byte("value2"))
+	if value, exists, err := store.Get("key1"); err != nil || !exists || string(value) != "value1" {
+		t.Errorf("Get(key1) = (%q, %v, %v), want (value1, true, nil)", value, exists, err)
+	}
+
+	store.Put("key1", []byte("updated"))
+	if value, _, _ := store.Get("key1"); string(value) != "updated" {
+		t.Errorf("Get after overwrite = %q, want updated", value)
+	}
+
+	store.Remove("key2")
+	if _, exists, _ := store.Get("key2"); exists {
+		t.Error("removed key still exists")
+	}
+	if store.Size() != 1 || !store.Contains("key1") || store.IsEmpty() {
+		t.Errorf("unexpected state after remove, keys %v", store.Keys())
+	}
+	if err := store.Remove("missing"); err != nil {
+		t.Errorf("Remove of missing key failed: %v", err)
+	}
+}
+
+func TestDiskHashMapReopen(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestDiskMap(t, dir, 0)
+	for i := 0; i < 50; i++ {
+		store.Put(fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)))
+	}
+	for i := 0; i < 50; i += 2 {
+		store.Remove(fmt.Sprintf("key%d", i))
+	}
+	store.Put("key1", []byte("latest"))
+	store.Close()
+
+	if countFiles(t, dir, "*.data") < 2 {
+		t.Fatal("expected writes to span several segments")
+	}
+
+	reopened := openTestDiskMap(t, dir, 0)
+	defer reopened.Close()
+	if reopened.Size() != 25 {
+		t.Errorf("expected 25 keys after reopen, got %d", reopened.Size())
+	}
+	if value, _, _ := reopened.Get("key1"); string(value) != "latest" {
+		t.Errorf("Get(key1) = %q, want latest", value)
+	}
+	if reopened.Contains("key0") {
+		t.Error("removed key reappeared after reopen")
+	}
+}
+
+func TestDiskHashMapMerge(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestDiskMap(t, dir, 0)
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 20; i++ {
+			store.Put(fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("round%d", round)))
+		}
+	}
+	store.Remove("key0")
+	before := countFiles(t, dir, "*.data")
+
+	if err := store.Merge(); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if after := countFiles(t, dir, "*.data"); after >= before {
+		t.Errorf("expected fewer segments after merge, got %d before and %d after", before, after)
+	}
+	if countFiles(t, dir, "*.hint") == 0 {
+		t.Error("expected hint files after merge")
+	}
+	if value, _, _ := store.Get("key5"); string(value) != "round4" {
+		t.Errorf("Get(key5) after merge = %q, want round4", value)
+	}
+	store.Put("key6", []byte("after-merge"))
+	store.Close()
+
+	reopened := openTestDiskMap(t, dir, 0)
+	defer reopened.Close()
+	if reopened.Size() != 19 || reopened.Contains("key0") {
+		t.Errorf("expected 19 keys without key0, got %v", reopened.Keys())
+	}
+	if value, _, _ := reopened.Get("key6"); string(value) != "after-merge" {
+		t.Errorf("Get(key6) = %q, want after-merge", value)
+	}
+	if value, _, _ := reopened.Get("key7"); string(value) != "round4" {
+		t.Errorf("Get(key7) = %q, want round4", value)
+	}
+}
+
+func TestDiskHashMapMergeWithConcurrentWrites(t *testing.T) {
+	store := openTestDiskMap(t, t.TempDir(), 16)
+	defer store.Close()
+	for i := 0; i < 100; i++ {
+		store.Put(fmt.Sprintf("key%d", i), []byte("old"))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			store.Put(fmt.Sprintf("key%d", i), []byte("new"))
+			store.Get(fmt.Sprintf("key%d", (i+50)%100))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 3; i++ {
+			if err := store.Merge(); err != nil {
+				t.Errorf("Merge failed: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	for i := 0; i < 100; i++ {
+		if value, _, err := store.Get(fmt.Sprintf("key%d", i)); err != nil || string(value) != "new" {
+			t.Errorf("Get(key%d) = (%q, %v), want new", i, value, err)
+		}
+	}
+}
+
+func TestDiskHashMapClear(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestDiskMap(t, dir, 0)
+	for i := 0; i < 30; i++ {
+		store.Put(fmt.Sprintf("key%d", i), []byte("value"))
+	}
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	store.Put("fresh", []byte("value"))
+	store.Close()
+
+	reopened := openTestDiskMap(t, dir, 0)
+	defer reopened.Close()
+	if keys := reopened.Keys(); len(keys) != 1 || keys[0] != "fresh" {
+		t.Errorf("expected only fresh after clear and reopen, got %v", keys)
+	}
+}
+
+func TestDiskHashMapTornTail(t *testing.T) {
+	dir := t.TempDir()
+	store, err := fastmap.OpenDiskHashMap(fastmap.DiskConfig[string, []byte]{
+		Dir:        dir,
+		KeyCodec:   fastmap.StringCodec{},
+		ValueCodec: fastmap.BytesCodec{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put("key1", []byte("value1"))
+	store.Put("key2", []byte("value2"))
+	store.Close()
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.data"))
+	last := matches[len(matches)-1]
+	info, _ := os.Stat(last)
+	os.Truncate(last, info.Size()-2)
+
+	reopened := openTestDiskMap(t, dir, 0)
+	defer reopened.Close()
+	if !reopened.Contains("key1") || reopened.Contains("key2") {
+		t.Errorf("expected only key1 after torn tail recovery, got %v", reopened.Keys())
+	}
+	reopened.Put("key3", []byte("value3"))
+	if value, _, _ := reopened.Get("key3"); string(value) != "value3" {
+		t.Errorf("Get(key3) = %q, want value3", value)
+	}
+}
+
+func TestDiskHashMapCorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestDiskMap(t, dir, 0)
+	store.Put("key1", []byte("value1"))
+	store.Put("key2", []byte("value2"))
+	store.Put("key3", []byte("value3"))
+	store.Close()
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.data"))
+	first := matches[0]
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a byte inside the value of the first record, two intact records follow it
+	data[25] ^= 0xff
+	if err := os.WriteFile(first, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fastmap.OpenDiskHashMap(fastmap.DiskConfig[string, []byte]{
+		Dir:        dir,
+		KeyCodec:   fastmap.StringCodec{},
+		ValueCodec: fastmap.BytesCodec{},
+	})
+	if !errors.Is(err, fastmap.ErrCorruptSegment) {
+		t.Fatalf("expected ErrCorruptSegment, got %v", err)
+	}
+	if info, _ := os.Stat(first); info.Size() != int64(len(data)) {
+		t.Errorf("corrupt segment was truncated from %d to %d bytes", len(data), info.Size())
+	}
+}
+
+func TestDiskHashMapReadCache(t *testing.T) {
+	store := openTestDiskMap(t, t.TempDir(), 2)
+	defer store.Close()
+
+	store.Put("key1", []byte("v1"))
+	store.Get("key1")
+	store.Put("key1", []byte("v2"))
+	if value, _, _ := store.Get("key1"); string(value) != "v2" {
+		t.Errorf("cached Get returned stale value %q", value)
+	}
+	store.Remove("key1")
+	if _, exists, _ := store.Get("key1"); exists {
+		t.Error("removed key served from cache")
+	}
+}
+
+func TestDiskHashMapForEach(t *testing.T) {
+	store := openTestDiskMap(t, t.TempDir(), 0)
+	defer store.Close()
+	store.Put("a", []byte("1"))
+	store.Put("b", []byte("22"))
+
+	total := 0
+	err := store.ForEach(func(key string, value []byte) error {
+		total += len(value)
+		return nil
+	})
+	if err != nil || total != 3 {
+		t.Errorf("ForEach = (%d, %v), want (3, nil)", total, err)
+	}
+
+	err = store.ForEach(func(key string, value []byte) error {
+		return fmt.Errorf("stop")
+	})
+	if err == nil {
+		t.Error("expected ForEach to return callback error")
+	}
+}
+
+func TestDiskHashMapClosed(t *testing.T) {
+	store := openTestDiskMap(t, t.TempDir(), 0)
+	store.Close()
+	if err := store.Put("key", nil); err != fastmap.ErrDiskMapClosed {
+		t.Errorf("expected ErrDiskMapClosed, got %v", err)
+	}
+	if _, _, err := store.Get("key"); err != fastmap.ErrDiskMapClosed {
+		t.Errorf("expected ErrDiskMapClosed, got %v", err)
+	}
+}
+
+func TestDiskHashMapBackgroundMerge(t *testing.T) {
+	dir := t.TempDir()
+	store, err := fastmap.OpenDiskHashMap(fastmap.DiskConfig[string, []byte]{
+		Dir:            dir,
+		KeyCodec:       fastmap.StringCodec{},
+		ValueCodec:     fastmap.BytesCodec{},
+		MaxSegmentSize: 64,
+		MergeInterval:  5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for i := 0; i < 10; i++ {
+		store.Put("key", []byte(fmt.Sprintf("value%d", i)))
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for countFiles(t, dir, "*.hint") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("background merge did not run")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if value, _, _ := store.Get("key"); string(value) != "value9" {
+		t.Errorf("Get after background merge = %q, want value9", value)
+	}
+}
+
+type hugeValueCodec struct{}
+
+func (hugeValueCodec) Encode(int) ([]byte, error) { return make([]byte, 1<<30), nil }
+
+func (hugeValueCodec) Decode([]byte) (int, error) { return 0, nil }
+
+func TestDiskHashMapRecordTooLarge(t *testing.T) {
+	store, err := fastmap.OpenDiskHashMap(fastmap.DiskConfig[string, int]{
+		Dir:        t.TempDir(),
+		KeyCodec:   fastmap.StringCodec{},
+		ValueCodec: hugeValueCodec{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.Put("big", 0); !errors.Is(err, fastmap.ErrRecordTooLarge) {
+		t.Errorf("expected ErrRecordTooLarge, got %v", err)
+	}
+	if store.Contains("big") {
+		t.Error("rejected record was indexed")
+	}
+}
+
+func TestDiskHashMapBackgroundMergeError(t *testing.T) {
+	dir := t.TempDir()
+	// Non-empty directories in place of the hint files make every merge fail to install them
+	for id := 0; id < 1000; id++ {
+		if err := os.MkdirAll(filepath.Join(dir, fmt.Sprintf("%010d.hint", id), "busy"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := fastmap.OpenDiskHashMap(fastmap.DiskConfig[string, []byte]{
+		Dir:            dir,
+		KeyCodec:       fastmap.StringCodec{},
+		ValueCodec:     fastmap.BytesCodec{},
+		MaxSegmentSize: 64,
+		MergeInterval:  5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for i := 0; time.Now().Before(deadline); i++ {
+		value := []byte(fmt.Sprintf("value%d", i))
+		if err := store.Put("key", value); err != nil {
+			t.Fatalf("Put must not report the background merge error: %v", err)
+		}
+		if got, _, _ := store.Get("key"); string(got) != string(value) {
+			t.Fatalf("Get(key) = %q, want %q", got, value)
+		}
+		if err := store.Sync(); err != nil {
+			if err := store.Sync(); err != nil {
+				t.Errorf("merge error reported twice: %v", err)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("background merge failure was never reported")
+}
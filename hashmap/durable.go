@@ -0,0 +1,618 @@
+package fastmap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrDurableMapClosed is returned by write operations on a closed DurableHashMap
+var ErrDurableMapClosed = errors.New("durable map is closed")
+
+// ErrRecordTooLarge is returned when an encoded key and value exceed the maximum record size
+var ErrRecordTooLarge = errors.New("record too large")
+
+// ErrCorruptLog is returned on open when a damaged record is followed by further data,
+// such a log is not the result of an interrupted append and is never truncated
+var ErrCorruptLog = errors.New("durable map log is corrupt")
+
+// errTornRecord marks an incomplete or checksum-mismatched record at the end of the data
+var errTornRecord = errors.New("torn record")
+
+// SyncPolicy controls when the write-ahead log is fsynced to stable storage
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every write, no acknowledged write is lost on power failure
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs in the background every SyncInterval
+	SyncInterval
+	// SyncNever leaves flushing to the operating system
+	SyncNever
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.dat"
+	snapshotMagic    = "FMSNAP01"
+
+	defaultSyncInterval = time.Second
+	recordHeaderSize    = 8
+	maxRecordSize       = 1 << 30
+
+	opPut    byte = 1
+	opRemove byte = 2
+	opClear  byte = 3
+)
+
+// DurableConfig configures the storage of a DurableHashMap
+//   - Dir: directory holding the log and snapshot files, created if missing
+//   - KeyCodec, ValueCodec: serialization of keys and values
+//   - SyncPolicy, SyncInterval: fsync behavior of the log, SyncInterval defaults to one second
+//   - SnapshotInterval: period of automatic snapshots, zero disables them
+type DurableConfig[K comparable, V any] struct {
+	Dir              string
+	KeyCodec         Codec[K]
+	ValueCodec       Codec[V]
+	SyncPolicy       SyncPolicy
+	SyncInterval     time.Duration
+	SnapshotInterval time.Duration
+}
+
+// DurableHashMap is a ThreadSafeHashMap whose writes are recorded in an append-only log.
+// The log is replayed on open and compacted into a snapshot periodically or on demand.
+// Reads are served from memory and never touch the disk.
+// Example:
+//
+//	store, err := OpenDurableHashMap(DurableConfig[string, User]{
+//	    Dir:        "/var/lib/users",
+//	    KeyCodec:   StringCodec{},
+//	    ValueCodec: JSONCodec[User]{},
+//	})
+//	defer store.Close()
+//	err = store.Put("user1", User{Name: "John"})
+type DurableHashMap[K comparable, V any] struct {
+	data   *ThreadSafeHashMap[K, V]
+	config DurableConfig[K, V]
+
+	// mutex serializes log appends with their application to data
+	mutex  sync.Mutex
+	log    logFile
+	offset int64
+	dirty  bool
+	closed bool
+	// backgroundErr holds the last failure of a background sync or snapshot until Sync or
+	// Close reports it
+	backgroundErr error
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// logFile is the subset of *os.File used to append to the log
+type logFile interface {
+	io.Writer
+	io.Seeker
+	io.Closer
+	Sync() error
+	Truncate(size int64) error
+}
+
+// OpenDurableHashMap opens or creates a DurableHashMap in config.Dir, loading the snapshot
+// and replaying the log. A partially written record at the end of the log is truncated,
+// a damaged record followed by further data fails with ErrCorruptLog.
+// Example:
+//
+//	store, err := OpenDurableHashMap(DurableConfig[string, int]{
+//	    Dir:              dir,
+//	    KeyCodec:         StringCodec{},
+//	    ValueCodec:       JSONCodec[int]{},
+//	    SyncPolicy:       SyncInterval,
+//	    SnapshotInterval: time.Hour,
+//	})
+func OpenDurableHashMap[K comparable, V any](config DurableConfig[K, V]) (*DurableHashMap[K, V], error) {
+	if config.Dir == "" {
+		return nil, errors.New("open durable map: Dir is empty")
+	}
+	if config.KeyCodec == nil || config.ValueCodec == nil {
+		return nil, errors.New("open durable map: KeyCodec and ValueCodec are required")
+	}
+	if config.SyncPolicy == SyncInterval && config.SyncInterval <= 0 {
+		config.SyncInterval = defaultSyncInterval
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("open durable map: %w", err)
+	}
+
+	d := &DurableHashMap[K, V]{
+		data:   NewThreadSafeHashMap[K, V](),
+		config: config,
+		stop:   make(chan struct{}),
+	}
+	if err := d.loadSnapshot(); err != nil {
+		return nil, fmt.Errorf("open durable map: %w", err)
+	}
+	if err := d.openLog(); err != nil {
+		return nil, fmt.Errorf("open durable map: %w", err)
+	}
+	d.startBackground()
+	return d, nil
+}
+
+// Put adds or updates a key-value pair after recording it in the log.
+// Returns ErrRecordTooLarge when the encoded key and value exceed 1 GiB. With SyncAlways a
+// record whose fsync fails is cut off the log again and the pair is not applied.
+// Example:
+//
+//	if err := store.Put("user1", user); err != nil {
+//	    return err
+//	}
+func (d *DurableHashMap[K, V]) Put(key K, value V) error {
+	keyData, err := d.config.KeyCodec.Encode(key)
+	if err != nil {
+		return fmt.Errorf("encode key %v: %w", key, err)
+	}
+	valueData, err := d.config.ValueCodec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("encode value for key %v: %w", key, err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err := d.appendRecord(opPut, keyData, valueData); err != nil {
+		return err
+	}
+	d.data.Put(key, value)
+	return nil
+}
+
+// Remove deletes a key after recording the removal in the log
+// Example:
+//
+//	err := store.Remove("user1")
+func (d *DurableHashMap[K, V]) Remove(key K) error {
+	keyData, err := d.config.KeyCodec.Encode(key)
+	if err != nil {
+		return fmt.Errorf("encode key %v: %w", key, err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err := d.appendRecord(opRemove, keyData, nil); err != nil {
+		return err
+	}
+	d.data.Remove(key)
+	return nil
+}
+
+// Clear removes all elements after recording the clear in the log
+// Example:
+//
+//	err := store.Clear()
+func (d *DurableHashMap[K, V]) Clear() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err := d.appendRecord(opClear, nil, nil); err != nil {
+		return err
+	}
+	d.data.Clear()
+	return nil
+}
+
+// Get retrieves a value by key from memory
+// Example:
+//
+//	if user, exists := store.Get("user1"); exists {
+//	    fmt.Printf("Found user: %v\n", user)
+//	}
+func (d *DurableHashMap[K, V]) Get(key K) (V, bool) {
+	return d.data.Get(key)
+}
+
+// Contains checks if a key exists
+func (d *DurableHashMap[K, V]) Contains(key K) bool {
+	return d.data.Contains(key)
+}
+
+// Size returns the number of elements
+func (d *DurableHashMap[K, V]) Size() int {
+	return d.data.Size()
+}
+
+// IsEmpty returns true if the map has no elements
+func (d *DurableHashMap[K, V]) IsEmpty() bool {
+	return d.data.IsEmpty()
+}
+
+// Keys returns a slice of all keys
+func (d *DurableHashMap[K, V]) Keys() []K {
+	return d.data.Keys()
+}
+
+// Values returns a slice of all values
+func (d *DurableHashMap[K, V]) Values() []V {
+	return d.data.Values()
+}
+
+// ForEach executes a callback function for each key-value pair with read lock
+func (d *DurableHashMap[K, V]) ForEach(callback func(K, V) error) error {
+	return d.data.ForEach(callback)
+}
+
+// ToMap returns a copy of the contents as a regular map
+func (d *DurableHashMap[K, V]) ToMap() map[K]V {
+	return d.data.ToMap()
+}
+
+// Compact writes the current contents to a new snapshot file, atomically replaces the
+// previous snapshot and truncates the log. Writes are blocked while the snapshot is written.
+// Example:
+//
+//	if err := store.Compact(); err != nil {
+//	    log.Printf("compaction failed: %v", err)
+//	}
+func (d *DurableHashMap[K, V]) Compact() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.closed {
+		return ErrDurableMapClosed
+	}
+	return d.snapshotLocked()
+}
+
+// Sync flushes the log to stable storage and reports a failed background sync or snapshot
+// if one occurred since the last Sync
+// Example:
+//
+//	err := store.Sync()
+func (d *DurableHashMap[K, V]) Sync() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.closed {
+		return ErrDurableMapClosed
+	}
+	if err := d.syncLocked(); err != nil {
+		return err
+	}
+	return d.takeBackgroundErr()
+}
+
+// Close stops background work, flushes the log and closes it. The map stays readable.
+// A failed background sync or snapshot that no Sync has returned yet is reported here.
+// Example:
+//
+//	defer store.Close()
+func (d *DurableHashMap[K, V]) Close() error {
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.stop)
+	d.mutex.Unlock()
+	d.done.Wait()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	syncErr := d.log.Sync()
+	if err := d.log.Close(); err != nil {
+		return fmt.Errorf("close log: %w", err)
+	}
+	if syncErr != nil {
+		return fmt.Errorf("sync log: %w", syncErr)
+	}
+	return d.takeBackgroundErr()
+}
+
+func (d *DurableHashMap[K, V]) appendRecord(op byte, key, value []byte) error {
+	if d.closed {
+		return ErrDurableMapClosed
+	}
+	if size := 1 + 4 + uint64(len(key)) + uint64(len(value)); size > maxRecordSize {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrRecordTooLarge, size, maxRecordSize)
+	}
+	record := encodeRecord(op, key, value)
+	if _, err := d.log.Write(record); err != nil {
+		// Drop the partial record, later appends would otherwise follow garbage
+		if rollbackErr := d.rollbackLocked(); rollbackErr != nil {
+			return fmt.Errorf("append to log: %w (rollback failed: %v)", err, rollbackErr)
+		}
+		return fmt.Errorf("append to log: %w", err)
+	}
+	if d.config.SyncPolicy == SyncAlways {
+		if err := d.log.Sync(); err != nil {
+			// The caller does not apply a record that failed, so it must not be replayed either
+			if rollbackErr := d.rollbackLocked(); rollbackErr != nil {
+				return fmt.Errorf("sync log: %w (rollback failed: %v)", err, rollbackErr)
+			}
+			return fmt.Errorf("sync log: %w", err)
+		}
+		d.offset += int64(len(record))
+		return nil
+	}
+	d.offset += int64(len(record))
+	d.dirty = true
+	return nil
+}
+
+// rollbackLocked truncates the log back to the end of the last complete record
+func (d *DurableHashMap[K, V]) rollbackLocked() error {
+	if err := d.log.Truncate(d.offset); err != nil {
+		return err
+	}
+	_, err := d.log.Seek(d.offset, io.SeekStart)
+	return err
+}
+
+// takeBackgroundErr returns and clears the last background failure, d.mutex must be held
+func (d *DurableHashMap[K, V]) takeBackgroundErr() error {
+	err := d.backgroundErr
+	d.backgroundErr = nil
+	return err
+}
+
+func (d *DurableHashMap[K, V]) syncLocked() error {
+	if !d.dirty {
+		return nil
+	}
+	if err := d.log.Sync(); err != nil {
+		return fmt.Errorf("sync log: %w", err)
+	}
+	d.dirty = false
+	return nil
+}
+
+func (d *DurableHashMap[K, V]) snapshotLocked() error {
+	path := filepath.Join(d.config.Dir, snapshotFileName)
+	tmpPath := path + ".tmp"
+	if err := d.writeSnapshot(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("install snapshot: %w", err)
+	}
+	if err := syncDir(d.config.Dir); err != nil {
+		return fmt.Errorf("install snapshot: %w", err)
+	}
+
+	// Replaying the old log on top of the new snapshot is harmless, so a crash
+	// before the truncation below loses nothing
+	if err := d.log.Truncate(0); err != nil {
+		return fmt.Errorf("truncate log: %w", err)
+	}
+	if _, err := d.log.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("truncate log: %w", err)
+	}
+	d.offset = 0
+	d.dirty = true
+	return d.syncLocked()
+}
+
+func (d *DurableHashMap[K, V]) writeSnapshot(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if _, err := w.WriteString(snapshotMagic); err != nil {
+		return err
+	}
+	err = d.data.ForEach(func(key K, value V) error {
+		keyData, err := d.config.KeyCodec.Encode(key)
+		if err != nil {
+			return err
+		}
+		valueData, err := d.config.ValueCodec.Encode(value)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(encodeRecord(opPut, keyData, valueData))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+func (d *DurableHashMap[K, V]) loadSnapshot() error {
+	file, err := os.Open(filepath.Join(d.config.Dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != snapshotMagic {
+		return errors.New("snapshot has invalid header")
+	}
+	if _, err := replayRecords(r, d.apply); err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	return nil
+}
+
+func (d *DurableHashMap[K, V]) openLog() error {
+	path := filepath.Join(d.config.Dir, walFileName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	valid, err := replayRecords(bufio.NewReader(file), d.apply)
+	if errors.Is(err, errTornRecord) {
+		// Only reached when nothing follows the damaged record
+		// A crash mid-append leaves a partial record, drop it so new appends start clean
+		if err := file.Truncate(valid); err != nil {
+			file.Close()
+			return fmt.Errorf("truncate torn log tail: %w", err)
+		}
+	} else if err != nil {
+		file.Close()
+		return fmt.Errorf("replay log: %w", err)
+	}
+	if _, err := file.Seek(valid, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	d.log = file
+	d.offset = valid
+	return nil
+}
+
+// apply replays a decoded record against the in-memory map
+func (d *DurableHashMap[K, V]) apply(op byte, keyData, valueData []byte) error {
+	if op == opClear {
+		d.data.Clear()
+		return nil
+	}
+	key, err := d.config.KeyCodec.Decode(keyData)
+	if err != nil {
+		return fmt.Errorf("decode key: %w", err)
+	}
+	switch op {
+	case opPut:
+		value, err := d.config.ValueCodec.Decode(valueData)
+		if err != nil {
+			return fmt.Errorf("decode value for key %v: %w", key, err)
+		}
+		d.data.Put(key, value)
+	case opRemove:
+		d.data.Remove(key)
+	default:
+		return fmt.Errorf("unknown operation %d", op)
+	}
+	return nil
+}
+
+func (d *DurableHashMap[K, V]) startBackground() {
+	if d.config.SyncPolicy != SyncInterval && d.config.SnapshotInterval <= 0 {
+		return
+	}
+
+	d.done.Add(1)
+	go func() {
+		defer d.done.Done()
+		var syncTick, snapshotTick <-chan time.Time
+		if d.config.SyncPolicy == SyncInterval {
+			ticker := time.NewTicker(d.config.SyncInterval)
+			defer ticker.Stop()
+			syncTick = ticker.C
+		}
+		if d.config.SnapshotInterval > 0 {
+			ticker := time.NewTicker(d.config.SnapshotInterval)
+			defer ticker.Stop()
+			snapshotTick = ticker.C
+		}
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-syncTick:
+				d.recordBackgroundErr(d.Sync())
+			case <-snapshotTick:
+				d.recordBackgroundErr(d.Compact())
+			}
+		}
+	}()
+}
+
+// recordBackgroundErr keeps a failure of the background loop for the next Sync or Close
+func (d *DurableHashMap[K, V]) recordBackgroundErr(err error) {
+	if err == nil || errors.Is(err, ErrDurableMapClosed) {
+		return
+	}
+	d.mutex.Lock()
+	d.backgroundErr = fmt.Errorf("background: %w", err)
+	d.mutex.Unlock()
+}
+
+// encodeRecord frames a log record as crc32 | payload length | op | key length | key | value
+func encodeRecord(op byte, key, value []byte) []byte {
+	payloadSize := 1 + 4 + len(key) + len(value)
+	record := make([]byte, recordHeaderSize+payloadSize)
+	payload := record[recordHeaderSize:]
+	payload[0] = op
+	binary.LittleEndian.PutUint32(payload[1:5], uint32(len(key)))
+	copy(payload[5:], key)
+	copy(payload[5+len(key):], value)
+	binary.LittleEndian.PutUint32(record[0:4], crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(payloadSize))
+	return record
+}
+
+// replayRecords decodes records until EOF and returns the number of bytes of valid records.
+// An incomplete record, or a corrupt one that ends the data, yields errTornRecord.
+// A corrupt record followed by more data yields ErrCorruptLog.
+func replayRecords(r io.Reader, apply func(op byte, key, value []byte) error) (int64, error) {
+	var valid int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return valid, nil
+		} else if err != nil {
+			return valid, errTornRecord
+		}
+		checksum := binary.LittleEndian.Uint32(header[0:4])
+		size := binary.LittleEndian.Uint32(header[4:8])
+		if size > maxRecordSize {
+			// A length no append writes, the record is torn only if it runs past the end
+			if _, err := io.CopyN(io.Discard, r, int64(size)); err != nil {
+				return valid, errTornRecord
+			}
+			return valid, fmt.Errorf("%w: invalid record length at offset %d", ErrCorruptLog, valid)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return valid, errTornRecord
+		}
+		if size < 5 || crc32.ChecksumIEEE(payload) != checksum ||
+			uint64(binary.LittleEndian.Uint32(payload[1:5])) > uint64(size-5) {
+			if atEOF(r) {
+				return valid, errTornRecord
+			}
+			return valid, fmt.Errorf("%w: damaged record at offset %d", ErrCorruptLog, valid)
+		}
+		keySize := binary.LittleEndian.Uint32(payload[1:5])
+		key := payload[5 : 5+keySize]
+		value := payload[5+keySize:]
+		if err := apply(payload[0], key, value); err != nil {
+			return valid, err
+		}
+		valid += int64(recordHeaderSize + size)
+	}
+}
+
+// atEOF reports whether r has no data left
+func atEOF(r io.Reader) bool {
+	var next [1]byte
+	_, err := io.ReadFull(r, next[:])
+	return err == io.EOF
+}
+
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
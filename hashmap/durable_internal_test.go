@@ -0,0 +1,101 @@
+package fastmap
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// shortWriteLog writes only part of the next record and then fails
+type shortWriteLog struct {
+	*os.File
+}
+
+func (l shortWriteLog) Write(p []byte) (int, error) {
+	n, _ := l.File.Write(p[:len(p)/2])
+	return n, errors.New("disk full")
+}
+
+func TestDurableHashMapFailedAppendRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenDurableHashMap(DurableConfig[string, int]{
+		Dir:        dir,
+		KeyCodec:   StringCodec{},
+		ValueCodec: JSONCodec[int]{},
+		SyncPolicy: SyncAlways,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put("a", 1)
+
+	file := store.log.(*os.File)
+	store.log = shortWriteLog{file}
+	if err := store.Put("b", 2); err == nil {
+		t.Fatal("expected the failed append to be reported")
+	}
+	store.log = file
+	store.Put("c", 3)
+	store.Close()
+
+	reopened, err := OpenDurableHashMap(DurableConfig[string, int]{
+		Dir:        dir,
+		KeyCodec:   StringCodec{},
+		ValueCodec: JSONCodec[int]{},
+	})
+	if err != nil {
+		t.Fatalf("reopen after failed append: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Size() != 2 || !reopened.Contains("a") || !reopened.Contains("c") {
+		t.Errorf("expected a and c after replay, got %v", reopened.Keys())
+	}
+}
+
+// failSyncLog accepts writes but cannot flush them
+type failSyncLog struct {
+	*os.File
+}
+
+func (l failSyncLog) Sync() error {
+	return errors.New("input/output error")
+}
+
+func TestDurableHashMapFailedSyncRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenDurableHashMap(DurableConfig[string, int]{
+		Dir:        dir,
+		KeyCodec:   StringCodec{},
+		ValueCodec: JSONCodec[int]{},
+		SyncPolicy: SyncAlways,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put("a", 1)
+
+	file := store.log.(*os.File)
+	store.log = failSyncLog{file}
+	if err := store.Put("b", 2); err == nil {
+		t.Fatal("expected the failed sync to be reported")
+	}
+	store.log = file
+	if store.Contains("b") {
+		t.Error("write whose sync failed was applied")
+	}
+	store.Put("c", 3)
+	store.Close()
+
+	reopened, err := OpenDurableHashMap(DurableConfig[string, int]{
+		Dir:        dir,
+		KeyCodec:   StringCodec{},
+		ValueCodec: JSONCodec[int]{},
+	})
+	if err != nil {
+		t.Fatalf("reopen after failed sync: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Size() != 2 || !reopened.Contains("a") || !reopened.Contains("c") {
+		t.Errorf("expected a and c after replay, got %v", reopened.Keys())
+	}
+}
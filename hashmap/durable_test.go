@@ -0,0 +1,295 @@
+package fastmap_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+type durableUser struct {
+	Name string
+	Age  int
+}
+
+func openTestStore(t *testing.T, dir string, policy fastmap.SyncPolicy) *fastmap.DurableHashMap[string, durableUser] {
+	t.Helper()
+	store, err := fastmap.OpenDurableHashMap(fastmap.DurableConfig[string, durableUser]{
+		Dir:        dir,
+		KeyCodec:   fastmap.StringCodec{},
+		ValueCodec: fastmap.JSONCodec[durableUser]{},
+		SyncPolicy: policy,
+	})
+	if err != nil {
+		t.Fatalf("OpenDurableHashMap failed: %v", err)
+	}
+	return store
+}
+
+func TestDurableHashMapReplay(t *testing.T) {
+	for _, policy := range []fastmap.SyncPolicy{fastmap.SyncAlways, fastmap.SyncInterval, fastmap.SyncNever} {
+		t.Run(fmt.Sprintf("policy %d", policy), func(t *testing.T) {
+			dir := t.TempDir()
+			store := openTestStore(t, dir, policy)
+			store.Put("user1", durableUser{Name: "John", Age: 30})
+			store.Put("user2", durableUser{Name: "Jane", Age: 25})
+			store.Put("user1", durableUser{Name: "John", Age: 31})
+			store.Remove("user2")
+			store.Put("user3", durableUser{Name: "Bob", Age: 40})
+			if err := store.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			reopened := openTestStore(t, dir, policy)
+			defer reopened.Close()
+			if reopened.Size() != 2 {
+				t.Errorf("expected 2 entries after replay, got %d", reopened.Size())
+			}
+			if user, _ := reopened.Get("user1"); user.Age != 31 {
+				t.Errorf("expected latest value for user1, got %+v", user)
+			}
+			if reopened.Contains("user2") {
+				t.Error("removed key reappeared after replay")
+			}
+		})
+	}
+}
+
+func TestDurableHashMapClearReplay(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, fastmap.SyncAlways)
+	store.Put("user1", durableUser{Name: "John"})
+	store.Clear()
+	store.Put("user2", durableUser{Name: "Jane"})
+	store.Close()
+
+	reopened := openTestStore(t, dir, fastmap.SyncAlways)
+	defer reopened.Close()
+	if keys := reopened.Keys(); len(keys) != 1 || keys[0] != "user2" {
+		t.Errorf("expected only user2 after replay, got %v", keys)
+	}
+}
+
+func TestDurableHashMapCompact(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, fastmap.SyncAlways)
+	for i := 0; i < 100; i++ {
+		store.Put(fmt.Sprintf("user%d", i), durableUser{Age: i})
+	}
+	for i := 0; i < 50; i++ {
+		store.Remove(fmt.Sprintf("user%d", i))
+	}
+
+	if err := store.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "wal.log")); err != nil || info.Size() != 0 {
+		t.Errorf("expected empty log after compaction, got %v, %v", info.Size(), err)
+	}
+
+	// Writes after the snapshot land in the fresh log
+	store.Put("user0", durableUser{Age: 1000})
+	store.Close()
+
+	reopened := openTestStore(t, dir, fastmap.SyncAlways)
+	defer reopened.Close()
+	if reopened.Size() != 51 {
+		t.Errorf("expected 51 entries, got %d", reopened.Size())
+	}
+	if user, _ := reopened.Get("user0"); user.Age != 1000 {
+		t.Errorf("expected post-snapshot write to survive, got %+v", user)
+	}
+	if user, _ := reopened.Get("user99"); user.Age != 99 {
+		t.Errorf("expected snapshot value for user99, got %+v", user)
+	}
+}
+
+func TestDurableHashMapPeriodicSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	store, err := fastmap.OpenDurableHashMap(fastmap.DurableConfig[string, int]{
+		Dir:              dir,
+		KeyCodec:         fastmap.StringCodec{},
+		ValueCodec:       fastmap.JSONCodec[int]{},
+		SyncPolicy:       fastmap.SyncInterval,
+		SyncInterval:     5 * time.Millisecond,
+		SnapshotInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("OpenDurableHashMap failed: %v", err)
+	}
+	defer store.Close()
+	store.Put("key", 1)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(filepath.Join(dir, "snapshot.dat")); err == nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("no snapshot written by background compaction")
+}
+
+func TestDurableHashMapTornTail(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, fastmap.SyncAlways)
+	store.Put("user1", durableUser{Name: "John"})
+	store.Put("user2", durableUser{Name: "Jane"})
+	store.Close()
+
+	// Simulate a crash in the middle of appending the second record
+	logPath := filepath.Join(dir, "wal.log")
+	info, _ := os.Stat(logPath)
+	if err := os.Truncate(logPath, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openTestStore(t, dir, fastmap.SyncAlways)
+	if !reopened.Contains("user1") || reopened.Contains("user2") {
+		t.Errorf("expected only user1 after torn tail recovery, got %v", reopened.Keys())
+	}
+	reopened.Put("user3", durableUser{Name: "Bob"})
+	reopened.Close()
+
+	// Garbage after the last record is dropped as well
+	file, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	file.Write([]byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x00, 0x00, 0x00, 0x42})
+	file.Close()
+
+	final := openTestStore(t, dir, fastmap.SyncAlways)
+	defer final.Close()
+	if final.Size() != 2 || !final.Contains("user3") {
+		t.Errorf("expected user1 and user3, got %v", final.Keys())
+	}
+}
+
+func TestDurableHashMapClosed(t *testing.T) {
+	store := openTestStore(t, t.TempDir(), fastmap.SyncNever)
+	store.Put("user1", durableUser{Name: "John"})
+	store.Close()
+
+	if err := store.Put("user2", durableUser{}); !errors.Is(err, fastmap.ErrDurableMapClosed) {
+		t.Errorf("expected ErrDurableMapClosed, got %v", err)
+	}
+	if _, exists := store.Get("user1"); !exists {
+		t.Error("closed map should remain readable")
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("second Close should be a no-op, got %v", err)
+	}
+}
+
+func TestDurableHashMapInvalidConfig(t *testing.T) {
+	if _, err := fastmap.OpenDurableHashMap(fastmap.DurableConfig[string, int]{Dir: t.TempDir()}); err == nil {
+		t.Error("expected error for missing codecs")
+	}
+	if _, err := fastmap.OpenDurableHashMap(fastmap.DurableConfig[string, int]{
+		KeyCodec:   fastmap.StringCodec{},
+		ValueCodec: fastmap.JSONCodec[int]{},
+	}); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestDurableHashMapCorruptLog(t *testing.T) {
+	dir := t.TempDir()
+	store := openTestStore(t, dir, fastmap.SyncAlways)
+	store.Put("user1", durableUser{Name: "John"})
+	store.Put("user2", durableUser{Name: "Jane"})
+	store.Put("user3", durableUser{Name: "Bob"})
+	store.Close()
+
+	// A damaged record in the middle is not a torn append, the records after it must survive
+	logPath := filepath.Join(dir, "wal.log")
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(logPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fastmap.OpenDurableHashMap(fastmap.DurableConfig[string, durableUser]{
+		Dir:        dir,
+		KeyCodec:   fastmap.StringCodec{},
+		ValueCodec: fastmap.JSONCodec[durableUser]{},
+	})
+	if !errors.Is(err, fastmap.ErrCorruptLog) {
+		t.Fatalf("expected ErrCorruptLog, got %v", err)
+	}
+	if info, _ := os.Stat(logPath); info.Size() != int64(len(data)) {
+		t.Errorf("corrupt log was truncated from %d to %d bytes", len(data), info.Size())
+	}
+}
+
+type hugeCodec struct{}
+
+func (hugeCodec) Encode(string) ([]byte, error) { return make([]byte, 1<<30), nil }
+
+func (hugeCodec) Decode([]byte) (string, error) { return "", nil }
+
+func TestDurableHashMapRecordTooLarge(t *testing.T) {
+	dir := t.TempDir()
+	store, err := fastmap.OpenDurableHashMap(fastmap.DurableConfig[string, string]{
+		Dir:        dir,
+		KeyCodec:   fastmap.StringCodec{},
+		ValueCodec: hugeCodec{},
+		SyncPolicy: fastmap.SyncAlways,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("big", ""); !errors.Is(err, fastmap.ErrRecordTooLarge) {
+		t.Errorf("expected ErrRecordTooLarge, got %v", err)
+	}
+	if store.Contains("big") {
+		t.Error("rejected record was applied")
+	}
+	store.Close()
+	if info, _ := os.Stat(filepath.Join(dir, "wal.log")); info.Size() != 0 {
+		t.Errorf("rejected record was appended, log has %d bytes", info.Size())
+	}
+}
+
+func TestDurableHashMapBackgroundError(t *testing.T) {
+	dir := t.TempDir()
+	// A non-empty directory in place of the temporary snapshot makes every compaction fail
+	blocker := filepath.Join(dir, "snapshot.dat.tmp")
+	if err := os.MkdirAll(filepath.Join(blocker, "busy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := fastmap.OpenDurableHashMap(fastmap.DurableConfig[string, durableUser]{
+		Dir:              dir,
+		KeyCodec:         fastmap.StringCodec{},
+		ValueCodec:       fastmap.JSONCodec[durableUser]{},
+		SnapshotInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for i := 0; time.Now().Before(deadline); i++ {
+		key := fmt.Sprintf("user%d", i)
+		if err := store.Put(key, durableUser{}); err != nil {
+			t.Fatalf("Put must not report the background error: %v", err)
+		}
+		if !store.Contains(key) {
+			t.Fatalf("Put(%s) was not applied", key)
+		}
+		if err := store.Sync(); err != nil {
+			if err := store.Sync(); err != nil {
+				t.Errorf("background error reported twice: %v", err)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("background snapshot failure was never reported")
+}
@@ -0,0 +1,37 @@
+package fastmap_test
+
+import (
+	"testing"
+
+	"github.com/billowdev/fastmap/fastmaptest"
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+// multiKeyTarget drives a MultiKeyHashMap through primary keys only
+type multiKeyTarget struct {
+	*fastmap.MultiKeyHashMap[uint16, int]
+}
+
+func (m multiKeyTarget) Put(key uint16, value int) {
+	m.MultiKeyHashMap.Put([]uint16{key}, value)
+}
+
+func (m multiKeyTarget) Remove(key uint16) bool {
+	_, exists := m.Get(key)
+	m.MultiKeyHashMap.Remove(key)
+	return exists
+}
+
+func FuzzHashMap(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fastmaptest.RunOps(t, fastmaptest.DecodeOps(data), map[string]fastmaptest.Target{
+			"hashmap":    fastmap.NewHashMap[uint16, int](),
+			"threadsafe": fastmap.NewThreadSafeHashMap[uint16, int](),
+			"multikey":   multiKeyTarget{fastmap.NewMultiKeyHashMap[uint16, int]()},
+			"small":      fastmap.NewSmallHashMapWithShrink[uint16, int](4),
+		})
+	})
+}
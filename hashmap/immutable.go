@@ -0,0 +1,453 @@
+package fastmap
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+const (
+	hamtBits     = 5
+	hamtMask     = 1<<hamtBits - 1
+	hamtMaxShift = 64
+)
+
+// ImmutableHashMap is a persistent map based on a hash array mapped trie (HAMT).
+// Every modification returns a new version in O(log32 n) that shares all untouched nodes
+// with the original, so keeping old versions around is cheap. Safe for concurrent readers.
+// Example:
+//
+//	state := NewImmutableHashMap[string, int]()
+//	next := state.With("count", 1)
+//	// state is still empty, next contains "count"
+type ImmutableHashMap[K comparable, V any] struct {
+	root *hamtNode[K, V]
+	size int
+	seed uint64
+	hash hashing.Func[K]
+}
+
+// hamtNode is a CHAMP-style node: entries stored inline for the bits in dataMap and child
+// nodes for the bits in nodeMap. Nodes below hamtMaxShift hold colliding entries unordered.
+type hamtNode[K comparable, V any] struct {
+	dataMap uint32
+	nodeMap uint32
+	entries []hamtEntry[K, V]
+	nodes   []*hamtNode[K, V]
+	owner   *hamtOwner
+}
+
+type hamtEntry[K comparable, V any] struct {
+	hash  uint64
+	key   K
+	value V
+}
+
+// hamtOwner marks the nodes a TransientHashMap may modify in place. It must not be zero-sized,
+// pointers to distinct zero-sized values may compare equal.
+type hamtOwner struct{ _ byte }
+
+// NewImmutableHashMap creates a new empty ImmutableHashMap
+// Example:
+//
+//	state := NewImmutableHashMap[string, User]()
+func NewImmutableHashMap[K comparable, V any]() *ImmutableHashMap[K, V] {
+	return &ImmutableHashMap[K, V]{
+		root: &hamtNode[K, V]{},
+		seed: hashing.RandomSeed(),
+		hash: hashing.For[K](),
+	}
+}
+
+// Get retrieves a value by key and returns whether it exists
+// Example:
+//
+//	if user, exists := state.Get("user123"); exists {
+//	    fmt.Printf("Found user: %v\n", user)
+//	}
+func (m *ImmutableHashMap[K, V]) Get(key K) (V, bool) {
+	return m.root.get(m.hash(m.seed, key), 0, key)
+}
+
+// Contains checks if a key exists
+func (m *ImmutableHashMap[K, V]) Contains(key K) bool {
+	_, exists := m.Get(key)
+	return exists
+}
+
+// Size returns the number of elements
+func (m *ImmutableHashMap[K, V]) Size() int {
+	return m.size
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *ImmutableHashMap[K, V]) IsEmpty() bool {
+	return m.size == 0
+}
+
+// With returns a new version with the key set to value, the receiver is unchanged
+// Example:
+//
+//	next := state.With("user123", User{Name: "John"})
+func (m *ImmutableHashMap[K, V]) With(key K, value V) *ImmutableHashMap[K, V] {
+	root, added := m.root.put(nil, m.hash(m.seed, key), 0, key, value)
+	return m.derive(root, added, false)
+}
+
+// Without returns a new version without the key, or the receiver itself if the key is absent
+// Example:
+//
+//	next := state.Without("user123")
+func (m *ImmutableHashMap[K, V]) Without(key K) *ImmutableHashMap[K, V] {
+	root, removed := m.root.remove(nil, m.hash(m.seed, key), 0, key)
+	if !removed {
+		return m
+	}
+	return m.derive(root, false, true)
+}
+
+// Update returns a new version with the key set to the result of fn, which receives the
+// current value and whether it exists
+// Example:
+//
+//	next := counters.Update("visits", func(count int, exists bool) int {
+//	    return count + 1
+//	})
+func (m *ImmutableHashMap[K, V]) Update(key K, fn func(value V, exists bool) V) *ImmutableHashMap[K, V] {
+	hash := m.hash(m.seed, key)
+	current, exists := m.root.get(hash, 0, key)
+	root, added := m.root.put(nil, hash, 0, key, fn(current, exists))
+	return m.derive(root, added, false)
+}
+
+// Keys returns a slice of all keys
+func (m *ImmutableHashMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.size)
+	m.root.forEach(func(key K, _ V) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *ImmutableHashMap[K, V]) Values() []V {
+	values := make([]V, 0, m.size)
+	m.root.forEach(func(_ K, value V) error {
+		values = append(values, value)
+		return nil
+	})
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails
+// Example:
+//
+//	err := state.ForEach(func(key string, user User) error {
+//	    fmt.Printf("User %s: %v\n", key, user)
+//	    return nil
+//	})
+func (m *ImmutableHashMap[K, V]) ForEach(callback func(K, V) error) error {
+	var failedKey K
+	err := m.root.forEach(func(key K, value V) error {
+		failedKey = key
+		return callback(key, value)
+	})
+	if err != nil {
+		return fmt.Errorf("ForEach operation failed at key %v: %w", failedKey, err)
+	}
+	return nil
+}
+
+// ToMap returns the contents as a regular map
+func (m *ImmutableHashMap[K, V]) ToMap() map[K]V {
+	result := make(map[K]V, m.size)
+	m.root.forEach(func(key K, value V) error {
+		result[key] = value
+		return nil
+	})
+	return result
+}
+
+// ToHashMap copies the contents into a new HashMap
+// Example:
+//
+//	hashMap := state.ToHashMap()
+func (m *ImmutableHashMap[K, V]) ToHashMap() *HashMap[K, V] {
+	return &HashMap[K, V]{data: m.ToMap()}
+}
+
+// ToImmutable copies the contents of the HashMap into a new ImmutableHashMap
+// Example:
+//
+//	state := hashMap.ToImmutable()
+func (h *HashMap[K, V]) ToImmutable() *ImmutableHashMap[K, V] {
+	transient := NewImmutableHashMap[K, V]().Transient()
+	for k, v := range h.data {
+		transient.Put(k, v)
+	}
+	return transient.Persistent()
+}
+
+// Transient returns a mutable builder that starts from this version. It modifies nodes it
+// has already copied in place, which makes bulk loads much cheaper than repeated With calls.
+// Example:
+//
+//	builder := NewImmutableHashMap[string, int]().Transient()
+//	for i, name := range names {
+//	    builder.Put(name, i)
+//	}
+//	state := builder.Persistent()
+func (m *ImmutableHashMap[K, V]) Transient() *TransientHashMap[K, V] {
+	return &TransientHashMap[K, V]{
+		root:  m.root,
+		size:  m.size,
+		seed:  m.seed,
+		hash:  m.hash,
+		owner: &hamtOwner{},
+	}
+}
+
+func (m *ImmutableHashMap[K, V]) derive(root *hamtNode[K, V], added, removed bool) *ImmutableHashMap[K, V] {
+	size := m.size
+	if added {
+		size++
+	}
+	if removed {
+		size--
+	}
+	return &ImmutableHashMap[K, V]{root: root, size: size, seed: m.seed, hash: m.hash}
+}
+
+// TransientHashMap is a single-owner mutable builder for ImmutableHashMap. It is not safe for
+// concurrent use.
+type TransientHashMap[K comparable, V any] struct {
+	root  *hamtNode[K, V]
+	size  int
+	seed  uint64
+	hash  hashing.Func[K]
+	owner *hamtOwner
+}
+
+// Put adds or updates a key-value pair in place
+func (t *TransientHashMap[K, V]) Put(key K, value V) {
+	root, added := t.root.put(t.owner, t.hash(t.seed, key), 0, key, value)
+	t.root = root
+	if added {
+		t.size++
+	}
+}
+
+// Remove deletes a key in place
+func (t *TransientHashMap[K, V]) Remove(key K) {
+	root, removed := t.root.remove(t.owner, t.hash(t.seed, key), 0, key)
+	t.root = root
+	if removed {
+		t.size--
+	}
+}
+
+// Get retrieves a value by key and returns whether it exists
+func (t *TransientHashMap[K, V]) Get(key K) (V, bool) {
+	return t.root.get(t.hash(t.seed, key), 0, key)
+}
+
+// Size returns the number of elements
+func (t *TransientHashMap[K, V]) Size() int {
+	return t.size
+}
+
+// Persistent returns the built ImmutableHashMap. The builder can still be used afterwards,
+// later changes copy nodes again and never affect the returned map.
+func (t *TransientHashMap[K, V]) Persistent() *ImmutableHashMap[K, V] {
+	t.owner = &hamtOwner{}
+	return &ImmutableHashMap[K, V]{root: t.root, size: t.size, seed: t.seed, hash: t.hash}
+}
+
+func (n *hamtNode[K, V]) get(hash uint64, shift uint, key K) (V, bool) {
+	for shift < hamtMaxShift {
+		bit := hamtBit(hash, shift)
+		if n.dataMap&bit != 0 {
+			entry := &n.entries[hamtIndex(n.dataMap, bit)]
+			if entry.key == key {
+				return entry.value, true
+			}
+			break
+		}
+		if n.nodeMap&bit == 0 {
+			break
+		}
+		n = n.nodes[hamtIndex(n.nodeMap, bit)]
+		shift += hamtBits
+	}
+	if shift >= hamtMaxShift {
+		for i := range n.entries {
+			if n.entries[i].key == key {
+				return n.entries[i].value, true
+			}
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func (n *hamtNode[K, V]) put(owner *hamtOwner, hash uint64, shift uint, key K, value V) (*hamtNode[K, V], bool) {
+	if shift >= hamtMaxShift {
+		for i := range n.entries {
+			if n.entries[i].key == key {
+				edited := n.editable(owner)
+				edited.entries[i].value = value
+				return edited, false
+			}
+		}
+		edited := n.editable(owner)
+		edited.entries = append(edited.entries, hamtEntry[K, V]{hash, key, value})
+		return edited, true
+	}
+
+	bit := hamtBit(hash, shift)
+	if n.dataMap&bit != 0 {
+		i := hamtIndex(n.dataMap, bit)
+		existing := n.entries[i]
+		if existing.key == key {
+			edited := n.editable(owner)
+			edited.entries[i].value = value
+			return edited, false
+		}
+		// Two keys share this slot, push both one level down
+		child := newHamtPair(owner, existing, hamtEntry[K, V]{hash, key, value}, shift+hamtBits)
+		edited := n.editable(owner)
+		edited.entries = hamtRemove(edited.entries, i)
+		edited.dataMap &^= bit
+		edited.nodeMap |= bit
+		edited.nodes = hamtInsert(edited.nodes, hamtIndex(edited.nodeMap, bit), child)
+		return edited, true
+	}
+	if n.nodeMap&bit != 0 {
+		i := hamtIndex(n.nodeMap, bit)
+		child, added := n.nodes[i].put(owner, hash, shift+hamtBits, key, value)
+		edited := n.editable(owner)
+		edited.nodes[i] = child
+		return edited, added
+	}
+
+	edited := n.editable(owner)
+	edited.dataMap |= bit
+	edited.entries = hamtInsert(edited.entries, hamtIndex(edited.dataMap, bit), hamtEntry[K, V]{hash, key, value})
+	return edited, true
+}
+
+func (n *hamtNode[K, V]) remove(owner *hamtOwner, hash uint64, shift uint, key K) (*hamtNode[K, V], bool) {
+	if shift >= hamtMaxShift {
+		for i := range n.entries {
+			if n.entries[i].key == key {
+				edited := n.editable(owner)
+				edited.entries = hamtRemove(edited.entries, i)
+				return edited, true
+			}
+		}
+		return n, false
+	}
+
+	bit := hamtBit(hash, shift)
+	if n.dataMap&bit != 0 {
+		i := hamtIndex(n.dataMap, bit)
+		if n.entries[i].key != key {
+			return n, false
+		}
+		edited := n.editable(owner)
+		edited.entries = hamtRemove(edited.entries, i)
+		edited.dataMap &^= bit
+		return edited, true
+	}
+	if n.nodeMap&bit == 0 {
+		return n, false
+	}
+
+	i := hamtIndex(n.nodeMap, bit)
+	child, removed := n.nodes[i].remove(owner, hash, shift+hamtBits, key)
+	if !removed {
+		return n, false
+	}
+	edited := n.editable(owner)
+	if len(child.nodes) == 0 && len(child.entries) <= 1 {
+		// Pull a lone remaining entry back up so the trie stays canonical
+		edited.nodes = hamtRemove(edited.nodes, i)
+		edited.nodeMap &^= bit
+		if len(child.entries) == 1 {
+			edited.dataMap |= bit
+			edited.entries = hamtInsert(edited.entries, hamtIndex(edited.dataMap, bit), child.entries[0])
+		}
+		return edited, true
+	}
+	edited.nodes[i] = child
+	return edited, true
+}
+
+func (n *hamtNode[K, V]) forEach(callback func(K, V) error) error {
+	for i := range n.entries {
+		if err := callback(n.entries[i].key, n.entries[i].value); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.nodes {
+		if err := child.forEach(callback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// editable returns n itself if owner may modify it in place, otherwise a copy owned by owner
+func (n *hamtNode[K, V]) editable(owner *hamtOwner) *hamtNode[K, V] {
+	if owner != nil && n.owner == owner {
+		return n
+	}
+	return &hamtNode[K, V]{
+		dataMap: n.dataMap,
+		nodeMap: n.nodeMap,
+		entries: append([]hamtEntry[K, V](nil), n.entries...),
+		nodes:   append([]*hamtNode[K, V](nil), n.nodes...),
+		owner:   owner,
+	}
+}
+
+// newHamtPair builds the subtree holding two entries whose hashes agree up to shift
+func newHamtPair[K comparable, V any](owner *hamtOwner, a, b hamtEntry[K, V], shift uint) *hamtNode[K, V] {
+	if shift >= hamtMaxShift {
+		return &hamtNode[K, V]{entries: []hamtEntry[K, V]{a, b}, owner: owner}
+	}
+	bitA, bitB := hamtBit(a.hash, shift), hamtBit(b.hash, shift)
+	if bitA == bitB {
+		child := newHamtPair(owner, a, b, shift+hamtBits)
+		return &hamtNode[K, V]{nodeMap: bitA, nodes: []*hamtNode[K, V]{child}, owner: owner}
+	}
+	if bitA > bitB {
+		a, b = b, a
+	}
+	return &hamtNode[K, V]{dataMap: bitA | bitB, entries: []hamtEntry[K, V]{a, b}, owner: owner}
+}
+
+func hamtBit(hash uint64, shift uint) uint32 {
+	return 1 << ((hash >> shift) & hamtMask)
+}
+
+func hamtIndex(bitmap, bit uint32) int {
+	return bits.OnesCount32(bitmap & (bit - 1))
+}
+
+func hamtInsert[T any](items []T, i int, item T) []T {
+	var zero T
+	items = append(items, zero)
+	copy(items[i+1:], items[i:])
+	items[i] = item
+	return items
+}
+
+func hamtRemove[T any](items []T, i int) []T {
+	copy(items[i:], items[i+1:])
+	var zero T
+	items[len(items)-1] = zero
+	return items[:len(items)-1]
+}
@@ -0,0 +1,32 @@
+package fastmap_test
+
+import (
+	"testing"
+
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+func BenchmarkImmutableHashMapWith(b *testing.B) {
+	builder := fastmap.NewImmutableHashMap[int, int]().Transient()
+	for i := 0; i < 10000; i++ {
+		builder.Put(i, i)
+	}
+	m := builder.Persistent()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m = m.With(i%20000, i)
+	}
+}
+
+func BenchmarkHashMapCopyOnWrite(b *testing.B) {
+	h := fastmap.NewHashMap[int, int]()
+	for i := 0; i < 10000; i++ {
+		h.Put(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		next := fastmap.FromMap(h.ToMap())
+		next.Put(i%20000, i)
+		h = next
+	}
+}
@@ -0,0 +1,209 @@
+package fastmap
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestImmutableHashMapPersistence(t *testing.T) {
+	empty := NewImmutableHashMap[string, int]()
+	one := empty.With("a", 1)
+	two := one.With("b", 2)
+	updated := two.With("a", 10)
+	removed := updated.Without("b")
+
+	if !empty.IsEmpty() || empty.Contains("a") {
+		t.Error("empty version was modified")
+	}
+	if value, _ := one.Get("a"); value != 1 || one.Size() != 1 || one.Contains("b") {
+		t.Errorf("version one changed: %v", one.ToMap())
+	}
+	if value, _ := two.Get("a"); value != 1 || two.Size() != 2 {
+		t.Errorf("version two changed: %v", two.ToMap())
+	}
+	if value, _ := updated.Get("a"); value != 10 || updated.Size() != 2 {
+		t.Errorf("updated version = %v", updated.ToMap())
+	}
+	if removed.Size() != 1 || removed.Contains("b") {
+		t.Errorf("removed version = %v", removed.ToMap())
+	}
+	if removed.Without("missing") != removed {
+		t.Error("Without of a missing key should return the receiver")
+	}
+}
+
+func TestImmutableHashMapUpdate(t *testing.T) {
+	increment := func(count int, exists bool) int {
+		if !exists {
+			return 1
+		}
+		return count + 1
+	}
+	m := NewImmutableHashMap[string, int]()
+	next := m.Update("visits", increment).Update("visits", increment)
+	if value, _ := next.Get("visits"); value != 2 || next.Size() != 1 {
+		t.Errorf("Update = %v, want visits=2", next.ToMap())
+	}
+}
+
+func TestImmutableHashMapRandomOperations(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	reference := make(map[int]int)
+	m := NewImmutableHashMap[int, int]()
+	versions := []*ImmutableHashMap[int, int]{m}
+	snapshots := []map[int]int{{}}
+
+	for i := 0; i < 20000; i++ {
+		key := rng.IntN(2000)
+		if rng.IntN(3) == 0 {
+			m = m.Without(key)
+			delete(reference, key)
+		} else {
+			m = m.With(key, i)
+			reference[key] = i
+		}
+		if i%2000 == 0 {
+			versions = append(versions, m)
+			copied := make(map[int]int, len(reference))
+			for k, v := range reference {
+				copied[k] = v
+			}
+			snapshots = append(snapshots, copied)
+		}
+	}
+	versions = append(versions, m)
+	snapshots = append(snapshots, reference)
+
+	for i, version := range versions {
+		assertImmutableEquals(t, fmt.Sprintf("version %d", i), version, snapshots[i])
+	}
+}
+
+func TestImmutableHashMapCollisions(t *testing.T) {
+	m := NewImmutableHashMap[int, string]()
+	// Every key gets one of two hashes so all keys end up in collision nodes
+	m.hash = func(_ uint64, key int) uint64 { return uint64(key % 2) }
+
+	reference := make(map[int]string)
+	for i := 0; i < 50; i++ {
+		m = m.With(i, fmt.Sprint(i))
+		reference[i] = fmt.Sprint(i)
+	}
+	assertImmutableEquals(t, "after inserts", m, reference)
+
+	before := m
+	for i := 0; i < 50; i += 3 {
+		m = m.Without(i)
+		delete(reference, i)
+	}
+	assertImmutableEquals(t, "after removals", m, reference)
+	if before.Size() != 50 {
+		t.Errorf("earlier version lost entries: size %d", before.Size())
+	}
+	for i := 0; i < 50; i++ {
+		m = m.Without(i)
+	}
+	if !m.IsEmpty() || len(m.root.nodes) != 0 || len(m.root.entries) != 0 {
+		t.Errorf("expected an empty root after removing all keys, got %+v", m.root)
+	}
+}
+
+func TestImmutableHashMapStructuralSharing(t *testing.T) {
+	m := NewImmutableHashMap[int, int]().Transient()
+	for i := 0; i < 10000; i++ {
+		m.Put(i, i)
+	}
+	base := m.Persistent()
+	next := base.With(-1, -1)
+
+	shared := 0
+	for _, child := range next.root.nodes {
+		for _, original := range base.root.nodes {
+			if child == original {
+				shared++
+				break
+			}
+		}
+	}
+	if shared < len(base.root.nodes)-1 {
+		t.Errorf("expected all but one root child to be shared, shared %d of %d", shared, len(base.root.nodes))
+	}
+}
+
+func TestTransientHashMap(t *testing.T) {
+	base := NewImmutableHashMap[int, int]().With(1, 1).With(2, 2)
+	builder := base.Transient()
+	for i := 3; i < 1000; i++ {
+		builder.Put(i, i)
+	}
+	builder.Remove(1)
+	builder.Remove(5000)
+	if builder.Size() != 998 {
+		t.Errorf("builder size = %d, want 998", builder.Size())
+	}
+	if value, exists := builder.Get(999); !exists || value != 999 {
+		t.Errorf("builder Get(999) = (%d, %v)", value, exists)
+	}
+
+	built := builder.Persistent()
+	builder.Put(2, 200)
+	builder.Remove(3)
+
+	if base.Size() != 2 || !base.Contains(1) {
+		t.Errorf("transient modified its source: %v", base.ToMap())
+	}
+	if value, _ := built.Get(2); value != 2 || !built.Contains(3) || built.Size() != 998 {
+		t.Error("changes after Persistent leaked into the built map")
+	}
+}
+
+func TestImmutableHashMapConversions(t *testing.T) {
+	h := NewHashMap[string, int]()
+	h.Put("a", 1)
+	h.Put("b", 2)
+
+	m := h.ToImmutable()
+	if m.Size() != 2 {
+		t.Errorf("ToImmutable size = %d, want 2", m.Size())
+	}
+	back := m.With("c", 3).ToHashMap()
+	if back.Size() != 3 || h.Size() != 2 {
+		t.Errorf("ToHashMap = %v, source %v", back.ToMap(), h.ToMap())
+	}
+	if len(m.Keys()) != 2 || len(m.Values()) != 2 {
+		t.Error("Keys and Values should return every entry")
+	}
+}
+
+func TestImmutableHashMapForEachError(t *testing.T) {
+	m := NewImmutableHashMap[string, int]().With("a", 1)
+	stop := errors.New("stop")
+	if err := m.ForEach(func(string, int) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ForEach error = %v, want wrapped stop", err)
+	}
+}
+
+func assertImmutableEquals[K comparable, V comparable](t *testing.T, name string, m *ImmutableHashMap[K, V], want map[K]V) {
+	t.Helper()
+	if m.Size() != len(want) {
+		t.Errorf("%s: size = %d, want %d", name, m.Size(), len(want))
+	}
+	for k, v := range want {
+		if got, exists := m.Get(k); !exists || got != v {
+			t.Errorf("%s: Get(%v) = (%v, %v), want %v", name, k, got, exists, v)
+		}
+	}
+	count := 0
+	m.ForEach(func(k K, v V) error {
+		count++
+		if want[k] != v {
+			t.Errorf("%s: iterated %v=%v, want %v", name, k, v, want[k])
+		}
+		return nil
+	})
+	if count != len(want) {
+		t.Errorf("%s: iterated %d entries, want %d", name, count, len(want))
+	}
+}
@@ -75,6 +75,7 @@ func (t *ThreadSafeHashMap[K, V]) ForEach(callback func(K, V) error) error {
 func (t *ThreadSafeHashMap[K, V]) UpdateValue(key K, newValue V) bool {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	t.recordPreimage(key)
 	return t.data.UpdateValue(key, newValue)
 }
 
@@ -89,5 +90,8 @@ func (t *ThreadSafeHashMap[K, V]) PutAll(other *ThreadSafeHashMap[K, V]) {
 	t.mutex.Lock()
 	defer other.mutex.RUnlock()
 	defer t.mutex.Unlock()
+	for key := range other.data.data {
+		t.recordPreimage(key)
+	}
 	t.data.PutAll(other.data)
 }
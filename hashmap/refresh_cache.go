@@ -0,0 +1,252 @@
+package fastmap
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrInvalidCacheConfig is returned when a RefreshCacheConfig has invalid durations or a missing loader
+var ErrInvalidCacheConfig = errors.New("invalid cache config")
+
+// ErrLoaderPanicked is returned by Get when the loader panicked
+var ErrLoaderPanicked = errors.New("loader panicked")
+
+// Clock provides the current time, allowing tests to control time-dependent behavior
+// Example:
+//
+//	type fixedClock struct{ now time.Time }
+//	func (c fixedClock) Now() time.Time { return c.now }
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Loader loads the value for a key when it is missing, stale or expired
+type Loader[K comparable, V any] func(key K) (V, error)
+
+// RefreshCacheConfig configures the refresh and expiry windows of a RefreshingCache
+//   - RefreshAfter: age after which a read returns the cached value and starts an asynchronous refresh
+//   - ExpireAfter: age after which a read blocks until the value has been reloaded
+//   - Clock: time source, defaults to the system clock when nil
+type RefreshCacheConfig struct {
+	RefreshAfter time.Duration
+	ExpireAfter  time.Duration
+	Clock        Clock
+}
+
+type cacheEntry[V any] struct {
+	value    V
+	loadedAt time.Time
+}
+
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// superseded is set by a Put or Remove of the key while the load runs, its result is then
+	// older than the cache contents and must not be stored
+	superseded bool
+}
+
+// RefreshingCache is a read-through cache on top of ThreadSafeHashMap that serves stale values
+// while refreshing them in the background (refresh-ahead / stale-while-revalidate).
+// Concurrent loads of the same key are coalesced into a single loader call.
+// Example:
+//
+//	cache, err := NewRefreshingCache(loadConfig, RefreshCacheConfig{
+//	    RefreshAfter: time.Minute,
+//	    ExpireAfter:  10 * time.Minute,
+//	})
+//	defer cache.Close()
+//	value, err := cache.Get("feature-flags")
+type RefreshingCache[K comparable, V any] struct {
+	data         *ThreadSafeHashMap[K, cacheEntry[V]]
+	loader       Loader[K, V]
+	refreshAfter time.Duration
+	expireAfter  time.Duration
+	clock        Clock
+
+	mutex   sync.Mutex
+	calls   map[K]*loadCall[V]
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// NewRefreshingCache creates a new RefreshingCache, returns an error if the config is invalid
+// Example:
+//
+//	cache, err := NewRefreshingCache(func(key string) (Config, error) {
+//	    return fetchConfig(key)
+//	}, RefreshCacheConfig{RefreshAfter: 30 * time.Second, ExpireAfter: 5 * time.Minute})
+func NewRefreshingCache[K comparable, V any](loader Loader[K, V], config RefreshCacheConfig) (*RefreshingCache[K, V], error) {
+	if loader == nil {
+		return nil, fmt.Errorf("%w: loader is nil", ErrInvalidCacheConfig)
+	}
+	if config.RefreshAfter <= 0 {
+		return nil, fmt.Errorf("%w: RefreshAfter must be positive, got %v", ErrInvalidCacheConfig, config.RefreshAfter)
+	}
+	if config.ExpireAfter < config.RefreshAfter {
+		return nil, fmt.Errorf("%w: ExpireAfter (%v) must not be less than RefreshAfter (%v)",
+			ErrInvalidCacheConfig, config.ExpireAfter, config.RefreshAfter)
+	}
+	clock := config.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &RefreshingCache[K, V]{
+		data:         NewThreadSafeHashMap[K, cacheEntry[V]](),
+		loader:       loader,
+		refreshAfter: config.RefreshAfter,
+		expireAfter:  config.ExpireAfter,
+		clock:        clock,
+		calls:        make(map[K]*loadCall[V]),
+	}, nil
+}
+
+// Get returns the value for a key. Fresh values are returned directly, stale values are returned
+// immediately while one asynchronous refresh is started, and missing or expired values block on the loader.
+// A failed background refresh keeps the stale value, the next read past RefreshAfter retries it.
+// A blocking read that overlaps a Put of the same key returns the value put.
+// Example:
+//
+//	value, err := cache.Get("feature-flags")
+//	if err != nil {
+//	    return fmt.Errorf("load feature flags: %w", err)
+//	}
+func (c *RefreshingCache[K, V]) Get(key K) (V, error) {
+	if entry, exists := c.data.Get(key); exists {
+		age := c.clock.Now().Sub(entry.loadedAt)
+		if age < c.refreshAfter {
+			return entry.value, nil
+		}
+		if age < c.expireAfter {
+			c.refreshAsync(key)
+			return entry.value, nil
+		}
+	}
+
+	call, started := c.startLoad(key)
+	if started {
+		c.runLoad(key, call)
+	} else {
+		<-call.done
+	}
+	if call.superseded {
+		// A Put during the load is newer than what the loader returned
+		if entry, exists := c.data.Get(key); exists {
+			return entry.value, nil
+		}
+	}
+	return call.value, call.err
+}
+
+// Put stores a value directly, resetting its age
+// Example:
+//
+//	cache.Put("feature-flags", defaults)
+func (c *RefreshingCache[K, V]) Put(key K, value V) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.supersede(key)
+	c.data.Put(key, cacheEntry[V]{value: value, loadedAt: c.clock.Now()})
+}
+
+// Remove evicts a key so that the next Get reloads it
+// Example:
+//
+//	cache.Remove("feature-flags")
+func (c *RefreshingCache[K, V]) Remove(key K) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.supersede(key)
+	c.data.Remove(key)
+}
+
+// supersede keeps an in-flight load of key from overwriting a newer write, c.mutex must be held
+func (c *RefreshingCache[K, V]) supersede(key K) {
+	if call, inFlight := c.calls[key]; inFlight {
+		call.superseded = true
+	}
+}
+
+// Size returns the number of cached entries, including stale and expired ones
+// Example:
+//
+//	fmt.Printf("Cache holds %d entries\n", cache.Size())
+func (c *RefreshingCache[K, V]) Size() int {
+	return c.data.Size()
+}
+
+// Close stops new background refreshes and waits for in-flight refreshes to finish.
+// The cache remains readable after Close, expired values are then reloaded synchronously.
+// Example:
+//
+//	defer cache.Close()
+func (c *RefreshingCache[K, V]) Close() {
+	c.mutex.Lock()
+	c.closed = true
+	c.mutex.Unlock()
+	c.pending.Wait()
+}
+
+// refreshAsync starts a background reload of key unless one is already running or the cache is closed
+func (c *RefreshingCache[K, V]) refreshAsync(key K) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return
+	}
+	if _, inFlight := c.calls[key]; inFlight {
+		return
+	}
+	call := &loadCall[V]{done: make(chan struct{})}
+	c.calls[key] = call
+	c.pending.Add(1)
+	go func() {
+		defer c.pending.Done()
+		c.runLoad(key, call)
+	}()
+}
+
+// startLoad returns the in-flight load for key, registering a new one if none exists
+func (c *RefreshingCache[K, V]) startLoad(key K) (*loadCall[V], bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if call, inFlight := c.calls[key]; inFlight {
+		return call, false
+	}
+	call := &loadCall[V]{done: make(chan struct{})}
+	c.calls[key] = call
+	return call, true
+}
+
+// runLoad invokes the loader, stores a successful result unless the key was written meanwhile
+// and releases waiters. A panicking loader is reported as an error so waiters never hang.
+func (c *RefreshingCache[K, V]) runLoad(key K, call *loadCall[V]) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = fmt.Errorf("load key %v: %w: %v", key, ErrLoaderPanicked, r)
+		}
+		c.mutex.Lock()
+		if call.err == nil && !call.superseded {
+			c.data.Put(key, cacheEntry[V]{value: call.value, loadedAt: c.clock.Now()})
+		}
+		delete(c.calls, key)
+		c.mutex.Unlock()
+		close(call.done)
+	}()
+
+	value, err := c.loader(key)
+	if err != nil {
+		call.err = fmt.Errorf("load key %v: %w", key, err)
+		return
+	}
+	call.value = value
+}
@@ -0,0 +1,324 @@
+package fastmap_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestCache(t *testing.T, clock *fakeClock, loader fastmap.Loader[string, int]) *fastmap.RefreshingCache[string, int] {
+	t.Helper()
+	cache, err := fastmap.NewRefreshingCache(loader, fastmap.RefreshCacheConfig{
+		RefreshAfter: time.Minute,
+		ExpireAfter:  10 * time.Minute,
+		Clock:        clock,
+	})
+	if err != nil {
+		t.Fatalf("NewRefreshingCache failed: %v", err)
+	}
+	t.Cleanup(cache.Close)
+	return cache
+}
+
+func TestRefreshingCacheConfigValidation(t *testing.T) {
+	loader := func(key string) (int, error) { return 0, nil }
+
+	tests := []struct {
+		name   string
+		loader fastmap.Loader[string, int]
+		config fastmap.RefreshCacheConfig
+	}{
+		{"nil loader", nil, fastmap.RefreshCacheConfig{RefreshAfter: time.Second, ExpireAfter: time.Minute}},
+		{"zero refresh", loader, fastmap.RefreshCacheConfig{ExpireAfter: time.Minute}},
+		{"expire before refresh", loader, fastmap.RefreshCacheConfig{RefreshAfter: time.Minute, ExpireAfter: time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := fastmap.NewRefreshingCache(tt.loader, tt.config); !errors.Is(err, fastmap.ErrInvalidCacheConfig) {
+				t.Errorf("expected ErrInvalidCacheConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRefreshingCacheFreshHit(t *testing.T) {
+	clock := newFakeClock()
+	var loads atomic.Int32
+	cache := newTestCache(t, clock, func(key string) (int, error) {
+		return int(loads.Add(1)), nil
+	})
+
+	for i := 0; i < 3; i++ {
+		value, err := cache.Get("config")
+		if err != nil || value != 1 {
+			t.Fatalf("Get = (%v, %v), want (1, nil)", value, err)
+		}
+		clock.Advance(10 * time.Second)
+	}
+	if got := loads.Load(); got != 1 {
+		t.Errorf("expected 1 load, got %d", got)
+	}
+}
+
+func TestRefreshingCacheServesStaleWhileRefreshing(t *testing.T) {
+	clock := newFakeClock()
+	release := make(chan struct{})
+	var loads atomic.Int32
+	cache := newTestCache(t, clock, func(key string) (int, error) {
+		n := loads.Add(1)
+		if n > 1 {
+			<-release
+		}
+		return int(n), nil
+	})
+
+	if value, _ := cache.Get("config"); value != 1 {
+		t.Fatalf("initial Get = %d, want 1", value)
+	}
+	clock.Advance(2 * time.Minute)
+
+	// Every read in the refresh window returns the stale value without blocking
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := cache.Get("config"); err != nil || value != 1 {
+				t.Errorf("stale Get = (%v, %v), want (1, nil)", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	close(release)
+	cache.Close()
+
+	if got := loads.Load(); got != 2 {
+		t.Errorf("expected exactly one refresh, got %d loads", got)
+	}
+	if value, _ := cache.Get("config"); value != 2 {
+		t.Errorf("Get after refresh = %d, want 2", value)
+	}
+}
+
+func TestRefreshingCacheExpiredBlocks(t *testing.T) {
+	clock := newFakeClock()
+	var loads atomic.Int32
+	cache := newTestCache(t, clock, func(key string) (int, error) {
+		return int(loads.Add(1)), nil
+	})
+
+	cache.Get("config")
+	clock.Advance(11 * time.Minute)
+
+	value, err := cache.Get("config")
+	if err != nil || value != 2 {
+		t.Errorf("expired Get = (%v, %v), want (2, nil)", value, err)
+	}
+}
+
+func TestRefreshingCacheLoaderError(t *testing.T) {
+	clock := newFakeClock()
+	errBackend := errors.New("backend down")
+	fail := atomic.Bool{}
+	cache := newTestCache(t, clock, func(key string) (int, error) {
+		if fail.Load() {
+			return 0, errBackend
+		}
+		return 42, nil
+	})
+
+	fail.Store(true)
+	if _, err := cache.Get("config"); !errors.Is(err, errBackend) {
+		t.Fatalf("expected loader error, got %v", err)
+	}
+	if cache.Size() != 0 {
+		t.Errorf("failed load should not be cached, size %d", cache.Size())
+	}
+
+	// A failed refresh keeps the stale value
+	fail.Store(false)
+	cache.Get("config")
+	fail.Store(true)
+	clock.Advance(2 * time.Minute)
+	if value, err := cache.Get("config"); err != nil || value != 42 {
+		t.Errorf("stale Get = (%v, %v), want (42, nil)", value, err)
+	}
+	cache.Close()
+	if value, err := cache.Get("config"); err != nil || value != 42 {
+		t.Errorf("Get after failed refresh = (%v, %v), want (42, nil)", value, err)
+	}
+}
+
+func TestRefreshingCacheCloseWaitsForRefresh(t *testing.T) {
+	clock := newFakeClock()
+	started := make(chan struct{})
+	var finished atomic.Bool
+	var loads atomic.Int32
+	cache := newTestCache(t, clock, func(key string) (int, error) {
+		if loads.Add(1) > 1 {
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			finished.Store(true)
+		}
+		return 1, nil
+	})
+
+	cache.Get("config")
+	clock.Advance(2 * time.Minute)
+	cache.Get("config")
+	<-started
+
+	cache.Close()
+	if !finished.Load() {
+		t.Error("Close returned before the in-flight refresh finished")
+	}
+
+	// No refreshes are started after Close
+	clock.Advance(2 * time.Minute)
+	cache.Get("config")
+	if got := loads.Load(); got != 2 {
+		t.Errorf("expected no refresh after Close, got %d loads", got)
+	}
+}
+
+func TestRefreshingCachePutAndRemove(t *testing.T) {
+	clock := newFakeClock()
+	cache := newTestCache(t, clock, func(key string) (int, error) {
+		return 7, nil
+	})
+
+	cache.Put("config", 3)
+	if value, _ := cache.Get("config"); value != 3 {
+		t.Errorf("Get after Put = %d, want 3", value)
+	}
+	cache.Remove("config")
+	if value, _ := cache.Get("config"); value != 7 {
+		t.Errorf("Get after Remove = %d, want 7", value)
+	}
+}
+
+func TestRefreshingCacheLoaderPanic(t *testing.T) {
+	clock := newFakeClock()
+	var loads atomic.Int32
+	cache := newTestCache(t, clock, func(key string) (int, error) {
+		if loads.Add(1) == 1 {
+			panic("backend exploded")
+		}
+		return 5, nil
+	})
+
+	if _, err := cache.Get("config"); !errors.Is(err, fastmap.ErrLoaderPanicked) {
+		t.Fatalf("Get error = %v, want ErrLoaderPanicked", err)
+	}
+
+	// The failed load must not leave the key blocked
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if value, err := cache.Get("config"); err != nil || value != 5 {
+			t.Errorf("Get after panic = (%d, %v), want 5", value, err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get blocked after the loader panicked")
+	}
+}
+
+func TestRefreshingCacheRefreshDoesNotOverwriteNewerWrites(t *testing.T) {
+	for _, write := range []string{"Put", "Remove"} {
+		t.Run(write, func(t *testing.T) {
+			clock := newFakeClock()
+			release := make(chan struct{})
+			started := make(chan struct{}, 1)
+			var loads atomic.Int32
+			cache := newTestCache(t, clock, func(key string) (int, error) {
+				if loads.Add(1) > 1 {
+					started <- struct{}{}
+					<-release
+					return 100, nil
+				}
+				return 1, nil
+			})
+
+			cache.Get("config")
+			clock.Advance(2 * time.Minute)
+			cache.Get("config") // stale, starts a background refresh
+			<-started
+
+			if write == "Put" {
+				cache.Put("config", 2)
+			} else {
+				cache.Remove("config")
+			}
+			close(release)
+			cache.Close()
+
+			want := 2
+			if write == "Remove" {
+				// The next Get reloads instead of seeing the superseded refresh
+				want = 100
+			}
+			if value, _ := cache.Get("config"); value != want {
+				t.Errorf("Get = %d, want %d", value, want)
+			}
+			if write == "Remove" && loads.Load() != 3 {
+				t.Errorf("loader ran %d times, want a fresh load after Remove", loads.Load())
+			}
+		})
+	}
+}
+
+func TestRefreshingCacheLoadSupersededByPut(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	cache := newTestCache(t, newFakeClock(), func(key string) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+
+	result := make(chan int)
+	go func() {
+		value, _ := cache.Get("config")
+		result <- value
+	}()
+	<-started
+	cache.Put("config", 2)
+	close(release)
+
+	if value := <-result; value != 2 {
+		t.Errorf("Get = %d, want the value put during the load", value)
+	}
+	if value, _ := cache.Get("config"); value != 2 {
+		t.Errorf("cached value = %d, want 2", value)
+	}
+}
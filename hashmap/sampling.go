@@ -0,0 +1,132 @@
+package fastmap
+
+import (
+	"cmp"
+	"container/heap"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+// RandomKey returns a uniformly random key, or false if the HashMap is empty. Go maps cannot be
+// indexed, so it reads every key once: each key gets a pseudo-random rank from a hash seeded by
+// rng and the smallest rank wins. This keeps results reproducible for a seeded rng even though
+// map iteration order is not.
+// Example:
+//
+//	rng := rand.New(rand.NewPCG(1, 2))
+//	if key, ok := hashMap.RandomKey(rng); ok {
+//	    fmt.Printf("Picked %v\n", key)
+//	}
+func (h *HashMap[K, V]) RandomKey(rng *rand.Rand) (K, bool) {
+	return randomKey(h.data, rng)
+}
+
+// Sample returns up to n distinct keys chosen uniformly at random without replacement, in
+// random order. It reads every key once and keeps the n keys with the smallest seeded rank.
+// Example:
+//
+//	rng := rand.New(rand.NewPCG(1, 2))
+//	canary := hashMap.Sample(100, rng)
+func (h *HashMap[K, V]) Sample(n int, rng *rand.Rand) []K {
+	return sample(h.data, n, rng)
+}
+
+// WeightedChoice returns a random key chosen with probability proportional to weight(key, value).
+// Entries with a weight of zero or less are never chosen, false is returned if no entry has a
+// positive weight. It reads every entry once.
+// Example:
+//
+//	rng := rand.New(rand.NewPCG(1, 2))
+//	backend, ok := backends.WeightedChoice(func(name string, b Backend) float64 {
+//	    return b.Capacity
+//	}, rng)
+func (h *HashMap[K, V]) WeightedChoice(weight func(K, V) float64, rng *rand.Rand) (K, bool) {
+	return weightedChoice(h.data, weight, rng)
+}
+
+// RandomKey returns a uniformly random key with read lock, see HashMap.RandomKey
+func (t *ThreadSafeHashMap[K, V]) RandomKey(rng *rand.Rand) (K, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return randomKey(t.data.data, rng)
+}
+
+// Sample returns up to n random distinct keys with read lock, see HashMap.Sample
+func (t *ThreadSafeHashMap[K, V]) Sample(n int, rng *rand.Rand) []K {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return sample(t.data.data, n, rng)
+}
+
+// WeightedChoice returns a key chosen with probability proportional to its weight with read lock,
+// see HashMap.WeightedChoice
+func (t *ThreadSafeHashMap[K, V]) WeightedChoice(weight func(K, V) float64, rng *rand.Rand) (K, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return weightedChoice(t.data.data, weight, rng)
+}
+
+func randomKey[K comparable, V any](data map[K]V, rng *rand.Rand) (K, bool) {
+	var chosen K
+	if len(data) == 0 {
+		return chosen, false
+	}
+	seed, hash := rng.Uint64(), hashing.For[K]()
+	best := uint64(math.MaxUint64)
+	for key := range data {
+		if rank := hash(seed, key); rank <= best {
+			best, chosen = rank, key
+		}
+	}
+	return chosen, true
+}
+
+func sample[K comparable, V any](data map[K]V, n int, rng *rand.Rand) []K {
+	if n <= 0 || len(data) == 0 {
+		return nil
+	}
+	seed, hash := rng.Uint64(), hashing.For[K]()
+	ranked := make(scanPage[K], 0, min(n, len(data)))
+	for key := range data {
+		rank := hash(seed, key)
+		switch {
+		case len(ranked) < n:
+			heap.Push(&ranked, scanKey[K]{rank, key})
+		case rank < ranked[0].hash:
+			ranked[0] = scanKey[K]{rank, key}
+			heap.Fix(&ranked, 0)
+		}
+	}
+	// Rank order is random but, unlike heap order, independent of map iteration order
+	slices.SortFunc(ranked, func(a, b scanKey[K]) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+	keys := make([]K, len(ranked))
+	for i, k := range ranked {
+		keys[i] = k.key
+	}
+	return keys
+}
+
+// weightedChoice gives every key an exponentially distributed score with rate equal to its
+// weight and picks the smallest, which selects each key with probability weight / total
+func weightedChoice[K comparable, V any](data map[K]V, weight func(K, V) float64, rng *rand.Rand) (K, bool) {
+	var chosen K
+	seed, hash := rng.Uint64(), hashing.For[K]()
+	best, found := math.Inf(1), false
+	for key, value := range data {
+		w := weight(key, value)
+		if !(w > 0) {
+			continue
+		}
+		// Uniform in (0, 1) from the top 53 bits of the seeded hash
+		u := (float64(hash(seed, key)>>11) + 0.5) / (1 << 53)
+		if score := -math.Log(u) / w; score < best {
+			best, chosen, found = score, key, true
+		}
+	}
+	return chosen, found
+}
@@ -0,0 +1,136 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+func newSamplingMap(n int) *fastmap.HashMap[string, int] {
+	h := fastmap.NewHashMap[string, int]()
+	for i := 0; i < n; i++ {
+		h.Put(fmt.Sprintf("key%d", i), i)
+	}
+	return h
+}
+
+func TestRandomKeyIsUniform(t *testing.T) {
+	h := newSamplingMap(10)
+	rng := rand.New(rand.NewPCG(1, 2))
+	counts := make(map[string]int)
+	for i := 0; i < 20000; i++ {
+		key, ok := h.RandomKey(rng)
+		if !ok {
+			t.Fatal("RandomKey failed on a non-empty map")
+		}
+		counts[key]++
+	}
+	for key, count := range counts {
+		if count < 1600 || count > 2400 {
+			t.Errorf("%s drawn %d times, want about 2000", key, count)
+		}
+	}
+	if len(counts) != 10 {
+		t.Errorf("expected all 10 keys to be drawn, got %d", len(counts))
+	}
+
+	if _, ok := fastmap.NewHashMap[string, int]().RandomKey(rng); ok {
+		t.Error("RandomKey on an empty map should return false")
+	}
+}
+
+func TestSamplingIsReproducible(t *testing.T) {
+	// Same contents inserted in a different order must give the same draws for the same seed
+	a := newSamplingMap(100)
+	b := fastmap.NewHashMap[string, int]()
+	for i := 99; i >= 0; i-- {
+		b.Put(fmt.Sprintf("key%d", i), i)
+	}
+	rngA, rngB := rand.New(rand.NewPCG(9, 9)), rand.New(rand.NewPCG(9, 9))
+
+	keyA, _ := a.RandomKey(rngA)
+	keyB, _ := b.RandomKey(rngB)
+	if keyA != keyB {
+		t.Errorf("RandomKey differs for the same seed: %s and %s", keyA, keyB)
+	}
+	if sampleA, sampleB := a.Sample(10, rngA), b.Sample(10, rngB); !slices.Equal(sampleA, sampleB) {
+		t.Errorf("Sample differs for the same seed: %v and %v", sampleA, sampleB)
+	}
+	weight := func(_ string, v int) float64 { return float64(v) }
+	choiceA, _ := a.WeightedChoice(weight, rngA)
+	choiceB, _ := b.WeightedChoice(weight, rngB)
+	if choiceA != choiceB {
+		t.Errorf("WeightedChoice differs for the same seed: %s and %s", choiceA, choiceB)
+	}
+}
+
+func TestSample(t *testing.T) {
+	h := newSamplingMap(50)
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	keys := h.Sample(20, rng)
+	if len(keys) != 20 {
+		t.Fatalf("Sample(20) returned %d keys", len(keys))
+	}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if seen[key] || !h.Contains(key) {
+			t.Errorf("Sample returned duplicate or unknown key %s", key)
+		}
+		seen[key] = true
+	}
+
+	if all := h.Sample(100, rng); len(all) != 50 {
+		t.Errorf("Sample larger than the map should return every key, got %d", len(all))
+	}
+	if none := h.Sample(0, rng); len(none) != 0 {
+		t.Errorf("Sample(0) returned %v", none)
+	}
+}
+
+func TestWeightedChoice(t *testing.T) {
+	h := fastmap.NewHashMap[string, float64]()
+	h.Put("heavy", 3)
+	h.Put("light", 1)
+	h.Put("disabled", 0)
+	rng := rand.New(rand.NewPCG(5, 6))
+
+	counts := make(map[string]int)
+	for i := 0; i < 20000; i++ {
+		key, ok := h.WeightedChoice(func(_ string, w float64) float64 { return w }, rng)
+		if !ok {
+			t.Fatal("WeightedChoice failed")
+		}
+		counts[key]++
+	}
+	if counts["disabled"] != 0 {
+		t.Error("zero weight key was chosen")
+	}
+	if counts["heavy"] < 14000 || counts["heavy"] > 16000 {
+		t.Errorf("heavy chosen %d times, want about 15000", counts["heavy"])
+	}
+
+	if _, ok := h.WeightedChoice(func(string, float64) float64 { return 0 }, rng); ok {
+		t.Error("WeightedChoice without positive weights should return false")
+	}
+}
+
+func TestThreadSafeHashMapSampling(t *testing.T) {
+	m := fastmap.NewThreadSafeHashMap[int, int]()
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	rng := rand.New(rand.NewPCG(7, 8))
+	if key, ok := m.RandomKey(rng); !ok || key < 0 || key >= 100 {
+		t.Errorf("RandomKey = (%d, %v)", key, ok)
+	}
+	if keys := m.Sample(5, rng); len(keys) != 5 {
+		t.Errorf("Sample(5) returned %v", keys)
+	}
+	if key, ok := m.WeightedChoice(func(k, _ int) float64 { return float64(k % 2) }, rng); !ok || key%2 != 1 {
+		t.Errorf("WeightedChoice = (%d, %v), want an odd key", key, ok)
+	}
+}
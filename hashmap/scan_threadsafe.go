@@ -0,0 +1,99 @@
+package fastmap
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+// scanSeed pins the hash that orders a scan, cursors stay valid across calls
+const scanSeed = 0
+
+// Scan returns a page of keys starting at cursor and the cursor for the next page, which is 0
+// once the scan is complete. Start with cursor 0. Keys are visited in the order of a fixed
+// 64-bit hash, so every key present for the whole scan is returned exactly once even if the
+// map is modified between calls. A page holds count keys plus any keys sharing the hash of
+// its last key, so colliding keys are never split across pages.
+//
+// Go maps expose no buckets, so every call walks and hashes the whole map under the read lock:
+// a page costs O(n log count) and a full scan O(n²/count), writers wait for each page.
+// Pointers and channels are ordered by address, their cursors are only meaningful within the
+// process that produced them. Keys that are or contain interfaces have no fixed order, Scan
+// returns all of them in a single page with cursor 0.
+// Example:
+//
+//	cursor := uint64(0)
+//	for {
+//	    var keys []string
+//	    keys, cursor = safeMap.Scan(cursor, 100)
+//	    process(keys)
+//	    if cursor == 0 {
+//	        break
+//	    }
+//	}
+func (t *ThreadSafeHashMap[K, V]) Scan(cursor uint64, count int) ([]K, uint64) {
+	if count <= 0 {
+		count = 10
+	}
+	if hashing.HasInterface[K]() {
+		return t.Keys(), 0
+	}
+	hash := hashing.For[K]()
+	page := make(scanPage[K], 0, count)
+	// ties holds the keys left out of a full page whose hash equals the largest one in it
+	var ties []K
+
+	t.mutex.RLock()
+	for key := range t.data.data {
+		h := hash(scanSeed, key)
+		switch {
+		case h < cursor:
+		case len(page) < count:
+			heap.Push(&page, scanKey[K]{h, key})
+		case h == page[0].hash:
+			ties = append(ties, key)
+		case h < page[0].hash:
+			evicted := page[0]
+			page[0] = scanKey[K]{h, key}
+			heap.Fix(&page, 0)
+			if page[0].hash == evicted.hash {
+				ties = append(ties, evicted.key)
+			} else {
+				ties = ties[:0]
+			}
+		}
+	}
+	t.mutex.RUnlock()
+
+	keys := make([]K, len(page), len(page)+len(ties))
+	for i, k := range page {
+		keys[i] = k.key
+	}
+	keys = append(keys, ties...)
+	if len(page) < count || page[0].hash == math.MaxUint64 {
+		return keys, 0
+	}
+	return keys, page[0].hash + 1
+}
+
+type scanKey[K comparable] struct {
+	hash uint64
+	key  K
+}
+
+// scanPage is a max-heap on hash holding the smallest hashes seen so far
+type scanPage[K comparable] []scanKey[K]
+
+func (p scanPage[K]) Len() int           { return len(p) }
+func (p scanPage[K]) Less(i, j int) bool { return p[i].hash > p[j].hash }
+func (p scanPage[K]) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func (p *scanPage[K]) Push(x any) { *p = append(*p, x.(scanKey[K])) }
+
+func (p *scanPage[K]) Pop() any {
+	old := *p
+	x := old[len(old)-1]
+	*p = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,126 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+func TestThreadSafeHashMapScan(t *testing.T) {
+	m := fastmap.NewThreadSafeHashMap[string, int]()
+	for i := 0; i < 1000; i++ {
+		m.Put(fmt.Sprintf("key%d", i), i)
+	}
+
+	seen := make(map[string]int)
+	cursor := uint64(0)
+	pages := 0
+	for {
+		var keys []string
+		keys, cursor = m.Scan(cursor, 64)
+		if len(keys) > 64 {
+			t.Errorf("page of %d keys exceeds count 64", len(keys))
+		}
+		for _, key := range keys {
+			seen[key]++
+		}
+		pages++
+		// Churn keys outside the stable range between pages
+		m.Put(fmt.Sprintf("extra%d", pages), pages)
+		m.Remove(fmt.Sprintf("key%d", 900+pages))
+		if cursor == 0 {
+			break
+		}
+	}
+
+	for i := 0; i < 900; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if seen[key] != 1 {
+			t.Errorf("%s returned %d times, want once", key, seen[key])
+		}
+	}
+	if pages < 1000/64 {
+		t.Errorf("expected at least %d pages, got %d", 1000/64, pages)
+	}
+}
+
+func TestThreadSafeHashMapScanEmpty(t *testing.T) {
+	m := fastmap.NewThreadSafeHashMap[int, int]()
+	keys, cursor := m.Scan(0, 10)
+	if len(keys) != 0 || cursor != 0 {
+		t.Errorf("Scan of empty map = (%v, %d)", keys, cursor)
+	}
+}
+
+func TestThreadSafeHashMapScanKeepsTiesTogether(t *testing.T) {
+	// Every NaN is a distinct key with the same hash, pages must not split them
+	m := fastmap.NewThreadSafeHashMap[float64, int]()
+	for i := 0; i < 50; i++ {
+		m.Put(math.NaN(), i)
+		m.Put(float64(i), i)
+	}
+
+	nans, numbers := 0, make(map[float64]int)
+	cursor := uint64(0)
+	for {
+		var keys []float64
+		keys, cursor = m.Scan(cursor, 7)
+		for _, key := range keys {
+			if math.IsNaN(key) {
+				nans++
+			} else {
+				numbers[key]++
+			}
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+	if nans != 50 {
+		t.Errorf("scan returned %d NaN keys, want 50", nans)
+	}
+	for i := 0; i < 50; i++ {
+		if numbers[float64(i)] != 1 {
+			t.Errorf("%d returned %d times, want once", i, numbers[float64(i)])
+		}
+	}
+}
+
+func TestThreadSafeHashMapScanPointerKeys(t *testing.T) {
+	m := fastmap.NewThreadSafeHashMap[*int, int]()
+	for i := 0; i < 100; i++ {
+		m.Put(new(int), i)
+	}
+	seen := make(map[*int]bool)
+	cursor := uint64(0)
+	for {
+		var keys []*int
+		keys, cursor = m.Scan(cursor, 7)
+		for _, key := range keys {
+			if seen[key] {
+				t.Fatalf("key %p returned twice", key)
+			}
+			seen[key] = true
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+	if len(seen) != 100 {
+		t.Errorf("scan returned %d keys, want 100", len(seen))
+	}
+}
+
+func TestThreadSafeHashMapScanInterfaceKeys(t *testing.T) {
+	m := fastmap.NewThreadSafeHashMap[any, int]()
+	for i := 0; i < 50; i++ {
+		m.Put(i, i)
+		m.Put(int64(i), i)
+	}
+	keys, cursor := m.Scan(0, 10)
+	if cursor != 0 || len(keys) != 100 {
+		t.Errorf("Scan over interface keys = %d keys, cursor %d, want all 100 keys and cursor 0", len(keys), cursor)
+	}
+}
@@ -0,0 +1,313 @@
+package fastmap
+
+import (
+	"fmt"
+	"iter"
+)
+
+// SmallHashMapInlineCapacity is the number of entries a SmallHashMap stores inline before it
+// switches to a HashMap
+const SmallHashMapInlineCapacity = 8
+
+// SmallHashMap stores up to SmallHashMapInlineCapacity entries in an inline array searched
+// linearly, and switches to a HashMap once it grows past that. Small maps therefore cost no
+// allocation beyond the SmallHashMap itself, and none at all when it is a local variable or a
+// struct field. The zero value is an empty map ready to use.
+// Example:
+//
+//	var headers SmallHashMap[string, string]
+//	headers.Put("Content-Type", "application/json")
+type SmallHashMap[K comparable, V any] struct {
+	inline [SmallHashMapInlineCapacity]smallEntry[K, V]
+	n      int
+	// large holds every entry once the map outgrew the inline array
+	large       *HashMap[K, V]
+	shrinkBelow int
+}
+
+type smallEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewSmallHashMap creates a new SmallHashMap that stays a HashMap once it has grown
+// Example:
+//
+//	tags := NewSmallHashMap[string, string]()
+func NewSmallHashMap[K comparable, V any]() *SmallHashMap[K, V] {
+	return &SmallHashMap[K, V]{}
+}
+
+// NewSmallHashMapWithShrink creates a SmallHashMap that moves back to inline storage when a
+// removal leaves it with fewer than shrinkBelow entries. shrinkBelow is capped at
+// SmallHashMapInlineCapacity, keeping it lower avoids switching back and forth around the limit.
+// Example:
+//
+//	tags := NewSmallHashMapWithShrink[string, string](4)
+func NewSmallHashMapWithShrink[K comparable, V any](shrinkBelow int) *SmallHashMap[K, V] {
+	return &SmallHashMap[K, V]{shrinkBelow: min(shrinkBelow, SmallHashMapInlineCapacity)}
+}
+
+// index returns the inline position of key, or -1
+func (s *SmallHashMap[K, V]) index(key K) int {
+	for i := 0; i < s.n; i++ {
+		if s.inline[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Put adds or updates a key-value pair, switching to a HashMap when the inline array is full
+// Example:
+//
+//	tags.Put("env", "production")
+func (s *SmallHashMap[K, V]) Put(key K, value V) {
+	if s.large != nil {
+		s.large.Put(key, value)
+		return
+	}
+	if i := s.index(key); i >= 0 {
+		s.inline[i].value = value
+		return
+	}
+	if s.n < SmallHashMapInlineCapacity {
+		s.inline[s.n] = smallEntry[K, V]{key: key, value: value}
+		s.n++
+		return
+	}
+
+	s.large = &HashMap[K, V]{data: make(map[K]V, 2*SmallHashMapInlineCapacity)}
+	for i := 0; i < s.n; i++ {
+		s.large.data[s.inline[i].key] = s.inline[i].value
+	}
+	s.large.data[key] = value
+	s.inline = [SmallHashMapInlineCapacity]smallEntry[K, V]{}
+	s.n = 0
+}
+
+// Get retrieves a value by key and returns whether it exists
+// Example:
+//
+//	if env, exists := tags.Get("env"); exists {
+//	    fmt.Printf("Environment: %s\n", env)
+//	}
+func (s *SmallHashMap[K, V]) Get(key K) (V, bool) {
+	if s.large != nil {
+		return s.large.Get(key)
+	}
+	if i := s.index(key); i >= 0 {
+		return s.inline[i].value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Remove deletes a key-value pair and returns whether the key existed
+// Example:
+//
+//	if tags.Remove("env") {
+//	    fmt.Println("Tag removed")
+//	}
+func (s *SmallHashMap[K, V]) Remove(key K) bool {
+	if s.large != nil {
+		if !s.large.Remove(key) {
+			return false
+		}
+		if s.large.Size() < s.shrinkBelow {
+			s.shrink()
+		}
+		return true
+	}
+	i := s.index(key)
+	if i < 0 {
+		return false
+	}
+	s.n--
+	s.inline[i] = s.inline[s.n]
+	s.inline[s.n] = smallEntry[K, V]{}
+	return true
+}
+
+// shrink moves the entries of the HashMap back into the inline array
+func (s *SmallHashMap[K, V]) shrink() {
+	for k, v := range s.large.data {
+		s.inline[s.n] = smallEntry[K, V]{key: k, value: v}
+		s.n++
+	}
+	s.large = nil
+}
+
+// Size returns the number of elements
+// Example:
+//
+//	count := tags.Size()
+func (s *SmallHashMap[K, V]) Size() int {
+	if s.large != nil {
+		return s.large.Size()
+	}
+	return s.n
+}
+
+// IsEmpty returns true if the SmallHashMap has no elements
+// Example:
+//
+//	if tags.IsEmpty() {
+//	    fmt.Println("No tags")
+//	}
+func (s *SmallHashMap[K, V]) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// Contains checks if a key exists
+// Example:
+//
+//	if tags.Contains("env") {
+//	    fmt.Println("Environment is tagged")
+//	}
+func (s *SmallHashMap[K, V]) Contains(key K) bool {
+	_, exists := s.Get(key)
+	return exists
+}
+
+// Clear removes all elements and returns to inline storage
+// Example:
+//
+//	tags.Clear()
+func (s *SmallHashMap[K, V]) Clear() {
+	s.inline = [SmallHashMapInlineCapacity]smallEntry[K, V]{}
+	s.n = 0
+	s.large = nil
+}
+
+// all yields every entry from whichever storage is in use
+func (s *SmallHashMap[K, V]) all() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if s.large != nil {
+			for k, v := range s.large.data {
+				if !yield(k, v) {
+					return
+				}
+			}
+			return
+		}
+		for i := 0; i < s.n; i++ {
+			if !yield(s.inline[i].key, s.inline[i].value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns a slice of all keys
+// Example:
+//
+//	for _, key := range tags.Keys() {
+//	    fmt.Printf("Tag: %v\n", key)
+//	}
+func (s *SmallHashMap[K, V]) Keys() []K {
+	keys := make([]K, 0, s.Size())
+	for k := range s.all() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+// Example:
+//
+//	for _, value := range tags.Values() {
+//	    fmt.Printf("Value: %v\n", value)
+//	}
+func (s *SmallHashMap[K, V]) Values() []V {
+	values := make([]V, 0, s.Size())
+	for _, v := range s.all() {
+		values = append(values, v)
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails
+// Example:
+//
+//	err := tags.ForEach(func(key string, value string) error {
+//	    fmt.Printf("%s=%s\n", key, value)
+//	    return nil
+//	})
+func (s *SmallHashMap[K, V]) ForEach(callback func(K, V) error) error {
+	for k, v := range s.all() {
+		if err := callback(k, v); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// UpdateValue updates the value of an existing key and returns whether the key exists
+// Example:
+//
+//	if !tags.UpdateValue("env", "staging") {
+//	    fmt.Println("env is not tagged")
+//	}
+func (s *SmallHashMap[K, V]) UpdateValue(key K, newValue V) bool {
+	if s.large != nil {
+		return s.large.UpdateValue(key, newValue)
+	}
+	if i := s.index(key); i >= 0 {
+		s.inline[i].value = newValue
+		return true
+	}
+	return false
+}
+
+// PutAll adds all key-value pairs from another SmallHashMap
+// Example:
+//
+//	tags.PutAll(defaultTags)
+func (s *SmallHashMap[K, V]) PutAll(other *SmallHashMap[K, V]) {
+	for k, v := range other.all() {
+		s.Put(k, v)
+	}
+}
+
+// Filter returns a new SmallHashMap containing only the elements that satisfy the predicate
+// Example:
+//
+//	public := tags.Filter(func(key string, value string) bool {
+//	    return !strings.HasPrefix(key, "internal.")
+//	})
+func (s *SmallHashMap[K, V]) Filter(predicate func(K, V) bool) *SmallHashMap[K, V] {
+	result := &SmallHashMap[K, V]{shrinkBelow: s.shrinkBelow}
+	for k, v := range s.all() {
+		if predicate(k, v) {
+			result.Put(k, v)
+		}
+	}
+	return result
+}
+
+// Map transforms values using the provided function and returns a new SmallHashMap
+// Example:
+//
+//	upper := tags.Map(func(key string, value string) string {
+//	    return strings.ToUpper(value)
+//	})
+func (s *SmallHashMap[K, V]) Map(transform func(K, V) V) *SmallHashMap[K, V] {
+	result := &SmallHashMap[K, V]{shrinkBelow: s.shrinkBelow}
+	for k, v := range s.all() {
+		result.Put(k, transform(k, v))
+	}
+	return result
+}
+
+// ToMap returns the contents as a regular map
+// Example:
+//
+//	standardMap := tags.ToMap()
+func (s *SmallHashMap[K, V]) ToMap() map[K]V {
+	result := make(map[K]V, s.Size())
+	for k, v := range s.all() {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"testing"
+
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+var smallMapKeys = func() []string {
+	keys := make([]string, 16)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("X-Header-%d", i)
+	}
+	return keys
+}()
+
+// Maps stored here escape to the heap like maps kept in request structs do
+var (
+	smallMapSink   *fastmap.SmallHashMap[string, int]
+	regularMapSink *fastmap.HashMap[string, int]
+)
+
+func BenchmarkSmallHashMapBuild(b *testing.B) {
+	for _, n := range []int{4, 8, 16} {
+		b.Run(fmt.Sprintf("SmallHashMap/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m := fastmap.NewSmallHashMap[string, int]()
+				for j, key := range smallMapKeys[:n] {
+					m.Put(key, j)
+				}
+				for _, key := range smallMapKeys[:n] {
+					m.Get(key)
+				}
+				smallMapSink = m
+			}
+		})
+
+		b.Run(fmt.Sprintf("HashMap/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m := fastmap.NewHashMap[string, int]()
+				for j, key := range smallMapKeys[:n] {
+					m.Put(key, j)
+				}
+				for _, key := range smallMapKeys[:n] {
+					m.Get(key)
+				}
+				regularMapSink = m
+			}
+		})
+	}
+}
+
+func BenchmarkSmallHashMapGet(b *testing.B) {
+	small := fastmap.NewSmallHashMap[string, int]()
+	regular := fastmap.NewHashMap[string, int]()
+	for i, key := range smallMapKeys[:fastmap.SmallHashMapInlineCapacity] {
+		small.Put(key, i)
+		regular.Put(key, i)
+	}
+
+	b.Run("SmallHashMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			small.Get(smallMapKeys[i%fastmap.SmallHashMapInlineCapacity])
+		}
+	})
+
+	b.Run("HashMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			regular.Get(smallMapKeys[i%fastmap.SmallHashMapInlineCapacity])
+		}
+	})
+}
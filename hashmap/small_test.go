@@ -0,0 +1,111 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"testing"
+
+	fastmap "github.com/billowdev/fastmap/hashmap"
+)
+
+func TestSmallHashMapZeroValue(t *testing.T) {
+	var m fastmap.SmallHashMap[string, int]
+	if !m.IsEmpty() || m.Contains("a") {
+		t.Error("zero value should be an empty map")
+	}
+	m.Put("a", 1)
+	if value, exists := m.Get("a"); !exists || value != 1 {
+		t.Errorf("Get(a) = (%d, %v), want 1", value, exists)
+	}
+}
+
+func TestSmallHashMapUpgrade(t *testing.T) {
+	m := fastmap.NewSmallHashMap[int, int]()
+	for i := 0; i < 100; i++ {
+		m.Put(i, i*10)
+		if m.Size() != i+1 {
+			t.Fatalf("Size = %d after %d puts", m.Size(), i+1)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if value, exists := m.Get(i); !exists || value != i*10 {
+			t.Fatalf("Get(%d) = (%d, %v) after upgrade", i, value, exists)
+		}
+	}
+	for i := 0; i < 99; i++ {
+		m.Remove(i)
+	}
+	if value, _ := m.Get(99); m.Size() != 1 || value != 990 {
+		t.Errorf("expected only 99 to remain, got %v", m.ToMap())
+	}
+}
+
+func TestSmallHashMapShrink(t *testing.T) {
+	m := fastmap.NewSmallHashMapWithShrink[string, int](4)
+	for i := 0; i < 20; i++ {
+		m.Put(fmt.Sprint(i), i)
+	}
+	for i := 0; i < 17; i++ {
+		m.Remove(fmt.Sprint(i))
+	}
+	if m.Size() != 3 {
+		t.Fatalf("Size = %d, want 3", m.Size())
+	}
+	// Back in inline storage, filling it up again must not allocate
+	allocs := testing.AllocsPerRun(10, func() {
+		m.Put("a", 1)
+		m.Put("b", 2)
+		m.Remove("a")
+		m.Remove("b")
+	})
+	if allocs != 0 {
+		t.Errorf("inline operations after shrinking allocated %.0f times", allocs)
+	}
+	for i := 17; i < 20; i++ {
+		if value, exists := m.Get(fmt.Sprint(i)); !exists || value != i {
+			t.Errorf("Get(%d) = (%d, %v) after shrinking", i, value, exists)
+		}
+	}
+}
+
+func TestSmallHashMapInlineDoesNotAllocate(t *testing.T) {
+	keys := make([]string, fastmap.SmallHashMapInlineCapacity)
+	for i := range keys {
+		keys[i] = fmt.Sprint("header", i)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		var m fastmap.SmallHashMap[string, int]
+		for i, key := range keys {
+			m.Put(key, i)
+		}
+		for _, key := range keys {
+			m.Get(key)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("a full inline map allocated %.0f times", allocs)
+	}
+}
+
+func TestSmallHashMapFunctional(t *testing.T) {
+	m := fastmap.NewSmallHashMap[string, int]()
+	for i := 0; i < 12; i++ {
+		m.Put(fmt.Sprint(i), i)
+	}
+	even := m.Filter(func(_ string, v int) bool { return v%2 == 0 })
+	doubled := even.Map(func(_ string, v int) int { return v * 2 })
+	if doubled.Size() != 6 {
+		t.Errorf("Filter then Map kept %d entries, want 6", doubled.Size())
+	}
+	if value, _ := doubled.Get("4"); value != 8 {
+		t.Errorf("doubled 4 = %d, want 8", value)
+	}
+	if !doubled.UpdateValue("4", 0) || doubled.UpdateValue("5", 0) {
+		t.Error("UpdateValue reported the wrong keys")
+	}
+
+	merged := fastmap.NewSmallHashMap[string, int]()
+	merged.PutAll(doubled)
+	if len(merged.ToMap()) != 6 || len(merged.Keys()) != 6 || len(merged.Values()) != 6 {
+		t.Errorf("PutAll produced %v", merged.ToMap())
+	}
+}
@@ -0,0 +1,29 @@
+package fastmap
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSnapshotFinalizerReleasesLeakedSnapshot(t *testing.T) {
+	safeMap := NewThreadSafeHashMap[int, int]()
+	safeMap.Put(1, 1)
+	func() {
+		safeMap.Snapshot().Size()
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		safeMap.mutex.RLock()
+		live := len(safeMap.snapshots)
+		safeMap.mutex.RUnlock()
+		if live == 0 {
+			safeMap.Put(1, 2)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error("an unreachable snapshot is still recording writes")
+}
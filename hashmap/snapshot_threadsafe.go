@@ -0,0 +1,194 @@
+package fastmap
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Snapshot is an immutable point-in-time view of a ThreadSafeHashMap.
+// Creating one is O(1): it shares the live map and the map records the previous value of a key
+// the first time the key is modified while the snapshot is alive. Reads take the source's read lock.
+// Release is required: until it is called every key written in the source keeps its old value
+// in the snapshot, so memory grows with the number of distinct keys written. A snapshot dropped
+// without Release is released by a finalizer, but only once the garbage collector finds it.
+// Example:
+//
+//	snapshot := safeMap.Snapshot()
+//	defer snapshot.Release()
+//	snapshot.ForEach(func(key string, user User) error {
+//	    return report.Write(key, user)
+//	})
+type Snapshot[K comparable, V any] struct {
+	// The source tracks the state, so the Snapshot itself can become unreachable and be finalized
+	*snapshotState[K, V]
+}
+
+type snapshotState[K comparable, V any] struct {
+	source   *ThreadSafeHashMap[K, V]
+	base     map[K]V
+	preimage map[K]snapshotValue[V]
+	size     int
+}
+
+type snapshotValue[V any] struct {
+	value  V
+	exists bool
+}
+
+// Snapshot returns a consistent read-only view of the current contents that is isolated from later writes.
+// Any number of snapshots can be alive at once, each write costs O(1) per live snapshot.
+// Example:
+//
+//	snapshot := safeMap.Snapshot()
+//	defer snapshot.Release()
+//	total := snapshot.Size()
+func (t *ThreadSafeHashMap[K, V]) Snapshot() *Snapshot[K, V] {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	state := &snapshotState[K, V]{
+		source:   t,
+		base:     t.data.data,
+		preimage: make(map[K]snapshotValue[V]),
+		size:     t.data.Size(),
+	}
+	if t.snapshots == nil {
+		t.snapshots = make(map[*snapshotState[K, V]]struct{})
+	}
+	t.snapshots[state] = struct{}{}
+	snapshot := &Snapshot[K, V]{state}
+	runtime.SetFinalizer(snapshot, (*Snapshot[K, V]).Release)
+	return snapshot
+}
+
+// recordPreimage saves the current value of key in every live snapshot that has not seen it change yet.
+// Must be called with the write lock held, before the key is modified.
+func (t *ThreadSafeHashMap[K, V]) recordPreimage(key K) {
+	if len(t.snapshots) == 0 {
+		return
+	}
+	value, exists := t.data.data[key]
+	for snapshot := range t.snapshots {
+		if _, recorded := snapshot.preimage[key]; !recorded {
+			snapshot.preimage[key] = snapshotValue[V]{value: value, exists: exists}
+		}
+	}
+}
+
+// detachSnapshots stops tracking live snapshots before the underlying map is replaced.
+// The replaced map is never written again, so the snapshots keep reading it unchanged.
+// Must be called with the write lock held.
+func (t *ThreadSafeHashMap[K, V]) detachSnapshots() {
+	t.snapshots = nil
+}
+
+// Release drops the snapshot and the values recorded for it. The snapshot is empty afterwards.
+// Example:
+//
+//	snapshot := safeMap.Snapshot()
+//	defer snapshot.Release()
+func (s *Snapshot[K, V]) Release() {
+	runtime.SetFinalizer(s, nil)
+	s.source.mutex.Lock()
+	defer s.source.mutex.Unlock()
+	delete(s.source.snapshots, s.snapshotState)
+	s.base = nil
+	s.preimage = nil
+	s.size = 0
+}
+
+// Get retrieves the value a key had when the snapshot was taken
+// Example:
+//
+//	if user, exists := snapshot.Get("user123"); exists {
+//	    fmt.Printf("Found user: %v\n", user)
+//	}
+func (s *Snapshot[K, V]) Get(key K) (V, bool) {
+	s.source.mutex.RLock()
+	defer s.source.mutex.RUnlock()
+	if recorded, exists := s.preimage[key]; exists {
+		return recorded.value, recorded.exists
+	}
+	value, exists := s.base[key]
+	return value, exists
+}
+
+// Contains checks if a key existed when the snapshot was taken
+func (s *Snapshot[K, V]) Contains(key K) bool {
+	_, exists := s.Get(key)
+	return exists
+}
+
+// Size returns the number of elements when the snapshot was taken
+func (s *Snapshot[K, V]) Size() int {
+	s.source.mutex.RLock()
+	defer s.source.mutex.RUnlock()
+	return s.size
+}
+
+// IsEmpty returns true if the map was empty when the snapshot was taken
+func (s *Snapshot[K, V]) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// Keys returns a slice of all keys in the snapshot
+func (s *Snapshot[K, V]) Keys() []K {
+	keys := make([]K, 0, s.Size())
+	s.ForEach(func(key K, _ V) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys
+}
+
+// Values returns a slice of all values in the snapshot
+func (s *Snapshot[K, V]) Values() []V {
+	values := make([]V, 0, s.Size())
+	s.ForEach(func(_ K, value V) error {
+		values = append(values, value)
+		return nil
+	})
+	return values
+}
+
+// ToMap returns the snapshot contents as a regular map
+func (s *Snapshot[K, V]) ToMap() map[K]V {
+	result := make(map[K]V, s.Size())
+	s.ForEach(func(key K, value V) error {
+		result[key] = value
+		return nil
+	})
+	return result
+}
+
+// ForEach executes a callback function for each key-value pair of the snapshot with read lock on the source
+// Example:
+//
+//	err := snapshot.ForEach(func(key string, value User) error {
+//	    fmt.Printf("User %s: %v\n", key, value)
+//	    return nil
+//	})
+func (s *Snapshot[K, V]) ForEach(callback func(K, V) error) error {
+	s.source.mutex.RLock()
+	defer s.source.mutex.RUnlock()
+	for key, value := range s.base {
+		if recorded, changed := s.preimage[key]; changed {
+			if !recorded.exists {
+				continue
+			}
+			value = recorded.value
+		}
+		if err := callback(key, value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", key, err)
+		}
+	}
+	// Keys removed from the live map since the snapshot was taken
+	for key, recorded := range s.preimage {
+		if _, inBase := s.base[key]; inBase || !recorded.exists {
+			continue
+		}
+		if err := callback(key, recorded.value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package fastmap
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotIsolation(t *testing.T) {
+	m := NewThreadSafeHashMap[string, int]()
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("c", 3)
+
+	snapshot := m.Snapshot()
+	defer snapshot.Release()
+
+	m.Put("a", 100)
+	m.Remove("b")
+	m.Put("d", 4)
+	m.UpdateValue("c", 300)
+
+	want := map[string]int{"a": 1, "b": 2, "c": 3}
+	if got := snapshot.ToMap(); len(got) != len(want) {
+		t.Fatalf("snapshot contents = %v, want %v", got, want)
+	} else {
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("snapshot[%s] = %d, want %d", k, got[k], v)
+			}
+		}
+	}
+	if snapshot.Size() != 3 || len(snapshot.Keys()) != 3 || len(snapshot.Values()) != 3 {
+		t.Errorf("snapshot size mismatch: Size %d, Keys %v", snapshot.Size(), snapshot.Keys())
+	}
+	if _, exists := snapshot.Get("d"); exists {
+		t.Error("key added after snapshot is visible")
+	}
+	if value, exists := snapshot.Get("b"); !exists || value != 2 {
+		t.Errorf("removed key in snapshot = (%d, %v), want (2, true)", value, exists)
+	}
+
+	if value, _ := m.Get("a"); value != 100 {
+		t.Errorf("live map Get(a) = %d, want 100", value)
+	}
+}
+
+func TestSnapshotMultipleVersions(t *testing.T) {
+	m := NewThreadSafeHashMap[string, int]()
+	snapshots := make([]*Snapshot[string, int], 0, 5)
+	for i := 0; i < 5; i++ {
+		m.Put("counter", i)
+		m.Put(fmt.Sprintf("key%d", i), i)
+		snapshots = append(snapshots, m.Snapshot())
+	}
+	m.Put("counter", 99)
+
+	for i, snapshot := range snapshots {
+		if value, _ := snapshot.Get("counter"); value != i {
+			t.Errorf("snapshot %d counter = %d, want %d", i, value, i)
+		}
+		if snapshot.Size() != i+2 {
+			t.Errorf("snapshot %d size = %d, want %d", i, snapshot.Size(), i+2)
+		}
+		snapshot.Release()
+	}
+}
+
+func TestSnapshotClearAndBulkWrites(t *testing.T) {
+	m := NewThreadSafeHashMap[string, int]()
+	m.Put("a", 1)
+	snapshot := m.Snapshot()
+	defer snapshot.Release()
+
+	other := NewThreadSafeHashMap[string, int]()
+	other.Put("a", 10)
+	other.Put("b", 20)
+	m.PutAll(other)
+	m.Clear()
+	m.Put("a", 1000)
+
+	if got := snapshot.ToMap(); len(got) != 1 || got["a"] != 1 {
+		t.Errorf("snapshot after PutAll and Clear = %v, want map[a:1]", got)
+	}
+}
+
+func TestSnapshotAppendable(t *testing.T) {
+	m := NewThreadSafeAppendableHashMap[string, int]()
+	m.AppendValues("list", 1, 2)
+	snapshot := m.Snapshot()
+	defer snapshot.Release()
+
+	m.AppendValues("list", 3)
+	m.AppendValues("other", 4)
+	if values, _ := snapshot.Get("list"); len(values) != 2 {
+		t.Errorf("snapshot list = %v, want [1 2]", values)
+	}
+	if snapshot.Contains("other") {
+		t.Error("appended key visible in snapshot")
+	}
+}
+
+func TestSnapshotRelease(t *testing.T) {
+	m := NewThreadSafeHashMap[string, int]()
+	first := m.Snapshot()
+	second := m.Snapshot()
+	m.Put("a", 1)
+
+	first.Release()
+	if len(m.snapshots) != 1 {
+		t.Errorf("expected 1 live snapshot after release, got %d", len(m.snapshots))
+	}
+	if first.preimage != nil || first.Size() != 0 {
+		t.Error("released snapshot still holds recorded values")
+	}
+
+	second.Release()
+	m.Put("b", 2)
+	if len(m.snapshots) != 0 || second.Contains("a") {
+		t.Error("snapshots still tracked after release")
+	}
+}
+
+func TestSnapshotForEachError(t *testing.T) {
+	m := NewThreadSafeHashMap[string, int]()
+	m.Put("a", 1)
+	snapshot := m.Snapshot()
+	defer snapshot.Release()
+	m.Remove("a")
+
+	err := snapshot.ForEach(func(key string, value int) error {
+		return fmt.Errorf("invalid value %d", value)
+	})
+	if err == nil {
+		t.Error("expected ForEach to return callback error")
+	}
+}
+
+func TestSnapshotConcurrentWriters(t *testing.T) {
+	m := NewThreadSafeHashMap[int, int]()
+	const keys = 100
+	for i := 0; i < keys; i++ {
+		m.Put(i, 0)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				key := (i*7 + w) % keys
+				if i%10 == 0 {
+					m.Remove(key)
+				} else {
+					m.Put(key, i)
+				}
+			}
+		}(w)
+	}
+
+	for i := 0; i < 50; i++ {
+		snapshot := m.Snapshot()
+		first := snapshot.ToMap()
+		second := snapshot.ToMap()
+		if len(first) != snapshot.Size() || len(second) != len(first) {
+			t.Errorf("snapshot changed size: Size %d, first %d, second %d", snapshot.Size(), len(first), len(second))
+		}
+		for k, v := range first {
+			if second[k] != v {
+				t.Errorf("snapshot value for %d changed from %d to %d", k, v, second[k])
+			}
+		}
+		snapshot.Release()
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,259 @@
+// Package hashing provides seeded hash functions for comparable keys.
+//
+// Hashes depend only on the seed and the key, never on process state, so a stored seed
+// reproduces the same hashes in another process. Keys containing pointers or channels are
+// the exception, they hash by address.
+package hashing
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"reflect"
+	"unsafe"
+)
+
+const (
+	prime0 = 0xa0761d6478bd642f
+	prime1 = 0xe7037ed1a0b428db
+	prime2 = 0x8ebc6af09c88c6e3
+	prime3 = 0x589965cc75374cc3
+)
+
+// Func hashes a key with a seed
+type Func[K comparable] func(seed uint64, key K) uint64
+
+// RandomSeed returns a random seed
+func RandomSeed() uint64 {
+	return rand.Uint64()
+}
+
+// Uint64 hashes an integer with a splitmix64 finalizer, it is a bijection for a fixed seed
+func Uint64(seed, value uint64) uint64 {
+	x := value ^ seed ^ prime0
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// String hashes the bytes of a string without copying them
+func String(seed uint64, s string) uint64 {
+	return Bytes(seed, unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// Bytes hashes a byte slice with a wyhash-style multiply-mix function
+func Bytes(seed uint64, p []byte) uint64 {
+	n := len(p)
+	seed ^= prime0
+	var a, b uint64
+	switch {
+	case n == 0:
+	case n < 4:
+		a = uint64(p[0])<<16 | uint64(p[n>>1])<<8 | uint64(p[n-1])
+	case n <= 16:
+		q := (n >> 3) << 2
+		a = read4(p)<<32 | read4(p[q:])
+		b = read4(p[n-4:])<<32 | read4(p[n-4-q:])
+	default:
+		i, rest := 0, n
+		if rest > 48 {
+			s1, s2 := seed, seed
+			for rest > 48 {
+				seed = mix(read8(p[i:])^prime1, read8(p[i+8:])^seed)
+				s1 = mix(read8(p[i+16:])^prime2, read8(p[i+24:])^s1)
+				s2 = mix(read8(p[i+32:])^prime3, read8(p[i+40:])^s2)
+				i += 48
+				rest -= 48
+			}
+			seed ^= s1 ^ s2
+		}
+		for rest > 16 {
+			seed = mix(read8(p[i:])^prime1, read8(p[i+8:])^seed)
+			i += 16
+			rest -= 16
+		}
+		a = read8(p[n-16:])
+		b = read8(p[n-8:])
+	}
+	return mix(prime1^uint64(n), mix(a^prime1, b^seed))
+}
+
+// Combine folds the hash of one part of a composite key into an accumulated hash
+func Combine(h, part uint64) uint64 {
+	return mix(h^prime2, part^prime3)
+}
+
+// For returns a hash function specialized for K. Strings, integers, floats and pointers are
+// hashed directly, structs and arrays made only of integers are hashed as raw memory and any
+// other comparable type is walked with reflection.
+func For[K comparable]() Func[K] {
+	typ := reflect.TypeOf((*K)(nil)).Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		return func(seed uint64, key K) uint64 {
+			return String(seed, *(*string)(unsafe.Pointer(&key)))
+		}
+	case reflect.Float32:
+		return func(seed uint64, key K) uint64 {
+			f := *(*float32)(unsafe.Pointer(&key))
+			if f == 0 {
+				f = 0 // +0 and -0 are equal keys
+			}
+			return Uint64(seed, uint64(math.Float32bits(f)))
+		}
+	case reflect.Float64:
+		return func(seed uint64, key K) uint64 {
+			f := *(*float64)(unsafe.Pointer(&key))
+			if f == 0 {
+				f = 0
+			}
+			return Uint64(seed, math.Float64bits(f))
+		}
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Chan:
+		return func(seed uint64, key K) uint64 {
+			return Uint64(seed, uint64(*(*uintptr)(unsafe.Pointer(&key))))
+		}
+	}
+	if isPlainMemory(typ) {
+		switch typ.Size() {
+		case 1:
+			return func(seed uint64, key K) uint64 {
+				return Uint64(seed, uint64(*(*uint8)(unsafe.Pointer(&key))))
+			}
+		case 2:
+			return func(seed uint64, key K) uint64 {
+				return Uint64(seed, uint64(*(*uint16)(unsafe.Pointer(&key))))
+			}
+		case 4:
+			return func(seed uint64, key K) uint64 {
+				return Uint64(seed, uint64(*(*uint32)(unsafe.Pointer(&key))))
+			}
+		case 8:
+			return func(seed uint64, key K) uint64 {
+				return Uint64(seed, *(*uint64)(unsafe.Pointer(&key)))
+			}
+		}
+		size := int(typ.Size())
+		return func(seed uint64, key K) uint64 {
+			return Bytes(seed, unsafe.Slice((*byte)(unsafe.Pointer(&key)), size))
+		}
+	}
+	return func(seed uint64, key K) uint64 {
+		return Value(seed, reflect.ValueOf(&key).Elem())
+	}
+}
+
+// HasInterface reports whether K is or contains an interface, whose dynamic values For[K]
+// hashes through reflection without regard to their type
+func HasInterface[K comparable]() bool {
+	return hasInterface(reflect.TypeOf((*K)(nil)).Elem())
+}
+
+func hasInterface(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Array:
+		return hasInterface(typ.Elem())
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if hasInterface(typ.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Value hashes any comparable value by walking it with reflection
+func Value(seed uint64, v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return Uint64(seed, 1)
+		}
+		return Uint64(seed, 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Uint64(seed, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Uint64(seed, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f == 0 {
+			f = 0
+		}
+		return Uint64(seed, math.Float64bits(f))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		re, im := real(c), imag(c)
+		if re == 0 {
+			re = 0
+		}
+		if im == 0 {
+			im = 0
+		}
+		return Combine(Uint64(seed, math.Float64bits(re)), Uint64(seed, math.Float64bits(im)))
+	case reflect.String:
+		return String(seed, v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return Uint64(seed, uint64(v.Pointer()))
+	case reflect.Array:
+		h := seed
+		for i := 0; i < v.Len(); i++ {
+			h = Combine(h, Value(seed, v.Index(i)))
+		}
+		return h
+	case reflect.Struct:
+		h := seed
+		for i := 0; i < v.NumField(); i++ {
+			h = Combine(h, Value(seed, v.Field(i)))
+		}
+		return h
+	case reflect.Interface:
+		if v.IsNil() {
+			return Uint64(seed, 0)
+		}
+		return Value(seed, v.Elem())
+	}
+	panic("hashing: unhashable type " + v.Type().String())
+}
+
+// isPlainMemory reports whether equal values of typ always have identical bytes:
+// booleans and integers, and arrays and structs of them without padding
+func isPlainMemory(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	case reflect.Array:
+		return isPlainMemory(typ.Elem())
+	case reflect.Struct:
+		var size uintptr
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.Name == "_" || !isPlainMemory(field.Type) {
+				return false
+			}
+			size += field.Type.Size()
+		}
+		return size == typ.Size()
+	}
+	return false
+}
+
+func mix(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+func read4(p []byte) uint64 {
+	return uint64(binary.LittleEndian.Uint32(p))
+}
+
+func read8(p []byte) uint64 {
+	return binary.LittleEndian.Uint64(p)
+}
@@ -0,0 +1,102 @@
+package hashing
+
+import (
+	"math"
+	"testing"
+)
+
+type plainKey struct {
+	ID   uint32
+	Kind uint32
+}
+
+type paddedKey struct {
+	Flag bool
+	ID   int64
+}
+
+type mixedKey struct {
+	Name string
+	ID   int
+}
+
+func TestForEqualKeysHashEqual(t *testing.T) {
+	const seed = 42
+	if For[string]()(seed, "fastmap") != String(seed, "fastmap") {
+		t.Error("string hash differs from String")
+	}
+	if For[int]()(seed, 7) != Uint64(seed, 7) {
+		t.Error("int hash differs from Uint64")
+	}
+	if For[plainKey]()(seed, plainKey{1, 2}) != For[plainKey]()(seed, plainKey{1, 2}) {
+		t.Error("plain struct hash is not deterministic")
+	}
+	if For[paddedKey]()(seed, paddedKey{true, 3}) != For[paddedKey]()(seed, paddedKey{true, 3}) {
+		t.Error("padded struct hash is not deterministic")
+	}
+	if For[mixedKey]()(seed, mixedKey{"a", 1}) != For[mixedKey]()(seed, mixedKey{"a", 1}) {
+		t.Error("mixed struct hash is not deterministic")
+	}
+	if For[any]()(seed, any("a")) != For[any]()(seed, any("a")) {
+		t.Error("interface hash is not deterministic")
+	}
+	if For[float64]()(seed, math.Copysign(0, -1)) != For[float64]()(seed, 0) {
+		t.Error("-0 and +0 hash differently")
+	}
+}
+
+func TestForDistinguishesKeys(t *testing.T) {
+	const seed = 7
+	seen := make(map[uint64]bool)
+	hash := For[mixedKey]()
+	for i := 0; i < 1000; i++ {
+		h := hash(seed, mixedKey{Name: "key", ID: i})
+		if seen[h] {
+			t.Fatalf("collision at %d", i)
+		}
+		seen[h] = true
+	}
+	if String(1, "fastmap") == String(2, "fastmap") {
+		t.Error("seed does not affect the hash")
+	}
+}
+
+func TestHasInterface(t *testing.T) {
+	type withError struct {
+		ID  int
+		Err error
+	}
+	if HasInterface[string]() || HasInterface[mixedKey]() || HasInterface[*int]() || HasInterface[chan int]() {
+		t.Error("keys without interfaces reported as containing one")
+	}
+	if !HasInterface[any]() || !HasInterface[[2]withError]() {
+		t.Error("keys containing interfaces not reported")
+	}
+}
+
+func TestBytesLengths(t *testing.T) {
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	seen := make(map[uint64]int)
+	for n := 0; n <= len(data); n++ {
+		h := Bytes(0, data[:n])
+		if prev, exists := seen[h]; exists {
+			t.Fatalf("lengths %d and %d collide", prev, n)
+		}
+		seen[h] = n
+	}
+}
+
+func TestForDoesNotAllocate(t *testing.T) {
+	stringHash := For[string]()
+	structHash := For[plainKey]()
+	allocs := testing.AllocsPerRun(100, func() {
+		stringHash(1, "some longer key value")
+		structHash(1, plainKey{1, 2})
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,308 @@
+package fastmap
+
+import (
+	"fmt"
+
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+const (
+	// fibonacci is 2^64 divided by the golden ratio, multiplying by it spreads consecutive and
+	// strided keys over the high bits
+	fibonacci = 0x9E3779B97F4A7C15
+	// mixMultiplier is the first splitmix64 finalizer constant
+	mixMultiplier = 0xBF58476D1CE4E5B9
+)
+
+const (
+	defaultCapacity = 16
+	// maxLoadFactor keeps linear probe sequences short, 2.5 slots for a miss on average
+	maxLoadFactor = 0.5
+)
+
+// Key is the set of integer types IntMap accepts as keys
+type Key interface {
+	~int | ~int64 | ~uint32 | ~uint64
+}
+
+// IntMap is an open-addressing hash map for integer keys. Keys are xored with a random per-map
+// seed and hashed with a fibonacci multiplication followed by a splitmix-style finalizer, which
+// keeps key sets that line up with the multiplier from clustering and keeps keys chosen to
+// collide from being reused against another map. Collisions are resolved by linear probing, with backward
+// shift deletion so no tombstones build up. Slots use the key 0 to mark empty slots, the
+// entry for the key 0 itself is kept outside the table.
+// Like a built-in map it is safe for concurrent readers as long as nobody writes.
+// Example:
+//
+//	users := NewIntMap[int64, User]()
+//	users.Put(42, User{Name: "John"})
+type IntMap[K Key, V any] struct {
+	slots []intSlot[K, V]
+	size  int
+	shift uint
+	seed  uint64
+	// hasZero and zeroValue hold the entry for the key 0
+	hasZero   bool
+	zeroValue V
+}
+
+type intSlot[K Key, V any] struct {
+	key   K
+	value V
+}
+
+// NewIntMap creates a new empty IntMap
+// Example:
+//
+//	users := NewIntMap[int64, User]()
+func NewIntMap[K Key, V any]() *IntMap[K, V] {
+	return NewIntMapWithCapacity[K, V](0)
+}
+
+// NewIntMapWithCapacity creates an IntMap that holds capacity entries without growing
+// Example:
+//
+//	users := NewIntMapWithCapacity[int64, User](100000)
+func NewIntMapWithCapacity[K Key, V any](capacity int) *IntMap[K, V] {
+	size := defaultCapacity
+	for float64(capacity) > float64(size)*maxLoadFactor {
+		size *= 2
+	}
+	m := &IntMap[K, V]{seed: hashing.RandomSeed()}
+	m.allocate(size)
+	return m
+}
+
+func (m *IntMap[K, V]) allocate(size int) {
+	m.slots = make([]intSlot[K, V], size)
+	m.shift = 64
+	for s := size; s > 1; s >>= 1 {
+		m.shift--
+	}
+}
+
+// home returns the slot a key hashes to. The xor-shift folds the high bits of the product into
+// the low ones and the second multiply carries them back up into the bits that pick the slot.
+func (m *IntMap[K, V]) home(key K) int {
+	h := (uint64(key) ^ m.seed) * fibonacci
+	h ^= h >> 32
+	h *= mixMultiplier
+	return int(h >> m.shift)
+}
+
+func (m *IntMap[K, V]) mask() int {
+	return len(m.slots) - 1
+}
+
+// find returns the slot holding key, or the empty slot where it would go
+func (m *IntMap[K, V]) find(key K) (int, bool) {
+	mask := m.mask()
+	for i := m.home(key); ; i = (i + 1) & mask {
+		switch m.slots[i].key {
+		case key:
+			return i, true
+		case 0:
+			return i, false
+		}
+	}
+}
+
+// Put adds or updates a key-value pair
+// Example:
+//
+//	users.Put(42, User{Name: "John"})
+func (m *IntMap[K, V]) Put(key K, value V) {
+	if key == 0 {
+		if !m.hasZero {
+			m.hasZero = true
+			m.size++
+		}
+		m.zeroValue = value
+		return
+	}
+	i, exists := m.find(key)
+	if exists {
+		m.slots[i].value = value
+		return
+	}
+	if float64(m.size+1) > float64(len(m.slots))*maxLoadFactor {
+		m.resize(len(m.slots) * 2)
+		i, _ = m.find(key)
+	}
+	m.slots[i] = intSlot[K, V]{key: key, value: value}
+	m.size++
+}
+
+// Get retrieves a value by key and returns whether it exists
+// Example:
+//
+//	if user, exists := users.Get(42); exists {
+//	    fmt.Printf("Found user: %v\n", user)
+//	}
+func (m *IntMap[K, V]) Get(key K) (V, bool) {
+	if key == 0 {
+		return m.zeroValue, m.hasZero
+	}
+	if i, exists := m.find(key); exists {
+		return m.slots[i].value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Contains checks if a key exists
+// Example:
+//
+//	if users.Contains(42) {
+//	    fmt.Println("User exists")
+//	}
+func (m *IntMap[K, V]) Contains(key K) bool {
+	if key == 0 {
+		return m.hasZero
+	}
+	_, exists := m.find(key)
+	return exists
+}
+
+// Remove deletes a key and returns whether it existed
+// Example:
+//
+//	if users.Remove(42) {
+//	    fmt.Println("User removed")
+//	}
+func (m *IntMap[K, V]) Remove(key K) bool {
+	if key == 0 {
+		if !m.hasZero {
+			return false
+		}
+		var zero V
+		m.hasZero, m.zeroValue = false, zero
+		m.size--
+		return true
+	}
+	i, exists := m.find(key)
+	if !exists {
+		return false
+	}
+	m.size--
+
+	// Backward shift deletion: move later entries of the probe run into the hole unless
+	// their home lies cyclically after the hole
+	mask := m.mask()
+	for j := (i + 1) & mask; m.slots[j].key != 0; j = (j + 1) & mask {
+		home := m.home(m.slots[j].key)
+		if (j-home)&mask >= (j-i)&mask {
+			m.slots[i] = m.slots[j]
+			i = j
+		}
+	}
+	m.slots[i] = intSlot[K, V]{}
+	return true
+}
+
+func (m *IntMap[K, V]) resize(newSize int) {
+	old := m.slots
+	m.allocate(newSize)
+	mask := m.mask()
+	for _, slot := range old {
+		if slot.key == 0 {
+			continue
+		}
+		i := m.home(slot.key)
+		for m.slots[i].key != 0 {
+			i = (i + 1) & mask
+		}
+		m.slots[i] = slot
+	}
+}
+
+// Size returns the number of elements
+// Example:
+//
+//	count := users.Size()
+func (m *IntMap[K, V]) Size() int {
+	return m.size
+}
+
+// IsEmpty returns true if the IntMap has no elements
+// Example:
+//
+//	if users.IsEmpty() {
+//	    fmt.Println("No users")
+//	}
+func (m *IntMap[K, V]) IsEmpty() bool {
+	return m.size == 0
+}
+
+// Clear removes all elements and drops back to the default capacity
+// Example:
+//
+//	users.Clear()
+func (m *IntMap[K, V]) Clear() {
+	var zero V
+	m.allocate(defaultCapacity)
+	m.size = 0
+	m.hasZero, m.zeroValue = false, zero
+}
+
+// Keys returns a slice of all keys
+// Example:
+//
+//	for _, id := range users.Keys() {
+//	    fmt.Printf("User id: %d\n", id)
+//	}
+func (m *IntMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.size)
+	if m.hasZero {
+		keys = append(keys, 0)
+	}
+	for _, slot := range m.slots {
+		if slot.key != 0 {
+			keys = append(keys, slot.key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+// Example:
+//
+//	for _, user := range users.Values() {
+//	    fmt.Printf("User: %v\n", user)
+//	}
+func (m *IntMap[K, V]) Values() []V {
+	values := make([]V, 0, m.size)
+	if m.hasZero {
+		values = append(values, m.zeroValue)
+	}
+	for _, slot := range m.slots {
+		if slot.key != 0 {
+			values = append(values, slot.value)
+		}
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The callback must not modify the map.
+// Example:
+//
+//	err := users.ForEach(func(id int64, user User) error {
+//	    return index.Add(id, user)
+//	})
+func (m *IntMap[K, V]) ForEach(callback func(K, V) error) error {
+	if m.hasZero {
+		if err := callback(0, m.zeroValue); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", 0, err)
+		}
+	}
+	for _, slot := range m.slots {
+		if slot.key == 0 {
+			continue
+		}
+		if err := callback(slot.key, slot.value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", slot.key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package fastmap_test
+
+import (
+	"testing"
+
+	hashmap "github.com/billowdev/fastmap/hashmap"
+	intmap "github.com/billowdev/fastmap/intmap"
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+const benchmarkKeys = 1 << 16
+
+func BenchmarkIntMapPut(b *testing.B) {
+	b.Run("IntMap", func(b *testing.B) {
+		m := intmap.NewIntMap[int64, int64]()
+		for i := 0; i < b.N; i++ {
+			m.Put(int64(i%benchmarkKeys)*7919, int64(i))
+		}
+	})
+
+	b.Run("HashMap", func(b *testing.B) {
+		m := hashmap.NewHashMap[int64, int64]()
+		for i := 0; i < b.N; i++ {
+			m.Put(int64(i%benchmarkKeys)*7919, int64(i))
+		}
+	})
+
+	b.Run("RobinHoodMap", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMap[int64, int64]()
+		for i := 0; i < b.N; i++ {
+			m.Put(int64(i%benchmarkKeys)*7919, int64(i))
+		}
+	})
+
+	b.Run("RobinHoodMapIntegerHasher", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMapWithHasher[int64, int64](robinhood.NewIntegerHasher[int64]())
+		for i := 0; i < b.N; i++ {
+			m.Put(int64(i%benchmarkKeys)*7919, int64(i))
+		}
+	})
+}
+
+func BenchmarkIntMapGet(b *testing.B) {
+	im := intmap.NewIntMap[int64, int64]()
+	hm := hashmap.NewHashMap[int64, int64]()
+	rm := robinhood.NewRobinHoodMap[int64, int64]()
+	rmInt := robinhood.NewRobinHoodMapWithHasher[int64, int64](robinhood.NewIntegerHasher[int64]())
+	for i := int64(0); i < benchmarkKeys; i++ {
+		im.Put(i*7919, i)
+		hm.Put(i*7919, i)
+		rm.Put(i*7919, i)
+		rmInt.Put(i*7919, i)
+	}
+
+	b.Run("IntMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			im.Get(int64(i%(2*benchmarkKeys)) * 7919)
+		}
+	})
+
+	b.Run("HashMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hm.Get(int64(i%(2*benchmarkKeys)) * 7919)
+		}
+	})
+
+	b.Run("RobinHoodMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rm.Get(int64(i%(2*benchmarkKeys)) * 7919)
+		}
+	})
+
+	b.Run("RobinHoodMapIntegerHasher", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rmInt.Get(int64(i%(2*benchmarkKeys)) * 7919)
+		}
+	})
+}
+
+func BenchmarkIntMapRemove(b *testing.B) {
+	b.Run("IntMap", func(b *testing.B) {
+		m := intmap.NewIntMap[int64, int64]()
+		for i := 0; i < b.N; i++ {
+			m.Put(int64(i), int64(i))
+			m.Remove(int64(i - benchmarkKeys))
+		}
+	})
+
+	b.Run("HashMap", func(b *testing.B) {
+		m := hashmap.NewHashMap[int64, int64]()
+		for i := 0; i < b.N; i++ {
+			m.Put(int64(i), int64(i))
+			m.Remove(int64(i - benchmarkKeys))
+		}
+	})
+
+	b.Run("RobinHoodMap", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMap[int64, int64]()
+		for i := 0; i < b.N; i++ {
+			m.Put(int64(i), int64(i))
+			m.Remove(int64(i - benchmarkKeys))
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package fastmap_test
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/billowdev/fastmap"
+	"github.com/billowdev/fastmap/fastmaptest"
+	intmap "github.com/billowdev/fastmap/intmap"
+)
+
+var (
+	_ fastmap.Map[int, string]    = (*intmap.IntMap[int, string])(nil)
+	_ fastmap.Map[int64, string]  = (*intmap.IntMap[int64, string])(nil)
+	_ fastmap.Map[uint32, string] = (*intmap.IntMap[uint32, string])(nil)
+	_ fastmap.Map[uint64, string] = (*intmap.IntMap[uint64, string])(nil)
+)
+
+func TestIntMapBasicOperations(t *testing.T) {
+	m := intmap.NewIntMap[int, string]()
+	m.Put(0, "zero")
+	m.Put(1, "one")
+	m.Put(-1, "minus one")
+	m.Put(1, "uno")
+
+	if m.Size() != 3 {
+		t.Errorf("Size = %d, want 3", m.Size())
+	}
+	for key, want := range map[int]string{0: "zero", 1: "uno", -1: "minus one"} {
+		if value, exists := m.Get(key); !exists || value != want {
+			t.Errorf("Get(%d) = (%q, %v), want %q", key, value, exists, want)
+		}
+	}
+	if !m.Remove(0) || m.Contains(0) || m.Remove(0) {
+		t.Error("removing the zero key failed")
+	}
+	if m.Remove(2) {
+		t.Error("Remove of a missing key returned true")
+	}
+	keys := m.Keys()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []int{-1, 1}) {
+		t.Errorf("Keys = %v, want [-1 1]", keys)
+	}
+	m.Clear()
+	if !m.IsEmpty() || m.Contains(1) {
+		t.Error("Clear left entries behind")
+	}
+}
+
+func TestIntMapMatchesReference(t *testing.T) {
+	// Strided keys share their low bits, sequential keys form long runs
+	generators := map[string]func(*rand.Rand) uint64{
+		"random":     func(rng *rand.Rand) uint64 { return rng.Uint64() % 4096 },
+		"strided":    func(rng *rand.Rand) uint64 { return rng.Uint64() % 2048 << 20 },
+		"sequential": func(rng *rand.Rand) uint64 { return rng.Uint64() % 3000 },
+		"high bits":  func(rng *rand.Rand) uint64 { return rng.Uint64()%64<<58 | rng.Uint64()%4 },
+	}
+	for name, next := range generators {
+		t.Run(name, func(t *testing.T) {
+			m := intmap.NewIntMap[uint64, int]()
+			reference := make(map[uint64]int)
+			rng := rand.New(rand.NewPCG(9, 9))
+			for i := 0; i < 40000; i++ {
+				key := next(rng)
+				if rng.IntN(3) == 0 {
+					_, want := reference[key]
+					if m.Remove(key) != want {
+						t.Fatalf("Remove(%d) disagreed with reference at op %d", key, i)
+					}
+					delete(reference, key)
+				} else {
+					m.Put(key, i)
+					reference[key] = i
+				}
+				if value, _ := m.Get(key); value != reference[key] {
+					t.Fatalf("Get(%d) = %d, want %d at op %d", key, value, reference[key], i)
+				}
+			}
+			if m.Size() != len(reference) {
+				t.Errorf("Size = %d, want %d", m.Size(), len(reference))
+			}
+			visited := 0
+			m.ForEach(func(k uint64, v int) error {
+				visited++
+				if reference[k] != v {
+					t.Errorf("ForEach passed %d=%d, want %d", k, v, reference[k])
+				}
+				return nil
+			})
+			if visited != len(reference) {
+				t.Errorf("ForEach visited %d entries, want %d", visited, len(reference))
+			}
+		})
+	}
+}
+
+func TestIntMapWithCapacity(t *testing.T) {
+	m := intmap.NewIntMapWithCapacity[uint32, int](1000)
+	allocs := testing.AllocsPerRun(1, func() {
+		for i := uint32(0); i < 1000; i++ {
+			m.Put(i, int(i))
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("filling a presized map allocated %.0f times", allocs)
+	}
+}
+
+func TestIntMapSeedsEachMap(t *testing.T) {
+	first := intmap.NewIntMap[int, int]()
+	second := intmap.NewIntMap[int, int]()
+	for i := 1; i <= 64; i++ {
+		first.Put(i, i)
+		second.Put(i, i)
+	}
+	// Slot order follows the hash, two maps laying keys out alike would share their collisions
+	if slices.Equal(first.Keys(), second.Keys()) {
+		t.Error("two maps placed 64 keys in the same order, their hashes are not seeded")
+	}
+}
+
+func TestIntMapForEachError(t *testing.T) {
+	m := intmap.NewIntMap[int, int]()
+	m.Put(0, 0)
+	stop := errors.New("stop")
+	if err := m.ForEach(func(int, int) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ForEach error = %v, want wrapped stop", err)
+	}
+}
+
+// intMapTarget widens the fuzzer's keys, mapping them onto high bits as well so the zero key
+// and strided keys both come up
+type intMapTarget struct {
+	*intmap.IntMap[uint64, int]
+}
+
+func widen(key uint16) uint64 {
+	return uint64(key>>8)<<56 | uint64(key&0xFF)
+}
+
+func (m intMapTarget) Put(key uint16, value int)  { m.IntMap.Put(widen(key), value) }
+func (m intMapTarget) Get(key uint16) (int, bool) { return m.IntMap.Get(widen(key)) }
+func (m intMapTarget) Remove(key uint16) bool     { return m.IntMap.Remove(widen(key)) }
+
+func FuzzIntMap(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fastmaptest.RunOps(t, fastmaptest.DecodeOps(data), map[string]fastmaptest.Target{
+			"intmap":   intMapTarget{intmap.NewIntMap[uint64, int]()},
+			"presized": intMapTarget{intmap.NewIntMapWithCapacity[uint64, int](1024)},
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package fastmap
+
+// ReadOnlyMap is the read side shared by every map in this module, including immutable
+// versions and snapshots
+// Example:
+//
+//	func countActive(users ReadOnlyMap[string, User]) int {
+//	    active := 0
+//	    users.ForEach(func(_ string, user User) error {
+//	        if user.Active {
+//	            active++
+//	        }
+//	        return nil
+//	    })
+//	    return active
+//	}
+type ReadOnlyMap[K comparable, V any] interface {
+	// Get retrieves a value by key and returns whether it exists
+	Get(key K) (V, bool)
+	// Contains checks if a key exists
+	Contains(key K) bool
+	// Size returns the number of elements
+	Size() int
+	// IsEmpty returns true if there are no elements
+	IsEmpty() bool
+	// Keys returns a slice of all keys in no particular order
+	Keys() []K
+	// Values returns a slice of all values in no particular order
+	Values() []V
+	// ForEach calls callback for each key-value pair and wraps the first callback error
+	// as "ForEach operation failed at key <key>: <err>"
+	ForEach(callback func(K, V) error) error
+}
+
+// Map is a mutable map, implemented by HashMap, ThreadSafeHashMap, SmallHashMap, RobinHoodMap,
+// ThreadSafeRobinHoodMap, CompactRobinHoodMap, StringMap, SwissMap, IntMap and CuckooMap
+// Example:
+//
+//	var sessions Map[string, Session] = hashmap.NewThreadSafeHashMap[string, Session]()
+//	sessions.Put("abc", session)
+type Map[K comparable, V any] interface {
+	ReadOnlyMap[K, V]
+	// Put adds or updates a key-value pair
+	Put(key K, value V)
+	// Remove deletes a key and returns whether it existed
+	Remove(key K) bool
+	// Clear removes all elements
+	Clear()
+}
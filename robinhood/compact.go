@@ -0,0 +1,286 @@
+package fastmap
+
+import "fmt"
+
+const (
+	compactDistanceBits = 8
+	compactDistanceMask = 1<<compactDistanceBits - 1
+	// compactSaturated is the stored distance of every slot probed this far or further, their
+	// exact distance is recomputed from the key's hash
+	compactSaturated = compactDistanceMask - 1
+)
+
+// CompactRobinHoodMap is a RobinHoodMap with a struct-of-arrays layout. A dense metadata array
+// holds each slot's probe distance and an 8-bit fingerprint of its hash, keys and values live in
+// separate arrays. Probing scans only the metadata and compares keys on fingerprint matches, so
+// large values are never pulled through the cache while searching. It uses less memory than
+// RobinHoodMap because no padding or hash is stored per slot, at the cost of rehashing keys on resize.
+// Like RobinHoodMap it is safe for concurrent readers as long as nobody writes.
+// Example:
+//
+//	m := NewCompactRobinHoodMap[string, Document]()
+//	m.Put("doc1", doc)
+type CompactRobinHoodMap[K comparable, V any] struct {
+	// meta is 0 for an empty slot, otherwise the probe distance plus one in the low 8 bits,
+	// saturating at compactSaturated, and the hash fingerprint in the high 8 bits
+	meta       []uint16
+	keys       []K
+	values     []V
+	size       int
+	mask       uint64
+	loadFactor float64
+	hasher     Hasher[K]
+}
+
+// NewCompactRobinHoodMap creates a new CompactRobinHoodMap
+// Example:
+//
+//	m := NewCompactRobinHoodMap[string, Document]()
+func NewCompactRobinHoodMap[K comparable, V any]() *CompactRobinHoodMap[K, V] {
+	return NewCompactRobinHoodMapWithHasher[K, V](NewDefaultHasher[K]())
+}
+
+// NewCompactRobinHoodMapWithHasher creates a CompactRobinHoodMap that hashes keys with the given hasher
+// Example:
+//
+//	m := NewCompactRobinHoodMapWithHasher[int64, Document](NewIntegerHasher[int64]())
+func NewCompactRobinHoodMapWithHasher[K comparable, V any](hasher Hasher[K]) *CompactRobinHoodMap[K, V] {
+	m := &CompactRobinHoodMap[K, V]{loadFactor: defaultMaxLoadFactor, hasher: hasher}
+	m.allocate(defaultCapacity)
+	return m
+}
+
+func (m *CompactRobinHoodMap[K, V]) allocate(size int) {
+	m.meta = make([]uint16, size)
+	m.keys = make([]K, size)
+	m.values = make([]V, size)
+	m.mask = uint64(size - 1)
+	m.size = 0
+}
+
+// compactMeta packs a probe distance and fingerprint into a metadata word, long distances saturate
+func compactMeta(distance uint32, fingerprint uint16) uint16 {
+	return fingerprint<<compactDistanceBits | uint16(min(distance, compactSaturated)+1)
+}
+
+// metaDistance returns the stored probe distance, compactSaturated stands for that or more
+func metaDistance(meta uint16) uint32 {
+	return uint32(meta&compactDistanceMask) - 1
+}
+
+func fingerprint(hash uint64) uint16 {
+	return uint16(hash >> 56)
+}
+
+// distanceAt returns the exact probe distance of the occupied slot at index
+func (m *CompactRobinHoodMap[K, V]) distanceAt(index uint64) uint32 {
+	if distance := metaDistance(m.meta[index]); distance < compactSaturated {
+		return distance
+	}
+	return uint32((index - m.hasher.Hash(m.keys[index])) & m.mask)
+}
+
+// Put adds or updates a key-value pair
+// Example:
+//
+//	m.Put("doc1", doc)
+func (m *CompactRobinHoodMap[K, V]) Put(key K, value V) {
+	if float64(m.size+1)/float64(len(m.meta)) > m.loadFactor {
+		m.resize(len(m.meta) * 2)
+	}
+	probe := m.insert(m.hasher.Hash(key), key, value)
+	if probe > maxProbeDistance && m.size*8 >= len(m.meta) {
+		m.resize(len(m.meta) * 2)
+	}
+}
+
+// insert places a key and returns the longest probe distance it had to walk
+func (m *CompactRobinHoodMap[K, V]) insert(hash uint64, key K, value V) uint32 {
+	index := hash & m.mask
+	fp := fingerprint(hash)
+	dist, longest := uint32(0), uint32(0)
+
+	for {
+		current := m.meta[index]
+		if current == 0 {
+			m.meta[index] = compactMeta(dist, fp)
+			m.keys[index] = key
+			m.values[index] = value
+			m.size++
+			return max(longest, dist)
+		}
+		if current>>compactDistanceBits == fp && m.keys[index] == key {
+			m.values[index] = value
+			return max(longest, dist)
+		}
+		if currentDist := m.distanceAt(index); dist > currentDist {
+			m.meta[index] = compactMeta(dist, fp)
+			key, m.keys[index] = m.keys[index], key
+			value, m.values[index] = m.values[index], value
+			longest = max(longest, dist)
+			dist, fp = currentDist, current>>compactDistanceBits
+		}
+		dist++
+		index = (index + 1) & m.mask
+	}
+}
+
+// find returns the slot index holding key, or -1 if the key is absent
+func (m *CompactRobinHoodMap[K, V]) find(key K) int {
+	hash := m.hasher.Hash(key)
+	index := hash & m.mask
+	fp := fingerprint(hash)
+	dist := uint32(0)
+
+	for {
+		current := m.meta[index]
+		if current == 0 {
+			return -1
+		}
+		if stored := metaDistance(current); dist > stored && (stored < compactSaturated || dist > m.distanceAt(index)) {
+			return -1
+		}
+		if current>>compactDistanceBits == fp && m.keys[index] == key {
+			return int(index)
+		}
+		dist++
+		index = (index + 1) & m.mask
+	}
+}
+
+// Get retrieves a value by key and returns whether it exists
+// Example:
+//
+//	if doc, exists := m.Get("doc1"); exists {
+//	    fmt.Printf("Found document: %v\n", doc)
+//	}
+func (m *CompactRobinHoodMap[K, V]) Get(key K) (V, bool) {
+	if index := m.find(key); index >= 0 {
+		return m.values[index], true
+	}
+	var zero V
+	return zero, false
+}
+
+// Contains checks if a key exists
+func (m *CompactRobinHoodMap[K, V]) Contains(key K) bool {
+	return m.find(key) >= 0
+}
+
+// Remove deletes a key and returns whether it existed
+// Example:
+//
+//	if m.Remove("doc1") {
+//	    fmt.Println("removed")
+//	}
+func (m *CompactRobinHoodMap[K, V]) Remove(key K) bool {
+	index := m.find(key)
+	if index < 0 {
+		return false
+	}
+	m.size--
+
+	// Backward shift deletion
+	var zeroKey K
+	var zeroValue V
+	for {
+		next := (index + 1) & int(m.mask)
+		nextMeta := m.meta[next]
+		if nextMeta == 0 || metaDistance(nextMeta) == 0 {
+			m.meta[index] = 0
+			m.keys[index] = zeroKey
+			m.values[index] = zeroValue
+			return true
+		}
+		if metaDistance(nextMeta) < compactSaturated {
+			m.meta[index] = nextMeta - 1
+		} else {
+			m.meta[index] = compactMeta(m.distanceAt(uint64(next))-1, nextMeta>>compactDistanceBits)
+		}
+		m.keys[index] = m.keys[next]
+		m.values[index] = m.values[next]
+		index = next
+	}
+}
+
+func (m *CompactRobinHoodMap[K, V]) resize(newSize int) {
+	oldMeta, oldKeys, oldValues := m.meta, m.keys, m.values
+	m.allocate(newSize)
+	for i, meta := range oldMeta {
+		if meta != 0 {
+			m.insert(m.hasher.Hash(oldKeys[i]), oldKeys[i], oldValues[i])
+		}
+	}
+}
+
+// Size returns the number of elements
+func (m *CompactRobinHoodMap[K, V]) Size() int {
+	return m.size
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *CompactRobinHoodMap[K, V]) IsEmpty() bool {
+	return m.size == 0
+}
+
+// Capacity returns the number of slots in the table
+func (m *CompactRobinHoodMap[K, V]) Capacity() int {
+	return len(m.meta)
+}
+
+// Clear removes all elements and drops back to the initial capacity
+func (m *CompactRobinHoodMap[K, V]) Clear() {
+	m.allocate(defaultCapacity)
+}
+
+// Keys returns a slice of all keys
+func (m *CompactRobinHoodMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.size)
+	for i, meta := range m.meta {
+		if meta != 0 {
+			keys = append(keys, m.keys[i])
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *CompactRobinHoodMap[K, V]) Values() []V {
+	values := make([]V, 0, m.size)
+	for i, meta := range m.meta {
+		if meta != 0 {
+			values = append(values, m.values[i])
+		}
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The callback must not modify the map.
+// Example:
+//
+//	err := m.ForEach(func(key string, doc Document) error {
+//	    return index.Add(key, doc)
+//	})
+func (m *CompactRobinHoodMap[K, V]) ForEach(callback func(K, V) error) error {
+	for i, meta := range m.meta {
+		if meta == 0 {
+			continue
+		}
+		if err := callback(m.keys[i], m.values[i]); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", m.keys[i], err)
+		}
+	}
+	return nil
+}
+
+// ToMap returns the contents as a regular map
+func (m *CompactRobinHoodMap[K, V]) ToMap() map[K]V {
+	result := make(map[K]V, m.size)
+	for i, meta := range m.meta {
+		if meta != 0 {
+			result[m.keys[i]] = m.values[i]
+		}
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package fastmap_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func TestCompactRobinHoodMapBasicOperations(t *testing.T) {
+	m := robinhood.NewCompactRobinHoodMap[string, int]()
+	if !m.IsEmpty() {
+		t.Error("New map should be empty")
+	}
+	m.Put("key1", 100)
+	m.Put("key2", 200)
+	m.Put("key1", 150)
+
+	if value, exists := m.Get("key1"); !exists || value != 150 {
+		t.Errorf("Get(key1) = (%d, %v), want 150", value, exists)
+	}
+	if m.Size() != 2 || !m.Contains("key2") || m.Contains("missing") {
+		t.Errorf("unexpected contents %v", m.ToMap())
+	}
+	if !m.Remove("key2") || m.Remove("key2") || m.Size() != 1 {
+		t.Error("Remove should report whether the key existed")
+	}
+	if len(m.Keys()) != 1 || len(m.Values()) != 1 {
+		t.Error("Keys and Values should return every entry")
+	}
+
+	stop := errors.New("stop")
+	if err := m.ForEach(func(string, int) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ForEach error = %v, want wrapped stop", err)
+	}
+
+	m.Clear()
+	if !m.IsEmpty() || m.Capacity() != 8 || m.Contains("key1") {
+		t.Error("Clear should empty the map and reset its capacity")
+	}
+}
+
+func TestCompactRobinHoodMapMatchesReference(t *testing.T) {
+	m := robinhood.NewCompactRobinHoodMap[int, int]()
+	reference := make(map[int]int)
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	for i := 0; i < 50000; i++ {
+		key := rng.IntN(3000)
+		if rng.IntN(3) == 0 {
+			_, want := reference[key]
+			if m.Remove(key) != want {
+				t.Fatalf("Remove(%d) disagreed with reference at op %d", key, i)
+			}
+			delete(reference, key)
+		} else {
+			m.Put(key, i)
+			reference[key] = i
+		}
+	}
+
+	if m.Size() != len(reference) {
+		t.Errorf("Size = %d, want %d", m.Size(), len(reference))
+	}
+	for k, v := range reference {
+		if value, exists := m.Get(k); !exists || value != v {
+			t.Errorf("Get(%d) = (%d, %v), want %d", k, value, exists, v)
+		}
+	}
+}
+
+func TestCompactRobinHoodMapLongCollisionChains(t *testing.T) {
+	constant := robinhood.HasherFunc[int](func(int) uint64 { return 5 })
+	m := robinhood.NewCompactRobinHoodMapWithHasher[int, string](constant)
+	for i := 0; i < 600; i++ {
+		m.Put(i, fmt.Sprint(i))
+	}
+	for i := 0; i < 600; i += 2 {
+		m.Remove(i)
+	}
+	for i := 0; i < 600; i++ {
+		value, exists := m.Get(i)
+		if exists != (i%2 == 1) || (exists && value != fmt.Sprint(i)) {
+			t.Fatalf("Get(%d) = (%q, %v)", i, value, exists)
+		}
+	}
+}
+
+func TestCompactRobinHoodMapSaturatedDistances(t *testing.T) {
+	// Clusters of 400 keys per home push probe distances far past the 8-bit metadata
+	clustered := robinhood.HasherFunc[int](func(key int) uint64 { return uint64(key/400) * 0x9e3779b97f4a7c15 })
+	m := robinhood.NewCompactRobinHoodMapWithHasher[int, int](clustered)
+	reference := make(map[int]int)
+	rng := rand.New(rand.NewPCG(5, 6))
+
+	for i := 0; i < 20000; i++ {
+		key := rng.IntN(2000)
+		if rng.IntN(3) == 0 {
+			_, want := reference[key]
+			if m.Remove(key) != want {
+				t.Fatalf("Remove(%d) disagreed with reference at op %d", key, i)
+			}
+			delete(reference, key)
+		} else {
+			m.Put(key, i)
+			reference[key] = i
+		}
+	}
+	for key := 0; key < 2400; key++ {
+		want, wantExists := reference[key]
+		if value, exists := m.Get(key); exists != wantExists || value != want {
+			t.Fatalf("Get(%d) = (%d, %v), want (%d, %v)", key, value, exists, want, wantExists)
+		}
+	}
+}
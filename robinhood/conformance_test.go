@@ -0,0 +1,53 @@
+package fastmap_test
+
+import (
+	"testing"
+
+	"github.com/billowdev/fastmap"
+	"github.com/billowdev/fastmap/fastmaptest"
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+var (
+	_ fastmap.Map[string, int] = (*robinhood.RobinHoodMap[string, int])(nil)
+	_ fastmap.Map[string, int] = (*robinhood.ThreadSafeRobinHoodMap[string, int])(nil)
+	_ fastmap.Map[string, int] = (*robinhood.CompactRobinHoodMap[string, int])(nil)
+	_ fastmap.Map[string, int] = (*robinhood.StringMap[int])(nil)
+)
+
+func TestRobinHoodMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return robinhood.NewRobinHoodMap[string, int]()
+	})
+}
+
+func TestRobinHoodMapWithOptionsConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		m, err := robinhood.NewRobinHoodMapWithOptions[string, int](
+			robinhood.WithMinLoadFactor(0.2),
+			robinhood.WithIncrementalResize(4),
+		)
+		if err != nil {
+			t.Fatalf("NewRobinHoodMapWithOptions: %v", err)
+		}
+		return m
+	})
+}
+
+func TestThreadSafeRobinHoodMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return robinhood.NewThreadSafeRobinHoodMap[string, int]()
+	})
+}
+
+func TestCompactRobinHoodMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return robinhood.NewCompactRobinHoodMap[string, int]()
+	})
+}
+
+func TestStringMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return robinhood.NewStringMap[int]()
+	})
+}
@@ -0,0 +1,29 @@
+package fastmap
+
+// ToMap returns the contents as a regular map
+// Example:
+//
+//	standardMap := m.ToMap()
+//	for k, v := range standardMap {
+//	    fmt.Printf("%v: %v\n", k, v)
+//	}
+func (m *RobinHoodMap[K, V]) ToMap() map[K]V {
+	result := make(map[K]V, m.size)
+	for entry := range m.all() {
+		result[entry.key] = entry.value
+	}
+	return result
+}
+
+// FromMap creates a new RobinHoodMap from a regular map
+// Example:
+//
+//	regularMap := map[string]int{"one": 1, "two": 2}
+//	m := FromMap(regularMap)
+func FromMap[K comparable, V any](source map[K]V) *RobinHoodMap[K, V] {
+	m := NewRobinHoodMap[K, V]()
+	for k, v := range source {
+		m.Put(k, v)
+	}
+	return m
+}
@@ -0,0 +1,34 @@
+package fastmap
+
+// Filter returns a new RobinHoodMap with the same options containing only the elements that
+// satisfy the predicate
+// Example:
+//
+//	activeUsers := m.Filter(func(key string, user User) bool {
+//	    return user.Active
+//	})
+func (m *RobinHoodMap[K, V]) Filter(predicate func(K, V) bool) *RobinHoodMap[K, V] {
+	result := m.emptyLike()
+	for entry := range m.all() {
+		if predicate(entry.key, entry.value) {
+			result.putHash(entry.hash, entry.key, entry.value)
+		}
+	}
+	return result
+}
+
+// Map transforms values using the provided function and returns a new RobinHoodMap with the
+// same options
+// Example:
+//
+//	upperNames := m.Map(func(key string, user User) User {
+//	    user.Name = strings.ToUpper(user.Name)
+//	    return user
+//	})
+func (m *RobinHoodMap[K, V]) Map(transform func(K, V) V) *RobinHoodMap[K, V] {
+	result := m.emptyLike()
+	for entry := range m.all() {
+		result.putHash(entry.hash, entry.key, transform(entry.key, entry.value))
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/billowdev/fastmap/fastmaptest"
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+// highByteHasher sends every key with the same high byte to the same home slot so short fuzz
+// inputs already build long probe chains
+var highByteHasher = robinhood.HasherFunc[uint16](func(key uint16) uint64 {
+	return uint64(key >> 8)
+})
+
+func FuzzRobinHoodMap(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		incremental, err := robinhood.NewRobinHoodMapWithOptions[uint16, int](
+			robinhood.WithIncrementalResize(2),
+			robinhood.WithMinLoadFactor(0.2),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fastmaptest.RunOps(t, fastmaptest.DecodeOps(data), map[string]fastmaptest.Target{
+			"default":     robinhood.NewRobinHoodMap[uint16, int](),
+			"colliding":   robinhood.NewRobinHoodMapWithHasher[uint16, int](highByteHasher),
+			"incremental": incremental,
+			"threadsafe":  robinhood.NewThreadSafeRobinHoodMapWithHasher[uint16, int](highByteHasher),
+			"compact":     robinhood.NewCompactRobinHoodMapWithHasher[uint16, int](highByteHasher),
+		})
+	})
+}
+
+// stringMapTarget adapts a StringMap to fastmaptest.Target. Keys become strings of varying
+// length so the arena holds keys of many sizes, their first three bytes encode the high byte.
+type stringMapTarget struct {
+	*robinhood.StringMap[int]
+}
+
+func stringKey(key uint16) string {
+	return fmt.Sprintf("%03d-%d%s", key>>8, key&0xFF, strings.Repeat("x", int(key)%23))
+}
+
+func (m stringMapTarget) Put(key uint16, value int)  { m.StringMap.Put(stringKey(key), value) }
+func (m stringMapTarget) Get(key uint16) (int, bool) { return m.StringMap.Get(stringKey(key)) }
+func (m stringMapTarget) Remove(key uint16) bool     { return m.StringMap.Remove(stringKey(key)) }
+
+// prefixHasher sends every string key with the same high byte to the same home slot
+var prefixHasher = robinhood.HasherFunc[string](func(key string) uint64 {
+	return uint64(key[0])<<16 | uint64(key[1])<<8 | uint64(key[2])
+})
+
+func FuzzStringMap(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fastmaptest.RunOps(t, fastmaptest.DecodeOps(data), map[string]fastmaptest.Target{
+			"default":   stringMapTarget{robinhood.NewStringMap[int]()},
+			"colliding": stringMapTarget{robinhood.NewStringMapWithHasher[int](prefixHasher)},
+		})
+	})
+}
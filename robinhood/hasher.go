@@ -0,0 +1,80 @@
+package fastmap
+
+import "github.com/billowdev/fastmap/internal/hashing"
+
+// Hasher computes hash codes for keys. Equal keys must produce equal hashes and Hash must be
+// safe to call concurrently.
+type Hasher[K comparable] interface {
+	Hash(key K) uint64
+}
+
+// HasherFunc adapts an ordinary function to the Hasher interface
+// Example:
+//
+//	hasher := HasherFunc[UserID](func(id UserID) uint64 {
+//	    return uint64(id.Shard)<<32 | uint64(id.Local)
+//	})
+type HasherFunc[K comparable] func(key K) uint64
+
+// Hash calls f(key)
+func (f HasherFunc[K]) Hash(key K) uint64 {
+	return f(key)
+}
+
+// Integer is the set of integer types supported by NewIntegerHasher
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// NewIntegerHasher returns a randomly seeded hasher for integer keys
+// Example:
+//
+//	m := NewRobinHoodMapWithHasher[int64, string](NewIntegerHasher[int64]())
+func NewIntegerHasher[K Integer]() Hasher[K] {
+	return integerHasher[K]{seed: hashing.RandomSeed()}
+}
+
+// NewStringHasher returns a randomly seeded hasher for string keys, byte slice keys can be
+// stored as string(b)
+// Example:
+//
+//	m := NewRobinHoodMapWithHasher[string, int](NewStringHasher[string]())
+func NewStringHasher[K ~string]() Hasher[K] {
+	return stringHasher[K]{seed: hashing.RandomSeed()}
+}
+
+// NewDefaultHasher returns a randomly seeded hasher for any comparable key. Integers, strings,
+// floats and pointers are hashed directly, byte arrays and other structs and arrays made of
+// integers are hashed as raw memory, and everything else is walked field by field.
+// Example:
+//
+//	m := NewRobinHoodMapWithHasher[[16]byte, Session](NewDefaultHasher[[16]byte]())
+func NewDefaultHasher[K comparable]() Hasher[K] {
+	return defaultHasher[K]{seed: hashing.RandomSeed(), hash: hashing.For[K]()}
+}
+
+type integerHasher[K Integer] struct {
+	seed uint64
+}
+
+func (h integerHasher[K]) Hash(key K) uint64 {
+	return hashing.Uint64(h.seed, uint64(key))
+}
+
+type stringHasher[K ~string] struct {
+	seed uint64
+}
+
+func (h stringHasher[K]) Hash(key K) uint64 {
+	return hashing.String(h.seed, string(key))
+}
+
+type defaultHasher[K comparable] struct {
+	seed uint64
+	hash hashing.Func[K]
+}
+
+func (h defaultHasher[K]) Hash(key K) uint64 {
+	return h.hash(h.seed, key)
+}
@@ -0,0 +1,68 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func TestBuiltinHashers(t *testing.T) {
+	ints := robinhood.NewIntegerHasher[int64]()
+	if ints.Hash(42) != ints.Hash(42) || ints.Hash(42) == ints.Hash(43) {
+		t.Error("integer hasher is not consistent")
+	}
+	strs := robinhood.NewStringHasher[string]()
+	if strs.Hash("key") != strs.Hash(string([]byte("key"))) || strs.Hash("key") == strs.Hash("kez") {
+		t.Error("string hasher is not consistent")
+	}
+
+	type point struct{ X, Y float64 }
+	points := robinhood.NewDefaultHasher[point]()
+	if points.Hash(point{0, 1}) != points.Hash(point{math.Copysign(0, -1), 1}) {
+		t.Error("default hasher should treat +0 and -0 as the same key")
+	}
+	arrays := robinhood.NewDefaultHasher[[16]byte]()
+	if arrays.Hash([16]byte{1}) == arrays.Hash([16]byte{2}) {
+		t.Error("default hasher should distinguish byte arrays")
+	}
+}
+
+func TestRobinHoodMapWithCustomHasher(t *testing.T) {
+	// A constant hash puts every key into one probe sequence
+	m := robinhood.NewRobinHoodMapWithHasher[int, int](robinhood.HasherFunc[int](func(int) uint64 { return 7 }))
+	for i := 0; i < 100; i++ {
+		m.Put(i, i*10)
+	}
+	for i := 0; i < 100; i += 2 {
+		m.Remove(i)
+	}
+	if m.Size() != 50 {
+		t.Errorf("Size should be 50, got %d", m.Size())
+	}
+	for i := 0; i < 100; i++ {
+		value, exists := m.Get(i)
+		if exists != (i%2 == 1) || (exists && value != i*10) {
+			t.Errorf("Get(%d) = (%d, %v)", i, value, exists)
+		}
+	}
+}
+
+func TestRobinHoodMapHashingDoesNotAllocate(t *testing.T) {
+	strs := robinhood.NewRobinHoodMap[string, int]()
+	ints := robinhood.NewRobinHoodMapWithHasher[int, int](robinhood.NewIntegerHasher[int]())
+	for i := 0; i < 1000; i++ {
+		strs.Put(fmt.Sprintf("key%d", i), i)
+		ints.Put(i, i)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		strs.Get("key500")
+		strs.Put("key500", 1)
+		ints.Get(500)
+		ints.Put(500, 1)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,94 @@
+package fastmap
+
+import "iter"
+
+// migration is the old table of an incremental resize. Slots before next have been moved to
+// the new table, the rest are still authoritative. The old table is never reordered, removals
+// only mark entries deleted, so probes through it stay valid until it is dropped.
+type migration[K comparable, V any] struct {
+	entries []entry[K, V]
+	mask    uint64
+	next    int
+}
+
+// find returns the not yet migrated old slot holding key, or nil
+func (g *migration[K, V]) find(hash uint64, key K) *entry[K, V] {
+	index := hash & g.mask
+	dist := uint32(0)
+
+	for {
+		entry := &g.entries[index]
+		if !entry.occupied || dist > entry.distance {
+			return nil
+		}
+		if entry.hash == hash && !entry.deleted && entry.key == key {
+			if int(index) < g.next {
+				return nil
+			}
+			return entry
+		}
+		dist++
+		index = (index + 1) & g.mask
+	}
+}
+
+// remove marks an old slot deleted without disturbing the probe sequences through it
+func (g *migration[K, V]) remove(e *entry[K, V]) {
+	var zero entry[K, V]
+	e.key, e.value = zero.key, zero.value
+	e.deleted = true
+}
+
+// startMigration swaps in an empty table of newSize slots and keeps the current one as the
+// old table of an incremental resize
+func (m *RobinHoodMap[K, V]) startMigration(newSize int) {
+	m.resizes++
+	m.migration = &migration[K, V]{entries: m.entries, mask: m.mask}
+	m.entries = make([]entry[K, V], newSize)
+	m.mask = uint64(newSize - 1)
+}
+
+// migrate moves up to slots old slots into the new table and drops the old table once it is empty
+func (m *RobinHoodMap[K, V]) migrate(slots int) {
+	g := m.migration
+	if g == nil {
+		return
+	}
+	for ; slots > 0 && g.next < len(g.entries); slots-- {
+		e := &g.entries[g.next]
+		g.next++
+		if e.occupied && !e.deleted {
+			// insert counts the entry again
+			m.size--
+			m.insert(e.hash, e.key, e.value)
+		}
+	}
+	if g.next == len(g.entries) {
+		m.migration = nil
+	}
+}
+
+// finishMigration completes a pending incremental resize at once
+func (m *RobinHoodMap[K, V]) finishMigration() {
+	if m.migration != nil {
+		m.migrate(len(m.migration.entries))
+	}
+}
+
+// all yields every live entry, including those still waiting in the old table
+func (m *RobinHoodMap[K, V]) all() iter.Seq[*entry[K, V]] {
+	return func(yield func(*entry[K, V]) bool) {
+		for i := range m.entries {
+			if m.entries[i].occupied && !yield(&m.entries[i]) {
+				return
+			}
+		}
+		if g := m.migration; g != nil {
+			for i := g.next; i < len(g.entries); i++ {
+				if g.entries[i].occupied && !g.entries[i].deleted && !yield(&g.entries[i]) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func TestIncrementalResizeMatchesReference(t *testing.T) {
+	for _, step := range []int{1, 4, 64} {
+		t.Run(fmt.Sprintf("step%d", step), func(t *testing.T) {
+			m, err := robinhood.NewRobinHoodMapWithOptions[int, int](
+				robinhood.WithIncrementalResize(step),
+				robinhood.WithMinLoadFactor(0.1),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rng := rand.New(rand.NewPCG(uint64(step), 7))
+			reference := make(map[int]int)
+			sawMigration := false
+
+			for i := 0; i < 50000; i++ {
+				key := rng.IntN(5000)
+				switch rng.IntN(4) {
+				case 0:
+					if m.Remove(key) != (reference[key] != 0) {
+						t.Fatalf("Remove(%d) disagreed with reference at op %d", key, i)
+					}
+					delete(reference, key)
+				default:
+					m.Put(key, i+1)
+					reference[key] = i + 1
+				}
+				if !sawMigration && m.Stats().MigratingSlots > 0 {
+					sawMigration = true
+				}
+				if value, _ := m.Get(key); value != reference[key] {
+					t.Fatalf("Get(%d) = %d, want %d at op %d", key, value, reference[key], i)
+				}
+			}
+
+			if !sawMigration {
+				t.Error("expected at least one incremental migration")
+			}
+			if m.Size() != len(reference) {
+				t.Errorf("Size = %d, want %d", m.Size(), len(reference))
+			}
+			if got := m.ToMap(); len(got) != len(reference) {
+				t.Errorf("ToMap returned %d entries, want %d", len(got), len(reference))
+			}
+			for k, v := range reference {
+				if value, exists := m.Get(k); !exists || value != v {
+					t.Errorf("Get(%d) = (%d, %v), want %d", k, value, exists, v)
+				}
+			}
+		})
+	}
+}
+
+func TestIncrementalResizeOverwriteWhileGrowing(t *testing.T) {
+	m, err := robinhood.NewRobinHoodMapWithOptions[int, int](robinhood.WithIncrementalResize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Overwriting an existing key can start a new migration, the key must not be duplicated
+	// into the new table
+	for i := 0; i < 2000; i++ {
+		m.Put(i, i)
+		m.Put(i/2, -i)
+		if m.Size() != i+1 {
+			t.Fatalf("Size = %d after %d distinct keys", m.Size(), i+1)
+		}
+	}
+	if keys := m.Keys(); len(keys) != 2000 {
+		t.Errorf("Keys returned %d keys, want 2000", len(keys))
+	}
+}
+
+func TestIncrementalResizeIterationDuringMigration(t *testing.T) {
+	m, err := robinhood.NewRobinHoodMapWithOptions[int, int](robinhood.WithIncrementalResize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 7 keys fill the first 8 slot table past the load factor, growing it starts a migration
+	for i := 0; i < 7; i++ {
+		m.Put(i, i)
+	}
+	if m.Stats().MigratingSlots == 0 {
+		t.Fatal("expected a migration in progress")
+	}
+
+	keys := m.Keys()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []int{0, 1, 2, 3, 4, 5, 6}) {
+		t.Errorf("Keys during migration = %v", keys)
+	}
+	if !m.UpdateValue(0, 100) || m.Filter(func(k, v int) bool { return v >= 100 }).Size() != 1 {
+		t.Error("UpdateValue or Filter missed an entry still in the old table")
+	}
+
+	m.Clear()
+	if m.Size() != 0 || m.Contains(3) || m.Stats().MigratingSlots != 0 {
+		t.Error("Clear should drop the pending migration")
+	}
+}
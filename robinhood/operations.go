@@ -0,0 +1,100 @@
+package fastmap
+
+import "fmt"
+
+// Contains checks if a key exists in the RobinHoodMap
+// Example:
+//
+//	if m.Contains("user123") {
+//	    fmt.Println("User exists")
+//	}
+func (m *RobinHoodMap[K, V]) Contains(key K) bool {
+	_, exists := m.Get(key)
+	return exists
+}
+
+// IsEmpty returns true if the RobinHoodMap has no elements
+// Example:
+//
+//	if m.IsEmpty() {
+//	    fmt.Println("RobinHoodMap is empty")
+//	}
+func (m *RobinHoodMap[K, V]) IsEmpty() bool {
+	return m.size == 0
+}
+
+// Keys returns a slice of all keys in the RobinHoodMap
+// Example:
+//
+//	keys := m.Keys()
+//	for _, key := range keys {
+//	    fmt.Printf("Key: %v\n", key)
+//	}
+func (m *RobinHoodMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.size)
+	for entry := range m.all() {
+		keys = append(keys, entry.key)
+	}
+	return keys
+}
+
+// Values returns a slice of all values in the RobinHoodMap
+// Example:
+//
+//	values := m.Values()
+//	for _, value := range values {
+//	    fmt.Printf("Value: %v\n", value)
+//	}
+func (m *RobinHoodMap[K, V]) Values() []V {
+	values := make([]V, 0, m.size)
+	for entry := range m.all() {
+		values = append(values, entry.value)
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The callback must not modify the map.
+// Example:
+//
+//	err := m.ForEach(func(key string, value User) error {
+//	    if value.IsInvalid() {
+//	        return fmt.Errorf("invalid user data for key %s", key)
+//	    }
+//	    fmt.Printf("User %s: %v\n", key, value)
+//	    return nil
+//	})
+func (m *RobinHoodMap[K, V]) ForEach(callback func(K, V) error) error {
+	for entry := range m.all() {
+		if err := callback(entry.key, entry.value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", entry.key, err)
+		}
+	}
+	return nil
+}
+
+// UpdateValue updates an existing value by key, returns false if key doesn't exist
+// Example:
+//
+//	if m.UpdateValue("user123", updatedUser) {
+//	    fmt.Println("User updated successfully")
+//	}
+func (m *RobinHoodMap[K, V]) UpdateValue(key K, newValue V) bool {
+	if entry := m.find(key); entry != nil {
+		entry.value = newValue
+		return true
+	}
+	return false
+}
+
+// PutAll adds all key-value pairs from another RobinHoodMap
+// Example:
+//
+//	otherMap := NewRobinHoodMap[string, User]()
+//	otherMap.Put("user456", newUser)
+//	m.PutAll(otherMap)
+func (m *RobinHoodMap[K, V]) PutAll(other *RobinHoodMap[K, V]) {
+	for entry := range other.all() {
+		m.Put(entry.key, entry.value)
+	}
+}
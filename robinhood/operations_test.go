@@ -0,0 +1,286 @@
+package fastmap_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func TestContains(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, int]()
+	m.Put("key", 100)
+	tests := []struct {
+		name string
+		key  string
+		want bool
+	}{
+		{"existing key", "key", true},
+		{"non-existing key", "nonexistent", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Contains(tt.key); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsWithNilValues(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, *int]()
+	var nilValue *int
+	m.Put("nilKey", nilValue)
+
+	if !m.Contains("nilKey") {
+		t.Error("Contains should return true for keys with nil values")
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, int]()
+	if !m.IsEmpty() {
+		t.Error("New map should be empty")
+	}
+	m.Put("key", 1)
+	if m.IsEmpty() {
+		t.Error("Map with an element should not be empty")
+	}
+	m.Remove("key")
+	if !m.IsEmpty() {
+		t.Error("IsEmpty failed after removing last element")
+	}
+}
+
+func TestKeysAndValues(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, int]()
+	m.Put("key1", 100)
+	m.Put("key2", 100)
+	m.Put("key3", 300)
+
+	keys := m.Keys()
+	sort.Strings(keys)
+	if fmt.Sprint(keys) != "[key1 key2 key3]" {
+		t.Errorf("Keys() = %v, want [key1 key2 key3]", keys)
+	}
+
+	values := m.Values()
+	sort.Ints(values)
+	if fmt.Sprint(values) != "[100 100 300]" {
+		t.Errorf("Values() = %v, want [100 100 300]", values)
+	}
+}
+
+func TestForEach(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, int]()
+	m.Put("key1", 1)
+	m.Put("key2", 2)
+
+	sum := 0
+	err := m.ForEach(func(k string, v int) error {
+		sum += v
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ForEach failed: %v", err)
+	}
+	if sum != 3 {
+		t.Errorf("ForEach visited values summing to %d, want 3", sum)
+	}
+
+	stop := errors.New("stop")
+	err = m.ForEach(func(k string, v int) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("ForEach should wrap the callback error, got %v", err)
+	}
+}
+
+func TestUpdateValueEdgeCases(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, interface{}]()
+
+	tests := []struct {
+		name    string
+		key     string
+		value   interface{}
+		setup   func()
+		want    bool
+		wantVal interface{}
+	}{
+		{
+			name:    "update nil to value",
+			key:     "key1",
+			value:   100,
+			setup:   func() { m.Put("key1", nil) },
+			want:    true,
+			wantVal: 100,
+		},
+		{
+			name:    "update value to nil",
+			key:     "key2",
+			value:   nil,
+			setup:   func() { m.Put("key2", 200) },
+			want:    true,
+			wantVal: nil,
+		},
+		{
+			name:    "update non-existent key",
+			key:     "nonexistent",
+			value:   300,
+			setup:   func() {},
+			want:    false,
+			wantVal: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.Clear()
+			tt.setup()
+
+			got := m.UpdateValue(tt.key, tt.value)
+			if got != tt.want {
+				t.Errorf("UpdateValue() = %v, want %v", got, tt.want)
+			}
+
+			if val, _ := m.Get(tt.key); val != tt.wantVal && tt.want {
+				t.Errorf("After update, value = %v, want %v", val, tt.wantVal)
+			}
+			if !tt.want && m.Contains(tt.key) {
+				t.Error("UpdateValue should not insert missing keys")
+			}
+		})
+	}
+}
+
+func TestPutAllWithEmptyMaps(t *testing.T) {
+	m1 := robinhood.NewRobinHoodMap[string, int]()
+	m2 := robinhood.NewRobinHoodMap[string, int]()
+
+	m1.PutAll(m2)
+	if !m1.IsEmpty() {
+		t.Error("PutAll with empty source should maintain empty destination")
+	}
+
+	m2.Put("key", 100)
+	m1.PutAll(m2)
+	if m1.Size() != 1 {
+		t.Error("PutAll failed to copy from non-empty to empty map")
+	}
+}
+
+func TestFilterAndMap(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[int, int]()
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+
+	even := m.Filter(func(k, v int) bool { return v%2 == 0 })
+	if even.Size() != 50 || !even.Contains(10) || even.Contains(11) {
+		t.Errorf("Filter returned %d elements", even.Size())
+	}
+
+	doubled := m.Map(func(k, v int) int { return v * 2 })
+	if value, _ := doubled.Get(21); value != 42 || doubled.Size() != 100 {
+		t.Errorf("Map produced Get(21) = %d, size %d", value, doubled.Size())
+	}
+	if value, _ := m.Get(21); value != 21 {
+		t.Error("Map should not modify the source")
+	}
+}
+
+func TestToMapAndFromMap(t *testing.T) {
+	regular := map[string]int{"one": 1, "two": 2, "three": 3}
+	m := robinhood.FromMap(regular)
+	if m.Size() != 3 {
+		t.Errorf("FromMap size = %d, want 3", m.Size())
+	}
+
+	back := m.ToMap()
+	if len(back) != len(regular) {
+		t.Errorf("ToMap returned %d elements, want %d", len(back), len(regular))
+	}
+	for k, v := range regular {
+		if back[k] != v {
+			t.Errorf("ToMap()[%s] = %d, want %d", k, back[k], v)
+		}
+	}
+}
+
+func TestLargeDataSetOperations(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, int]()
+	numItems := 10000
+
+	for i := 0; i < numItems; i++ {
+		m.Put(fmt.Sprintf("key%d", i), i)
+	}
+	if m.Size() != numItems {
+		t.Errorf("Expected size %d for large dataset, got %d", numItems, m.Size())
+	}
+
+	count := 0
+	err := m.ForEach(func(k string, v int) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ForEach failed: %v", err)
+	}
+	if count != numItems {
+		t.Errorf("ForEach processed %d items, expected %d", count, numItems)
+	}
+
+	keys := m.Keys()
+	values := m.Values()
+	if len(keys) != numItems || len(values) != numItems {
+		t.Errorf("Keys/Values length mismatch: keys=%d, values=%d, expected=%d",
+			len(keys), len(values), numItems)
+	}
+}
+
+func TestEdgeCaseEmptyMaps(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, struct{}]()
+	m.Put("key", struct{}{})
+	m.Remove("key")
+
+	err := m.ForEach(func(k string, v struct{}) error {
+		t.Error("ForEach should not execute on empty map")
+		return nil
+	})
+	if err != nil {
+		t.Error("ForEach on empty map should not return error")
+	}
+	if m.Filter(func(k string, v struct{}) bool { return true }).Size() != 0 {
+		t.Error("Filter on empty map should return empty map")
+	}
+}
+
+func TestEdgeCaseKeyTypes(t *testing.T) {
+	type complexKey struct {
+		f float64
+		s string
+	}
+
+	m := robinhood.NewRobinHoodMap[complexKey, int]()
+
+	// Test only Inf values since NaN != NaN in Go
+	k1 := complexKey{f: math.Inf(1), s: "inf"}
+	k2 := complexKey{f: math.Inf(-1), s: "neginf"}
+	k3 := complexKey{f: 0.0, s: "zero"}
+
+	m.Put(k1, 1)
+	m.Put(k2, 2)
+	m.Put(k3, 3)
+
+	if m.Size() != 3 {
+		t.Errorf("Expected size 3, got %d", m.Size())
+	}
+	if value, exists := m.Get(complexKey{f: math.Copysign(0, -1), s: "zero"}); !exists || value != 3 {
+		t.Error("-0 and +0 keys should be equal")
+	}
+}
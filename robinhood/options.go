@@ -0,0 +1,134 @@
+package fastmap
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	defaultCapacity      = 8
+	defaultMaxLoadFactor = 0.75
+)
+
+// ErrInvalidOption is returned by NewRobinHoodMapWithOptions when an option value is out of range
+var ErrInvalidOption = errors.New("invalid robinhood map option")
+
+// Option configures a RobinHoodMap created by NewRobinHoodMapWithOptions
+type Option func(*options) error
+
+type options struct {
+	initialCapacity       int
+	maxLoadFactor         float64
+	minLoadFactor         float64
+	keepCapacityOnClear   bool
+	incrementalResizeStep int
+}
+
+// WithInitialCapacity sizes the table to hold capacity elements without growing. The table
+// never shrinks below this size.
+// Example:
+//
+//	m, err := NewRobinHoodMapWithOptions[string, int](WithInitialCapacity(1_000_000))
+func WithInitialCapacity(capacity int) Option {
+	return func(o *options) error {
+		if capacity < 0 {
+			return fmt.Errorf("%w: initial capacity must not be negative, got %d", ErrInvalidOption, capacity)
+		}
+		o.initialCapacity = capacity
+		return nil
+	}
+}
+
+// WithMaxLoadFactor sets the fraction of slots that may be filled before the table doubles.
+// Higher values save memory at the cost of longer probes. Defaults to 0.75.
+// Example:
+//
+//	m, err := NewRobinHoodMapWithOptions[string, int](WithMaxLoadFactor(0.9))
+func WithMaxLoadFactor(loadFactor float64) Option {
+	return func(o *options) error {
+		if !(loadFactor > 0 && loadFactor < 1) {
+			return fmt.Errorf("%w: max load factor must be in (0, 1), got %v", ErrInvalidOption, loadFactor)
+		}
+		o.maxLoadFactor = loadFactor
+		return nil
+	}
+}
+
+// WithMinLoadFactor makes Remove halve the table once the fraction of filled slots drops below
+// loadFactor. It must be less than half the max load factor so a shrink never triggers a grow.
+// Defaults to 0, which never shrinks.
+// Example:
+//
+//	m, err := NewRobinHoodMapWithOptions[string, int](WithMinLoadFactor(0.2))
+func WithMinLoadFactor(loadFactor float64) Option {
+	return func(o *options) error {
+		if !(loadFactor >= 0 && loadFactor < 1) {
+			return fmt.Errorf("%w: min load factor must be in [0, 1), got %v", ErrInvalidOption, loadFactor)
+		}
+		o.minLoadFactor = loadFactor
+		return nil
+	}
+}
+
+// WithClearKeepsCapacity makes Clear empty the table in place instead of dropping back to the
+// initial capacity, which avoids regrowing when the map is about to be refilled
+// Example:
+//
+//	m, err := NewRobinHoodMapWithOptions[string, int](WithClearKeepsCapacity(true))
+func WithClearKeepsCapacity(keep bool) Option {
+	return func(o *options) error {
+		o.keepCapacityOnClear = keep
+		return nil
+	}
+}
+
+// WithIncrementalResize spreads growing over later writes instead of rehashing the whole table in
+// the Put that crosses the load factor. Each Put and Remove then moves up to slotsPerOperation
+// slots of the old table, and lookups consult both tables until the move is done. Reads never
+// migrate, so concurrent readers stay safe. Defaults to 0, which resizes at once.
+// Example:
+//
+//	m, err := NewRobinHoodMapWithOptions[string, Session](WithIncrementalResize(64))
+func WithIncrementalResize(slotsPerOperation int) Option {
+	return func(o *options) error {
+		if slotsPerOperation < 0 {
+			return fmt.Errorf("%w: incremental resize step must not be negative, got %d", ErrInvalidOption, slotsPerOperation)
+		}
+		o.incrementalResizeStep = slotsPerOperation
+		return nil
+	}
+}
+
+// NewRobinHoodMapWithOptions creates a RobinHoodMap configured by the given options
+// Example:
+//
+//	m, err := NewRobinHoodMapWithOptions[string, User](
+//	    WithInitialCapacity(10_000),
+//	    WithMaxLoadFactor(0.9),
+//	    WithMinLoadFactor(0.25),
+//	)
+//	if err != nil {
+//	    return err
+//	}
+func NewRobinHoodMapWithOptions[K comparable, V any](opts ...Option) (*RobinHoodMap[K, V], error) {
+	o := options{maxLoadFactor: defaultMaxLoadFactor}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	if o.minLoadFactor > 0 && o.minLoadFactor*2 >= o.maxLoadFactor {
+		return nil, fmt.Errorf("%w: min load factor %v must be less than half the max load factor %v",
+			ErrInvalidOption, o.minLoadFactor, o.maxLoadFactor)
+	}
+	return newRobinHoodMap[K, V](NewDefaultHasher[K](), o), nil
+}
+
+// tableSize returns the smallest power of two that holds capacity elements under loadFactor
+func tableSize(capacity int, loadFactor float64) int {
+	size := defaultCapacity
+	for float64(capacity) > float64(size)*loadFactor {
+		size *= 2
+	}
+	return size
+}
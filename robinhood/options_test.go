@@ -0,0 +1,162 @@
+package fastmap_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func TestRobinHoodMapOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []robinhood.Option
+	}{
+		{"negative capacity", []robinhood.Option{robinhood.WithInitialCapacity(-1)}},
+		{"zero max load factor", []robinhood.Option{robinhood.WithMaxLoadFactor(0)}},
+		{"full max load factor", []robinhood.Option{robinhood.WithMaxLoadFactor(1)}},
+		{"negative min load factor", []robinhood.Option{robinhood.WithMinLoadFactor(-0.1)}},
+		{"min too close to max", []robinhood.Option{robinhood.WithMaxLoadFactor(0.5), robinhood.WithMinLoadFactor(0.3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := robinhood.NewRobinHoodMapWithOptions[string, int](tt.opts...)
+			if !errors.Is(err, robinhood.ErrInvalidOption) || m != nil {
+				t.Errorf("expected ErrInvalidOption, got (%v, %v)", m, err)
+			}
+		})
+	}
+}
+
+func TestRobinHoodMapInitialCapacity(t *testing.T) {
+	m, err := robinhood.NewRobinHoodMapWithOptions[int, int](robinhood.WithInitialCapacity(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	capacity := m.Capacity()
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	if m.Capacity() != capacity {
+		t.Errorf("table grew from %d to %d while filling to the initial capacity", capacity, m.Capacity())
+	}
+}
+
+func TestRobinHoodMapMaxLoadFactor(t *testing.T) {
+	m, err := robinhood.NewRobinHoodMapWithOptions[int, int](robinhood.WithMaxLoadFactor(0.9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 900; i++ {
+		m.Put(i, i)
+	}
+	if m.Capacity() != 1024 {
+		t.Errorf("expected 900 keys to fit in 1024 slots at load factor 0.9, got %d", m.Capacity())
+	}
+	for i := 0; i < 900; i++ {
+		if value, exists := m.Get(i); !exists || value != i {
+			t.Fatalf("Get(%d) = (%d, %v)", i, value, exists)
+		}
+	}
+}
+
+func TestRobinHoodMapShrink(t *testing.T) {
+	m, err := robinhood.NewRobinHoodMapWithOptions[string, int](
+		robinhood.WithInitialCapacity(16),
+		robinhood.WithMinLoadFactor(0.2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial := m.Capacity()
+	for i := 0; i < 10000; i++ {
+		m.Put(fmt.Sprintf("key%d", i), i)
+	}
+	grown := m.Capacity()
+	for i := 0; i < 9990; i++ {
+		m.Remove(fmt.Sprintf("key%d", i))
+	}
+	if m.Capacity() >= grown/8 {
+		t.Errorf("expected table to shrink from %d slots, got %d", grown, m.Capacity())
+	}
+	for i := 9990; i < 10000; i++ {
+		if value, exists := m.Get(fmt.Sprintf("key%d", i)); !exists || value != i {
+			t.Errorf("Get(key%d) = (%d, %v) after shrinking", i, value, exists)
+		}
+	}
+	for i := 9990; i < 10000; i++ {
+		m.Remove(fmt.Sprintf("key%d", i))
+	}
+	if m.Capacity() != initial {
+		t.Errorf("table should not shrink below the initial capacity %d, got %d", initial, m.Capacity())
+	}
+}
+
+func TestRobinHoodMapNoShrinkByDefault(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[int, int]()
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	grown := m.Capacity()
+	for i := 0; i < 1000; i++ {
+		m.Remove(i)
+	}
+	if m.Capacity() != grown {
+		t.Errorf("default map should not shrink, went from %d to %d slots", grown, m.Capacity())
+	}
+}
+
+func TestRobinHoodMapClearCapacity(t *testing.T) {
+	keep, err := robinhood.NewRobinHoodMapWithOptions[int, int](robinhood.WithClearKeepsCapacity(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	drop := robinhood.NewRobinHoodMap[int, int]()
+	for i := 0; i < 1000; i++ {
+		keep.Put(i, i)
+		drop.Put(i, i)
+	}
+	grown := keep.Capacity()
+	keep.Clear()
+	drop.Clear()
+
+	if keep.Capacity() != grown || !keep.IsEmpty() || keep.Contains(1) {
+		t.Errorf("Clear with kept capacity: capacity %d, size %d", keep.Capacity(), keep.Size())
+	}
+	if drop.Capacity() != 8 || !drop.IsEmpty() {
+		t.Errorf("default Clear should drop back to 8 slots, got %d", drop.Capacity())
+	}
+	keep.Put(5, 5)
+	if value, _ := keep.Get(5); value != 5 || keep.Size() != 1 {
+		t.Error("map with kept capacity is unusable after Clear")
+	}
+}
+
+func TestRobinHoodMapFilterAndMapKeepOptions(t *testing.T) {
+	m, err := robinhood.NewRobinHoodMapWithOptions[int, int](
+		robinhood.WithMaxLoadFactor(0.9),
+		robinhood.WithClearKeepsCapacity(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 900; i++ {
+		m.Put(i, i)
+	}
+
+	results := map[string]*robinhood.RobinHoodMap[int, int]{
+		"Filter": m.Filter(func(int, int) bool { return true }),
+		"Map":    m.Map(func(_, v int) int { return v * 2 }),
+	}
+	for name, result := range results {
+		if result.Capacity() != 1024 {
+			t.Errorf("%s: 900 keys at load factor 0.9 should fit in 1024 slots, got %d", name, result.Capacity())
+		}
+		result.Clear()
+		if result.Capacity() != 1024 {
+			t.Errorf("%s: Clear should keep the capacity, got %d slots", name, result.Capacity())
+		}
+	}
+}
@@ -1,72 +1,135 @@
 package fastmap
 
-import (
-	"fmt"
-	"hash/maphash"
-)
+// maxProbeDistance is the probe length past which Put grows the table early. Longer probes stay
+// correct since distances are 32-bit, they only mean the hash spreads keys badly.
+const maxProbeDistance = 64
 
+// RobinHoodMap is an open-addressing hash map using Robin Hood probing.
+// Like a built-in map it is safe for any number of concurrent readers as long as nobody
+// writes: hashing is stateless and only reads the hasher's seed. Use ThreadSafeRobinHoodMap
+// when reads and writes are mixed.
 type RobinHoodMap[K comparable, V any] struct {
-	entries    []entry[K, V]
-	size       int
-	mask       uint64
-	loadFactor float64
-	hasher     maphash.Hash
+	entries       []entry[K, V]
+	size          int
+	mask          uint64
+	maxLoadFactor float64
+	minLoadFactor float64
+	minTableSize  int
+	keepOnClear   bool
+	resizes       int
+	hasher        Hasher[K]
+	migration     *migration[K, V]
+	migrateStep   int
 }
 
 type entry[K comparable, V any] struct {
+	hash     uint64
 	key      K
 	value    V
-	distance uint8
+	distance uint32
 	occupied bool
+	// deleted marks entries removed from the old table during an incremental resize,
+	// they stay occupied so probe sequences through them remain intact
+	deleted bool
 }
 
 func NewRobinHoodMap[K comparable, V any]() *RobinHoodMap[K, V] {
-	initialSize := 8
+	return NewRobinHoodMapWithHasher[K, V](NewDefaultHasher[K]())
+}
+
+// NewRobinHoodMapWithHasher creates a RobinHoodMap that hashes keys with the given hasher
+// Example:
+//
+//	m := NewRobinHoodMapWithHasher[string, int](NewStringHasher[string]())
+func NewRobinHoodMapWithHasher[K comparable, V any](hasher Hasher[K]) *RobinHoodMap[K, V] {
+	return newRobinHoodMap[K, V](hasher, options{maxLoadFactor: defaultMaxLoadFactor})
+}
+
+func newRobinHoodMap[K comparable, V any](hasher Hasher[K], o options) *RobinHoodMap[K, V] {
+	size := tableSize(o.initialCapacity, o.maxLoadFactor)
 	return &RobinHoodMap[K, V]{
-		entries:    make([]entry[K, V], initialSize),
-		mask:       uint64(initialSize - 1),
-		loadFactor: 0.75,
-		hasher:     maphash.Hash{},
+		entries:       make([]entry[K, V], size),
+		mask:          uint64(size - 1),
+		maxLoadFactor: o.maxLoadFactor,
+		minLoadFactor: o.minLoadFactor,
+		minTableSize:  size,
+		keepOnClear:   o.keepCapacityOnClear,
+		migrateStep:   o.incrementalResizeStep,
+		hasher:        hasher,
 	}
 }
 
-func (m *RobinHoodMap[K, V]) hash(key K) uint64 {
-	m.hasher.Write([]byte(fmt.Sprintf("%v", key)))
-	defer m.hasher.Reset()
-	return m.hasher.Sum64()
+// emptyLike returns an empty map with the hasher, options and initial capacity of m
+func (m *RobinHoodMap[K, V]) emptyLike() *RobinHoodMap[K, V] {
+	return &RobinHoodMap[K, V]{
+		entries:       make([]entry[K, V], m.minTableSize),
+		mask:          uint64(m.minTableSize - 1),
+		maxLoadFactor: m.maxLoadFactor,
+		minLoadFactor: m.minLoadFactor,
+		minTableSize:  m.minTableSize,
+		keepOnClear:   m.keepOnClear,
+		migrateStep:   m.migrateStep,
+		hasher:        m.hasher,
+	}
 }
 
 func (m *RobinHoodMap[K, V]) Put(key K, value V) {
-	if float64(m.size+1)/float64(len(m.entries)) > m.loadFactor {
-		m.resize()
+	m.putHash(m.hasher.Hash(key), key, value)
+}
+
+// putHash is Put for a key whose hash is already known
+func (m *RobinHoodMap[K, V]) putHash(hash uint64, key K, value V) {
+	m.migrate(m.migrateStep)
+	if float64(m.size+1)/float64(len(m.entries)) > m.maxLoadFactor {
+		m.grow(len(m.entries) * 2)
 	}
+	// Growing may turn the current table into the old one, so look for a stale copy after it
+	if m.migration != nil {
+		// The new table is authoritative, drop a copy still waiting in the old one
+		if old := m.migration.find(hash, key); old != nil {
+			m.migration.remove(old)
+			m.size--
+		}
+	}
+	probe := m.insert(hash, key, value)
+	// Growing spreads clustered keys apart, but not when the hashes themselves collide, so
+	// only grow tables that are not already sparse.
+	if probe > maxProbeDistance && m.size*8 >= len(m.entries) && m.migration == nil {
+		m.grow(len(m.entries) * 2)
+	}
+}
 
-	hash := m.hash(key)
+// insert places a key whose hash is already known, resize uses it to avoid rehashing.
+// It returns the longest probe distance it had to walk.
+func (m *RobinHoodMap[K, V]) insert(hash uint64, key K, value V) uint32 {
 	index := hash & m.mask
-	dist := uint8(0)
+	dist, longest := uint32(0), uint32(0)
 
 	for {
 		entry := &m.entries[index]
 
 		if !entry.occupied {
+			entry.hash = hash
 			entry.key = key
 			entry.value = value
 			entry.distance = dist
 			entry.occupied = true
 			m.size++
-			return
+			return max(longest, dist)
 		}
 
-		if entry.key == key {
+		if entry.hash == hash && entry.key == key {
 			entry.value = value
-			return
+			return max(longest, dist)
 		}
 
 		// Robin Hood: rich (current entry) vs poor (new entry)
 		if dist > entry.distance {
 			// Swap entries
+			hash, entry.hash = entry.hash, hash
 			key, entry.key = entry.key, key
 			value, entry.value = entry.value, value
+			longest = max(longest, dist)
 			dist, entry.distance = entry.distance, dist
 		}
 
@@ -76,18 +139,37 @@ func (m *RobinHoodMap[K, V]) Put(key K, value V) {
 }
 
 func (m *RobinHoodMap[K, V]) Get(key K) (V, bool) {
-	hash := m.hash(key)
+	if entry := m.find(key); entry != nil {
+		return entry.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// find returns the slot holding key, or nil if the key is absent
+func (m *RobinHoodMap[K, V]) find(key K) *entry[K, V] {
+	hash := m.hasher.Hash(key)
+	if entry := m.findHash(hash, key); entry != nil {
+		return entry
+	}
+	if m.migration != nil {
+		return m.migration.find(hash, key)
+	}
+	return nil
+}
+
+// findHash looks a key up in the current table only
+func (m *RobinHoodMap[K, V]) findHash(hash uint64, key K) *entry[K, V] {
 	index := hash & m.mask
-	dist := uint8(0)
+	dist := uint32(0)
 
 	for {
 		entry := &m.entries[index]
 		if !entry.occupied || dist > entry.distance {
-			var zero V
-			return zero, false
+			return nil
 		}
-		if entry.key == key {
-			return entry.value, true
+		if entry.hash == hash && entry.key == key {
+			return entry
 		}
 		dist++
 		index = (index + 1) & m.mask
@@ -95,16 +177,24 @@ func (m *RobinHoodMap[K, V]) Get(key K) (V, bool) {
 }
 
 func (m *RobinHoodMap[K, V]) Remove(key K) bool {
-	hash := m.hash(key)
+	hash := m.hasher.Hash(key)
+	m.migrate(m.migrateStep)
+	if m.migration != nil {
+		if old := m.migration.find(hash, key); old != nil {
+			m.migration.remove(old)
+			m.size--
+			return true
+		}
+	}
 	index := hash & m.mask
-	dist := uint8(0)
+	dist := uint32(0)
 
 	for {
 		entry := &m.entries[index]
 		if !entry.occupied || dist > entry.distance {
 			return false
 		}
-		if entry.key == key {
+		if entry.hash == hash && entry.key == key {
 			// Found the entry to remove
 			m.size--
 
@@ -114,6 +204,7 @@ func (m *RobinHoodMap[K, V]) Remove(key K) bool {
 				nextEntry := &m.entries[nextIndex]
 				if !nextEntry.occupied || nextEntry.distance == 0 {
 					entry.occupied = false
+					m.maybeShrink()
 					return true
 				}
 				*entry = *nextEntry
@@ -128,16 +219,43 @@ func (m *RobinHoodMap[K, V]) Remove(key K) bool {
 	}
 }
 
-func (m *RobinHoodMap[K, V]) resize() {
+// maybeShrink halves the table while it is filled below the min load factor
+func (m *RobinHoodMap[K, V]) maybeShrink() {
+	if m.minLoadFactor == 0 || m.migration != nil {
+		return
+	}
+	newSize := len(m.entries)
+	for newSize > m.minTableSize && float64(m.size) < float64(newSize)*m.minLoadFactor {
+		newSize /= 2
+	}
+	if newSize != len(m.entries) {
+		m.resize(newSize)
+	}
+}
+
+// grow doubles the table, either at once or by starting an incremental migration
+func (m *RobinHoodMap[K, V]) grow(newSize int) {
+	if m.migrateStep == 0 {
+		m.resize(newSize)
+		return
+	}
+	// A table that fills up again before the previous migration ends finishes it first
+	m.finishMigration()
+	m.startMigration(newSize)
+	m.migrate(m.migrateStep)
+}
+
+func (m *RobinHoodMap[K, V]) resize(newSize int) {
+	m.finishMigration()
 	oldEntries := m.entries
-	newSize := len(m.entries) * 2
+	m.resizes++
 	m.entries = make([]entry[K, V], newSize)
 	m.mask = uint64(newSize - 1)
 	m.size = 0
 
 	for i := range oldEntries {
 		if oldEntries[i].occupied {
-			m.Put(oldEntries[i].key, oldEntries[i].value)
+			m.insert(oldEntries[i].hash, oldEntries[i].key, oldEntries[i].value)
 		}
 	}
 }
@@ -146,8 +264,19 @@ func (m *RobinHoodMap[K, V]) Size() int {
 	return m.size
 }
 
+// Capacity returns the number of slots in the table, growing happens before Size exceeds
+// Capacity times the max load factor
+func (m *RobinHoodMap[K, V]) Capacity() int {
+	return len(m.entries)
+}
+
 func (m *RobinHoodMap[K, V]) Clear() {
-	m.entries = make([]entry[K, V], 8)
-	m.mask = 7
 	m.size = 0
+	m.migration = nil
+	if m.keepOnClear {
+		clear(m.entries)
+		return
+	}
+	m.entries = make([]entry[K, V], m.minTableSize)
+	m.mask = uint64(m.minTableSize - 1)
 }
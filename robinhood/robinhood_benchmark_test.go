@@ -2,7 +2,10 @@ package fastmap_test
 
 import (
 	"fmt"
+	"math/rand/v2"
+	"slices"
 	"testing"
+	"time"
 
 	robinhood "github.com/billowdev/fastmap/robinhood"
 )
@@ -81,3 +84,132 @@ func BenchmarkHighLoadFactor(b *testing.B) {
 		m.Put(key, i)
 	}
 }
+
+// largeValue makes the entry layout pay for moving values, which the compact layout avoids
+type largeValue [256]byte
+
+func BenchmarkCompactRobinHoodGet(b *testing.B) {
+	const size = 1 << 16
+	entries := robinhood.NewRobinHoodMapWithHasher[int, largeValue](robinhood.NewIntegerHasher[int]())
+	compact := robinhood.NewCompactRobinHoodMapWithHasher[int, largeValue](robinhood.NewIntegerHasher[int]())
+	for i := 0; i < size; i++ {
+		entries.Put(i, largeValue{byte(i)})
+		compact.Put(i, largeValue{byte(i)})
+	}
+
+	b.Run("Entries/Hit", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			entries.Get(i % size)
+		}
+	})
+	b.Run("Compact/Hit", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			compact.Get(i % size)
+		}
+	})
+	b.Run("Entries/Miss", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			entries.Get(-1 - i)
+		}
+	})
+	b.Run("Compact/Miss", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			compact.Get(-1 - i)
+		}
+	})
+}
+
+func BenchmarkCompactRobinHoodPut(b *testing.B) {
+	b.Run("Entries", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMapWithHasher[int, largeValue](robinhood.NewIntegerHasher[int]())
+		for i := 0; i < b.N; i++ {
+			m.Put(i, largeValue{})
+		}
+	})
+	b.Run("Compact", func(b *testing.B) {
+		m := robinhood.NewCompactRobinHoodMapWithHasher[int, largeValue](robinhood.NewIntegerHasher[int]())
+		for i := 0; i < b.N; i++ {
+			m.Put(i, largeValue{})
+		}
+	})
+}
+
+func BenchmarkRobinHoodLoadFactor(b *testing.B) {
+	for _, loadFactor := range []float64{0.5, 0.75, 0.9, 0.95} {
+		// Fill a 65536 slot table right up to the threshold so lookups see the configured load
+		size := int(65536 * loadFactor)
+		m, err := robinhood.NewRobinHoodMapWithOptions[int, int](
+			robinhood.WithMaxLoadFactor(loadFactor),
+			robinhood.WithInitialCapacity(size),
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < size; i++ {
+			m.Put(i, i)
+		}
+
+		b.Run(fmt.Sprintf("Hit/%.2f", loadFactor), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.Get(i % size)
+			}
+		})
+		b.Run(fmt.Sprintf("Miss/%.2f", loadFactor), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.Get(-1 - i)
+			}
+		})
+	}
+}
+
+func BenchmarkPutLatency(b *testing.B) {
+	for _, step := range []int{0, 8, 64} {
+		b.Run(fmt.Sprintf("step%d", step), func(b *testing.B) {
+			m, err := robinhood.NewRobinHoodMapWithOptions[int, int](robinhood.WithIncrementalResize(step))
+			if err != nil {
+				b.Fatal(err)
+			}
+			latencies := make([]time.Duration, b.N)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				m.Put(i, i)
+				latencies[i] = time.Since(start)
+			}
+			b.StopTimer()
+			slices.Sort(latencies)
+			b.ReportMetric(float64(latencies[b.N*99/100].Nanoseconds()), "p99-ns")
+			b.ReportMetric(float64(latencies[b.N-1].Nanoseconds()), "max-ns")
+		})
+	}
+}
+
+func BenchmarkRobinHoodIntKeys(b *testing.B) {
+	b.Run("RobinHood", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMapWithHasher[int, int](robinhood.NewIntegerHasher[int]())
+		for i := 0; i < b.N; i++ {
+			m.Put(i, i)
+			m.Get(i)
+		}
+	})
+
+	b.Run("StandardMap", func(b *testing.B) {
+		m := make(map[int]int)
+		for i := 0; i < b.N; i++ {
+			m[i] = i
+			_ = m[i]
+		}
+	})
+}
+
+func BenchmarkRobinHoodRandomKey(b *testing.B) {
+	m := robinhood.NewRobinHoodMapWithHasher[int, int](robinhood.NewIntegerHasher[int]())
+	for i := 0; i < 100000; i++ {
+		m.Put(i, i)
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.RandomKey(rng)
+	}
+}
@@ -169,6 +169,48 @@ func TestComplexKeyTypes(t *testing.T) {
 	}
 }
 
+func TestLongCollisionChains(t *testing.T) {
+	// Every key hashes to the same slot, so probe distances grow far past 255
+	constant := robinhood.HasherFunc[int](func(int) uint64 { return 42 })
+	m := robinhood.NewRobinHoodMapWithHasher[int, int](constant)
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	for i := 0; i < 1000; i++ {
+		if value, exists := m.Get(i); !exists || value != i {
+			t.Fatalf("Get(%d) = (%d, %v) in a 1000 key collision chain", i, value, exists)
+		}
+	}
+
+	for i := 0; i < 1000; i += 3 {
+		if !m.Remove(i) {
+			t.Fatalf("Remove(%d) failed", i)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		if _, exists := m.Get(i); exists != (i%3 != 0) {
+			t.Errorf("Get(%d) exists = %v after removals", i, exists)
+		}
+	}
+	if m.Size() != 666 {
+		t.Errorf("Size should be 666, got %d", m.Size())
+	}
+}
+
+func TestClusteredHashes(t *testing.T) {
+	// Keys hash into runs of consecutive slots, which forces long probes until the table grows
+	clustered := robinhood.HasherFunc[int](func(key int) uint64 { return uint64(key / 100) })
+	m := robinhood.NewRobinHoodMapWithHasher[int, string](clustered)
+	for i := 0; i < 5000; i++ {
+		m.Put(i, fmt.Sprint(i))
+	}
+	for i := 0; i < 5000; i++ {
+		if value, exists := m.Get(i); !exists || value != fmt.Sprint(i) {
+			t.Fatalf("Get(%d) = (%q, %v)", i, value, exists)
+		}
+	}
+}
+
 func BenchmarkPut(b *testing.B) {
 	m := robinhood.NewRobinHoodMap[string, int]()
 
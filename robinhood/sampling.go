@@ -0,0 +1,124 @@
+package fastmap
+
+import "math/rand/v2"
+
+// maxRandomSlotProbes bounds the rejection sampling of RandomKey on very sparse tables
+const maxRandomSlotProbes = 64
+
+// RandomKey returns a uniformly random key, or false if the map is empty. It probes random
+// slots until it hits an occupied one, which takes 1/load factor probes on average.
+// Results are reproducible for a seeded rng and a deterministic hasher.
+// Example:
+//
+//	rng := rand.New(rand.NewPCG(1, 2))
+//	if backend, ok := backends.RandomKey(rng); ok {
+//	    route(backend)
+//	}
+func (m *RobinHoodMap[K, V]) RandomKey(rng *rand.Rand) (K, bool) {
+	var zero K
+	if m.size == 0 {
+		return zero, false
+	}
+	slots := m.slotCount()
+	for i := 0; i < maxRandomSlotProbes; i++ {
+		if entry := m.slot(rng.IntN(slots)); entry != nil {
+			return entry.key, true
+		}
+	}
+	// Too sparse for rejection sampling, pick the n-th entry instead
+	target := rng.IntN(m.size)
+	for entry := range m.all() {
+		if target == 0 {
+			return entry.key, true
+		}
+		target--
+	}
+	return zero, false
+}
+
+// Sample returns up to n distinct keys chosen uniformly at random without replacement, in
+// random order
+// Example:
+//
+//	rng := rand.New(rand.NewPCG(1, 2))
+//	canary := users.Sample(100, rng)
+func (m *RobinHoodMap[K, V]) Sample(n int, rng *rand.Rand) []K {
+	if n <= 0 || m.size == 0 {
+		return nil
+	}
+	slots := m.slotCount()
+	// Large samples and very sparse tables shuffle all keys instead of probing slots
+	if n*2 > m.size || m.size*maxRandomSlotProbes < slots {
+		keys := m.Keys()
+		// Partial Fisher-Yates shuffle of the first n positions
+		for i := 0; i < n && i < len(keys)-1; i++ {
+			j := i + rng.IntN(len(keys)-i)
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+		return keys[:min(n, len(keys))]
+	}
+
+	chosen := make(map[int]struct{}, n)
+	keys := make([]K, 0, n)
+	for len(keys) < n {
+		index := rng.IntN(slots)
+		if _, dup := chosen[index]; dup {
+			continue
+		}
+		if entry := m.slot(index); entry != nil {
+			chosen[index] = struct{}{}
+			keys = append(keys, entry.key)
+		}
+	}
+	return keys
+}
+
+// WeightedChoice returns a random key chosen with probability proportional to weight(key, value).
+// Entries with a weight of zero or less are never chosen, false is returned if no entry has a
+// positive weight. It reads every entry once.
+// Example:
+//
+//	rng := rand.New(rand.NewPCG(1, 2))
+//	backend, ok := backends.WeightedChoice(func(name string, b Backend) float64 {
+//	    return b.Capacity
+//	}, rng)
+func (m *RobinHoodMap[K, V]) WeightedChoice(weight func(K, V) float64, rng *rand.Rand) (K, bool) {
+	var chosen K
+	total := 0.0
+	for entry := range m.all() {
+		w := weight(entry.key, entry.value)
+		if !(w > 0) {
+			continue
+		}
+		total += w
+		if rng.Float64()*total < w {
+			chosen = entry.key
+		}
+	}
+	return chosen, total > 0
+}
+
+// slotCount returns the number of slots RandomKey and Sample draw from, including the old
+// table during an incremental resize
+func (m *RobinHoodMap[K, V]) slotCount() int {
+	if m.migration != nil {
+		return len(m.entries) + len(m.migration.entries)
+	}
+	return len(m.entries)
+}
+
+// slot returns the live entry at a position counted across both tables, or nil
+func (m *RobinHoodMap[K, V]) slot(index int) *entry[K, V] {
+	if index < len(m.entries) {
+		if m.entries[index].occupied {
+			return &m.entries[index]
+		}
+		return nil
+	}
+	index -= len(m.entries)
+	g := m.migration
+	if index < g.next || !g.entries[index].occupied || g.entries[index].deleted {
+		return nil
+	}
+	return &g.entries[index]
+}
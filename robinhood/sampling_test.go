@@ -0,0 +1,115 @@
+package fastmap_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+// An unseeded hasher keeps slot positions, and therefore draws, reproducible between runs
+var fixedHasher = robinhood.HasherFunc[int](func(key int) uint64 { return uint64(key) * 0x9e3779b97f4a7c15 })
+
+func TestRobinHoodRandomKeyIsUniform(t *testing.T) {
+	m := robinhood.NewRobinHoodMapWithHasher[int, int](fixedHasher)
+	for i := 0; i < 10; i++ {
+		m.Put(i, i)
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	counts := make([]int, 10)
+	for i := 0; i < 20000; i++ {
+		key, ok := m.RandomKey(rng)
+		if !ok {
+			t.Fatal("RandomKey failed on a non-empty map")
+		}
+		counts[key]++
+	}
+	for key, count := range counts {
+		if count < 1600 || count > 2400 {
+			t.Errorf("key %d drawn %d times, want about 2000", key, count)
+		}
+	}
+}
+
+func TestRobinHoodRandomKeySparseAndEmpty(t *testing.T) {
+	m := robinhood.NewRobinHoodMapWithHasher[int, int](fixedHasher)
+	rng := rand.New(rand.NewPCG(1, 2))
+	if _, ok := m.RandomKey(rng); ok {
+		t.Error("RandomKey on an empty map should return false")
+	}
+	for i := 0; i < 10000; i++ {
+		m.Put(i, i)
+	}
+	for i := 1; i < 10000; i++ {
+		m.Remove(i)
+	}
+	if key, ok := m.RandomKey(rng); !ok || key != 0 {
+		t.Errorf("RandomKey on a sparse map = (%d, %v), want the only key 0", key, ok)
+	}
+	if keys := m.Sample(3, rng); !slices.Equal(keys, []int{0}) {
+		t.Errorf("Sample on a sparse map = %v, want [0]", keys)
+	}
+}
+
+func TestRobinHoodSample(t *testing.T) {
+	m, err := robinhood.NewRobinHoodMapWithOptions[int, int](robinhood.WithIncrementalResize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	for _, n := range []int{10, 600, 1000, 2000} {
+		keys := m.Sample(n, rng)
+		if len(keys) != min(n, 1000) {
+			t.Errorf("Sample(%d) returned %d keys", n, len(keys))
+		}
+		seen := make(map[int]bool)
+		for _, key := range keys {
+			if seen[key] || !m.Contains(key) {
+				t.Errorf("Sample(%d) returned duplicate or unknown key %d", n, key)
+			}
+			seen[key] = true
+		}
+	}
+}
+
+func TestRobinHoodSamplingIsReproducible(t *testing.T) {
+	build := func() *robinhood.RobinHoodMap[int, int] {
+		m := robinhood.NewRobinHoodMapWithHasher[int, int](fixedHasher)
+		for i := 0; i < 500; i++ {
+			m.Put(i, i)
+		}
+		return m
+	}
+	a, b := build(), build()
+	rngA, rngB := rand.New(rand.NewPCG(9, 9)), rand.New(rand.NewPCG(9, 9))
+	if sampleA, sampleB := a.Sample(20, rngA), b.Sample(20, rngB); !slices.Equal(sampleA, sampleB) {
+		t.Errorf("Sample differs for the same seed: %v and %v", sampleA, sampleB)
+	}
+}
+
+func TestRobinHoodWeightedChoice(t *testing.T) {
+	m := robinhood.NewThreadSafeRobinHoodMap[string, float64]()
+	m.Put("heavy", 3)
+	m.Put("light", 1)
+	m.Put("disabled", 0)
+	rng := rand.New(rand.NewPCG(5, 6))
+
+	counts := make(map[string]int)
+	for i := 0; i < 20000; i++ {
+		key, ok := m.WeightedChoice(func(_ string, w float64) float64 { return w }, rng)
+		if !ok {
+			t.Fatal("WeightedChoice failed")
+		}
+		counts[key]++
+	}
+	if counts["disabled"] != 0 {
+		t.Error("zero weight key was chosen")
+	}
+	if counts["heavy"] < 14000 || counts["heavy"] > 16000 {
+		t.Errorf("heavy chosen %d times, want about 15000", counts["heavy"])
+	}
+}
@@ -0,0 +1,86 @@
+package fastmap
+
+import "math/bits"
+
+// Scan returns a page of keys starting at cursor and the cursor for the next page, which is 0
+// once the scan is complete. Start with cursor 0. Like Redis SCAN, the cursor walks home slots
+// in reverse-binary order, so every key present for the whole scan is returned at least once
+// even if the map is modified or resized between calls. Keys may be returned more than once.
+// count is a hint for the number of slots to visit, a page may hold more or fewer keys.
+// Example:
+//
+//	cursor := uint64(0)
+//	for {
+//	    var keys []string
+//	    keys, cursor = m.Scan(cursor, 100)
+//	    process(keys)
+//	    if cursor == 0 {
+//	        break
+//	    }
+//	}
+func (m *RobinHoodMap[K, V]) Scan(cursor uint64, count int) ([]K, uint64) {
+	if count <= 0 {
+		count = 10
+	}
+	var keys []K
+	small, large := m.scanTables()
+	for visited := 0; visited < count; visited++ {
+		keys = small.appendHome(keys, cursor&small.mask)
+		if large != nil {
+			// Visit every slot of the larger table whose low bits match the smaller one
+			v := cursor
+			for {
+				keys = large.appendHome(keys, v&large.mask)
+				v = (((v | small.mask) + 1) &^ small.mask) | (v & small.mask)
+				if v&(small.mask^large.mask) == 0 {
+					break
+				}
+			}
+		}
+
+		// Reverse-binary increment over the bits of the smaller table
+		cursor |= ^small.mask
+		cursor = bits.Reverse64(bits.Reverse64(cursor) + 1)
+		if cursor == 0 {
+			break
+		}
+	}
+	return keys, cursor
+}
+
+// scanTable is one table seen by Scan, with the slots below next skipped as already migrated
+type scanTable[K comparable, V any] struct {
+	entries []entry[K, V]
+	mask    uint64
+	next    int
+}
+
+// scanTables returns the current table and, during an incremental resize, the old one,
+// ordered smaller first
+func (m *RobinHoodMap[K, V]) scanTables() (*scanTable[K, V], *scanTable[K, V]) {
+	current := &scanTable[K, V]{entries: m.entries, mask: m.mask}
+	if m.migration == nil {
+		return current, nil
+	}
+	old := &scanTable[K, V]{entries: m.migration.entries, mask: m.migration.mask, next: m.migration.next}
+	if old.mask < current.mask {
+		return old, current
+	}
+	return current, old
+}
+
+// appendHome appends the keys whose home slot is home. Robin Hood keeps them in one run
+// starting at home, each at a distance equal to its offset.
+func (t *scanTable[K, V]) appendHome(keys []K, home uint64) []K {
+	index := home
+	for dist := uint32(0); ; dist++ {
+		entry := &t.entries[index]
+		if !entry.occupied || entry.distance < dist {
+			return keys
+		}
+		if entry.distance == dist && !entry.deleted && int(index) >= t.next {
+			keys = append(keys, entry.key)
+		}
+		index = (index + 1) & t.mask
+	}
+}
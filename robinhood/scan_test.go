@@ -0,0 +1,124 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func scanAll[V any](m *robinhood.RobinHoodMap[int, V], count int, between func(page int)) map[int]int {
+	seen := make(map[int]int)
+	cursor := uint64(0)
+	for page := 0; ; page++ {
+		var keys []int
+		keys, cursor = m.Scan(cursor, count)
+		for _, key := range keys {
+			seen[key]++
+		}
+		if cursor == 0 {
+			return seen
+		}
+		if between != nil {
+			between(page)
+		}
+	}
+}
+
+func TestScanReturnsEveryKeyOnce(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[int, int]()
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	seen := scanAll(m, 10, nil)
+	if len(seen) != 1000 {
+		t.Errorf("Scan returned %d distinct keys, want 1000", len(seen))
+	}
+	for key, times := range seen {
+		if times != 1 {
+			t.Errorf("key %d returned %d times without modifications", key, times)
+		}
+	}
+}
+
+func TestScanEmptyMap(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, int]()
+	keys, cursor := m.Scan(0, 100)
+	if len(keys) != 0 || cursor != 0 {
+		t.Errorf("Scan of empty map = (%v, %d)", keys, cursor)
+	}
+}
+
+func TestScanWithModificationsBetweenPages(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []robinhood.Option
+	}{
+		{"resize at once", nil},
+		{"incremental resize", []robinhood.Option{robinhood.WithIncrementalResize(4)}},
+		{"shrinking", []robinhood.Option{robinhood.WithMinLoadFactor(0.2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := robinhood.NewRobinHoodMapWithOptions[int, int](tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Keys below 500 stay for the whole scan, the rest come and go
+			for i := 0; i < 2000; i++ {
+				m.Put(i, i)
+			}
+			rng := rand.New(rand.NewPCG(5, 6))
+			next := 2000
+			seen := scanAll(m, 4, func(page int) {
+				for i := 0; i < 20; i++ {
+					if rng.IntN(2) == 0 {
+						m.Put(next, next)
+						next++
+					} else {
+						m.Remove(500 + rng.IntN(next-500))
+					}
+				}
+				if page == 20 {
+					// Force big jumps in table size mid scan
+					for i := 0; i < 20000; i++ {
+						m.Put(next+i, i)
+					}
+					for i := 0; i < 20000; i++ {
+						m.Remove(next + i)
+					}
+				}
+			})
+
+			for i := 0; i < 500; i++ {
+				if seen[i] == 0 {
+					t.Errorf("key %d was present for the whole scan but not returned", i)
+				}
+			}
+		})
+	}
+}
+
+func TestThreadSafeRobinHoodMapScan(t *testing.T) {
+	m := robinhood.NewThreadSafeRobinHoodMap[string, int]()
+	for i := 0; i < 100; i++ {
+		m.Put(fmt.Sprintf("key%d", i), i)
+	}
+	seen := make(map[string]bool)
+	cursor := uint64(0)
+	for {
+		var keys []string
+		keys, cursor = m.Scan(cursor, 7)
+		for _, key := range keys {
+			seen[key] = true
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+	if len(seen) != 100 {
+		t.Errorf("Scan returned %d distinct keys, want 100", len(seen))
+	}
+}
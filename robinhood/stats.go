@@ -0,0 +1,93 @@
+package fastmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// debugStringMaxSlots is the largest table DebugString renders slot by slot
+const debugStringMaxSlots = 64
+
+// Stats describes the occupancy and probe lengths of a RobinHoodMap
+type Stats struct {
+	Capacity   int
+	Size       int
+	LoadFactor float64
+	// ProbeHistogram[d] is the number of entries stored d slots away from their home slot
+	ProbeHistogram  []int
+	MeanProbeLength float64
+	MaxProbeLength  int
+	// Resizes counts every grow and shrink since the map was created
+	Resizes int
+	// MigratingSlots is the number of old table slots an incremental resize has yet to move
+	MigratingSlots int
+}
+
+// Stats scans the table and reports occupancy and probe length statistics. It is O(capacity).
+// Example:
+//
+//	stats := m.Stats()
+//	fmt.Printf("load %.2f, mean probe %.2f, max probe %d\n",
+//	    stats.LoadFactor, stats.MeanProbeLength, stats.MaxProbeLength)
+func (m *RobinHoodMap[K, V]) Stats() Stats {
+	stats := Stats{
+		Capacity:   len(m.entries),
+		Size:       m.size,
+		LoadFactor: float64(m.size) / float64(len(m.entries)),
+		Resizes:    m.resizes,
+	}
+	if m.migration != nil {
+		stats.MigratingSlots = len(m.migration.entries) - m.migration.next
+	}
+	total := 0
+	for entry := range m.all() {
+		distance := int(entry.distance)
+		for len(stats.ProbeHistogram) <= distance {
+			stats.ProbeHistogram = append(stats.ProbeHistogram, 0)
+		}
+		stats.ProbeHistogram[distance]++
+		stats.MaxProbeLength = max(stats.MaxProbeLength, distance)
+		total += distance
+	}
+	if m.size > 0 {
+		stats.MeanProbeLength = float64(total) / float64(m.size)
+	}
+	return stats
+}
+
+// String renders the stats on one line
+func (s Stats) String() string {
+	line := fmt.Sprintf("size=%d capacity=%d load=%.2f meanProbe=%.2f maxProbe=%d resizes=%d histogram=%v",
+		s.Size, s.Capacity, s.LoadFactor, s.MeanProbeLength, s.MaxProbeLength, s.Resizes, s.ProbeHistogram)
+	if s.MigratingSlots > 0 {
+		line += fmt.Sprintf(" migrating=%d", s.MigratingSlots)
+	}
+	return line
+}
+
+// DebugString renders the slot layout, one line per slot with its home slot and probe distance.
+// Tables larger than 64 slots only render the stats line.
+// Example:
+//
+//	fmt.Println(m.DebugString())
+//	// size=2 capacity=8 load=0.25 meanProbe=0.50 maxProbe=1 resizes=0 histogram=[1 1]
+//	// [0] empty
+//	// [1] home=1 dist=0 key1 => 100
+//	// [2] home=1 dist=1 key2 => 200
+//	// ...
+func (m *RobinHoodMap[K, V]) DebugString() string {
+	var b strings.Builder
+	b.WriteString(m.Stats().String())
+	if len(m.entries) > debugStringMaxSlots {
+		return b.String()
+	}
+	for i := range m.entries {
+		entry := &m.entries[i]
+		if !entry.occupied {
+			fmt.Fprintf(&b, "\n[%d] empty", i)
+			continue
+		}
+		fmt.Fprintf(&b, "\n[%d] home=%d dist=%d %v => %v", i, entry.hash&m.mask, entry.distance, entry.key, entry.value)
+	}
+	return b.String()
+}
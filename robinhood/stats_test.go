@@ -0,0 +1,73 @@
+package fastmap_test
+
+import (
+	"strings"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func TestRobinHoodMapStats(t *testing.T) {
+	m := robinhood.NewRobinHoodMapWithHasher[int, int](robinhood.NewIntegerHasher[int]())
+	empty := m.Stats()
+	if empty.Size != 0 || empty.Capacity != 8 || empty.MeanProbeLength != 0 || empty.Resizes != 0 {
+		t.Errorf("unexpected stats for empty map: %v", empty)
+	}
+
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	stats := m.Stats()
+	if stats.Size != 1000 || stats.Capacity != m.Capacity() {
+		t.Errorf("unexpected size or capacity: %v", stats)
+	}
+	if stats.LoadFactor <= 0 || stats.LoadFactor > 0.75 {
+		t.Errorf("load factor %v outside (0, 0.75]", stats.LoadFactor)
+	}
+	if stats.Resizes == 0 {
+		t.Error("expected resizes to be counted")
+	}
+	histogramTotal := 0
+	for _, count := range stats.ProbeHistogram {
+		histogramTotal += count
+	}
+	if histogramTotal != 1000 || len(stats.ProbeHistogram) != stats.MaxProbeLength+1 {
+		t.Errorf("histogram %v does not cover all entries", stats.ProbeHistogram)
+	}
+}
+
+func TestRobinHoodMapStatsDetectsBadHasher(t *testing.T) {
+	good := robinhood.NewRobinHoodMapWithHasher[int, int](robinhood.NewIntegerHasher[int]())
+	bad := robinhood.NewRobinHoodMapWithHasher[int, int](robinhood.HasherFunc[int](func(key int) uint64 {
+		return uint64(key % 16)
+	}))
+	for i := 0; i < 500; i++ {
+		good.Put(i, i)
+		bad.Put(i, i)
+	}
+	if good.Stats().MeanProbeLength*10 > bad.Stats().MeanProbeLength {
+		t.Errorf("expected the clustered hasher to show far longer probes: good %v, bad %v",
+			good.Stats(), bad.Stats())
+	}
+}
+
+func TestRobinHoodMapDebugString(t *testing.T) {
+	m := robinhood.NewRobinHoodMapWithHasher[string, int](robinhood.HasherFunc[string](func(string) uint64 { return 1 }))
+	m.Put("key1", 100)
+	m.Put("key2", 200)
+
+	lines := strings.Split(m.DebugString(), "\n")
+	if len(lines) != 1+m.Capacity() {
+		t.Fatalf("expected a stats line and one line per slot, got %q", lines)
+	}
+	if lines[1] != "[0] empty" || lines[2] != "[1] home=1 dist=0 key1 => 100" || lines[3] != "[2] home=1 dist=1 key2 => 200" {
+		t.Errorf("unexpected slot layout:\n%s", m.DebugString())
+	}
+
+	for i := 0; i < 100; i++ {
+		m.Put(strings.Repeat("k", i+3), i)
+	}
+	if strings.Contains(m.DebugString(), "\n") {
+		t.Error("large maps should only render the stats line")
+	}
+}
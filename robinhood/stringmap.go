@@ -0,0 +1,372 @@
+package fastmap
+
+import "fmt"
+
+const (
+	minSlabSize = 256
+	maxSlabSize = 1 << 20
+)
+
+// StringMap is a RobinHoodMap specialized for string keys that keeps the garbage collector
+// away from its keys. Key bytes are copied into large byte slabs and slots refer to them by
+// slab, offset and length, so slots hold no pointers of their own. When V has no pointers
+// either the whole slot array is skipped by the garbage collector and a map of any size costs
+// only a handful of pointers to mark.
+// Keys returned by Keys, ForEach and the functional helpers are fresh copies. Bytes of removed
+// keys are reclaimed by compacting the slabs once they make up most of them.
+// Like RobinHoodMap it is safe for concurrent readers as long as nobody writes.
+// Example:
+//
+//	m := NewStringMap[uint64]()
+//	m.Put("user:1234", 42)
+type StringMap[V any] struct {
+	slots []stringSlot[V]
+	size  int
+	mask  uint64
+	slabs [][]byte
+	// stored counts the bytes in slabs, garbage the part of them belonging to removed keys
+	stored  int
+	garbage int
+	hasher  Hasher[string]
+}
+
+// stringSlot must stay free of pointers apart from those inside V
+type stringSlot[V any] struct {
+	hash uint64
+	key  keyRef
+	// distance is the probe distance plus one, 0 marks an empty slot
+	distance uint32
+	value    V
+}
+
+// keyRef locates key bytes inside the slabs
+type keyRef struct {
+	slab   uint32
+	offset uint32
+	length uint32
+}
+
+// NewStringMap creates a new StringMap
+// Example:
+//
+//	m := NewStringMap[uint64]()
+func NewStringMap[V any]() *StringMap[V] {
+	return NewStringMapWithHasher[V](NewStringHasher[string]())
+}
+
+// NewStringMapWithHasher creates a StringMap that hashes keys with the given hasher
+// Example:
+//
+//	m := NewStringMapWithHasher[uint64](NewStringHasher[string]())
+func NewStringMapWithHasher[V any](hasher Hasher[string]) *StringMap[V] {
+	m := &StringMap[V]{hasher: hasher}
+	m.allocate(defaultCapacity)
+	return m
+}
+
+func (m *StringMap[V]) allocate(size int) {
+	m.slots = make([]stringSlot[V], size)
+	m.mask = uint64(size - 1)
+	m.size = 0
+}
+
+// key returns the bytes of ref, they alias a slab and must not be modified or retained
+func (m *StringMap[V]) key(ref keyRef) []byte {
+	return m.slabs[ref.slab][ref.offset : ref.offset+ref.length]
+}
+
+// store copies key into the last slab, starting a bigger slab when it does not fit
+func (m *StringMap[V]) store(key string) keyRef {
+	last := len(m.slabs) - 1
+	if last < 0 || cap(m.slabs[last])-len(m.slabs[last]) < len(key) {
+		size := minSlabSize
+		if last >= 0 {
+			size = min(cap(m.slabs[last])*2, maxSlabSize)
+		}
+		m.slabs = append(m.slabs, make([]byte, 0, max(size, len(key))))
+		last++
+	}
+	ref := keyRef{slab: uint32(last), offset: uint32(len(m.slabs[last])), length: uint32(len(key))}
+	m.slabs[last] = append(m.slabs[last], key...)
+	m.stored += len(key)
+	return ref
+}
+
+// Put adds or updates a key-value pair. The key is copied, the caller's string is not retained.
+// Example:
+//
+//	m.Put("user:1234", 42)
+func (m *StringMap[V]) Put(key string, value V) {
+	hash := m.hasher.Hash(key)
+	if index := m.findHash(hash, key); index >= 0 {
+		m.slots[index].value = value
+		return
+	}
+	if float64(m.size+1)/float64(len(m.slots)) > defaultMaxLoadFactor {
+		m.resize(len(m.slots) * 2)
+	}
+	probe := m.insert(stringSlot[V]{hash: hash, key: m.store(key), value: value})
+	if probe > maxProbeDistance && m.size*8 >= len(m.slots) {
+		m.resize(len(m.slots) * 2)
+	}
+}
+
+// insert places a slot for a key known to be absent and returns the longest probe distance it
+// had to walk
+func (m *StringMap[V]) insert(slot stringSlot[V]) uint32 {
+	index := slot.hash & m.mask
+	slot.distance = 1
+	longest := uint32(0)
+
+	for {
+		current := &m.slots[index]
+		if current.distance == 0 {
+			*current = slot
+			m.size++
+			return max(longest, slot.distance-1)
+		}
+		// Robin Hood: rich (current slot) vs poor (new slot)
+		if slot.distance > current.distance {
+			longest = max(longest, slot.distance-1)
+			slot, *current = *current, slot
+		}
+		slot.distance++
+		index = (index + 1) & m.mask
+	}
+}
+
+// findHash returns the slot index holding key, or -1 if the key is absent
+func (m *StringMap[V]) findHash(hash uint64, key string) int {
+	index := hash & m.mask
+	dist := uint32(1)
+
+	for {
+		slot := &m.slots[index]
+		if slot.distance == 0 || dist > slot.distance {
+			return -1
+		}
+		if slot.hash == hash && string(m.key(slot.key)) == key {
+			return int(index)
+		}
+		dist++
+		index = (index + 1) & m.mask
+	}
+}
+
+// Get retrieves a value by key and returns whether it exists
+// Example:
+//
+//	if id, exists := m.Get("user:1234"); exists {
+//	    fmt.Printf("Found id: %d\n", id)
+//	}
+func (m *StringMap[V]) Get(key string) (V, bool) {
+	if index := m.findHash(m.hasher.Hash(key), key); index >= 0 {
+		return m.slots[index].value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Contains checks if a key exists
+func (m *StringMap[V]) Contains(key string) bool {
+	return m.findHash(m.hasher.Hash(key), key) >= 0
+}
+
+// Remove deletes a key and returns whether it existed
+// Example:
+//
+//	if m.Remove("user:1234") {
+//	    fmt.Println("removed")
+//	}
+func (m *StringMap[V]) Remove(key string) bool {
+	index := m.findHash(m.hasher.Hash(key), key)
+	if index < 0 {
+		return false
+	}
+	m.size--
+	m.garbage += int(m.slots[index].key.length)
+
+	// Backward shift deletion
+	for {
+		next := (index + 1) & int(m.mask)
+		if m.slots[next].distance <= 1 {
+			m.slots[index] = stringSlot[V]{}
+			break
+		}
+		m.slots[index] = m.slots[next]
+		m.slots[index].distance--
+		index = next
+	}
+	if m.garbage >= maxSlabSize && m.garbage*2 > m.stored {
+		m.compact()
+	}
+	return true
+}
+
+// compact copies the keys still in use into fresh slabs and drops the old ones
+func (m *StringMap[V]) compact() {
+	old := m.slabs
+	m.slabs, m.stored, m.garbage = nil, 0, 0
+	for i := range m.slots {
+		slot := &m.slots[i]
+		if slot.distance != 0 {
+			ref := slot.key
+			slot.key = m.store(string(old[ref.slab][ref.offset : ref.offset+ref.length]))
+		}
+	}
+}
+
+func (m *StringMap[V]) resize(newSize int) {
+	old := m.slots
+	m.allocate(newSize)
+	for i := range old {
+		if old[i].distance != 0 {
+			m.insert(old[i])
+		}
+	}
+}
+
+// Size returns the number of elements
+func (m *StringMap[V]) Size() int {
+	return m.size
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *StringMap[V]) IsEmpty() bool {
+	return m.size == 0
+}
+
+// Capacity returns the number of slots in the table
+func (m *StringMap[V]) Capacity() int {
+	return len(m.slots)
+}
+
+// ArenaBytes returns the number of key bytes held in slabs, including bytes of removed keys
+// that have not been compacted away yet
+func (m *StringMap[V]) ArenaBytes() int {
+	return m.stored
+}
+
+// Clear removes all elements, releases the key slabs and drops back to the initial capacity
+func (m *StringMap[V]) Clear() {
+	m.allocate(defaultCapacity)
+	m.slabs, m.stored, m.garbage = nil, 0, 0
+}
+
+// Keys returns a slice of all keys
+func (m *StringMap[V]) Keys() []string {
+	keys := make([]string, 0, m.size)
+	for i := range m.slots {
+		if m.slots[i].distance != 0 {
+			keys = append(keys, string(m.key(m.slots[i].key)))
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *StringMap[V]) Values() []V {
+	values := make([]V, 0, m.size)
+	for i := range m.slots {
+		if m.slots[i].distance != 0 {
+			values = append(values, m.slots[i].value)
+		}
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The callback must not modify the map.
+// Example:
+//
+//	err := m.ForEach(func(key string, id uint64) error {
+//	    return index.Add(key, id)
+//	})
+func (m *StringMap[V]) ForEach(callback func(string, V) error) error {
+	for i := range m.slots {
+		slot := &m.slots[i]
+		if slot.distance == 0 {
+			continue
+		}
+		key := string(m.key(slot.key))
+		if err := callback(key, slot.value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// UpdateValue updates the value of an existing key and returns whether the key exists
+// Example:
+//
+//	if !m.UpdateValue("user:1234", 43) {
+//	    fmt.Println("no such user")
+//	}
+func (m *StringMap[V]) UpdateValue(key string, newValue V) bool {
+	index := m.findHash(m.hasher.Hash(key), key)
+	if index < 0 {
+		return false
+	}
+	m.slots[index].value = newValue
+	return true
+}
+
+// PutAll adds all key-value pairs from another StringMap
+// Example:
+//
+//	m.PutAll(other)
+func (m *StringMap[V]) PutAll(other *StringMap[V]) {
+	for i := range other.slots {
+		if other.slots[i].distance != 0 {
+			m.Put(string(other.key(other.slots[i].key)), other.slots[i].value)
+		}
+	}
+}
+
+// Filter returns a new StringMap with the entries for which predicate returns true
+// Example:
+//
+//	active := m.Filter(func(key string, id uint64) bool {
+//	    return strings.HasPrefix(key, "user:")
+//	})
+func (m *StringMap[V]) Filter(predicate func(string, V) bool) *StringMap[V] {
+	result := NewStringMapWithHasher[V](m.hasher)
+	for i := range m.slots {
+		slot := &m.slots[i]
+		if slot.distance != 0 {
+			if key := string(m.key(slot.key)); predicate(key, slot.value) {
+				result.Put(key, slot.value)
+			}
+		}
+	}
+	return result
+}
+
+// Map returns a new StringMap with every value replaced by transform(key, value)
+// Example:
+//
+//	next := m.Map(func(key string, id uint64) uint64 {
+//	    return id + 1
+//	})
+func (m *StringMap[V]) Map(transform func(string, V) V) *StringMap[V] {
+	result := NewStringMapWithHasher[V](m.hasher)
+	for i := range m.slots {
+		slot := &m.slots[i]
+		if slot.distance != 0 {
+			key := string(m.key(slot.key))
+			result.Put(key, transform(key, slot.value))
+		}
+	}
+	return result
+}
+
+// ToMap returns the contents as a regular map
+func (m *StringMap[V]) ToMap() map[string]V {
+	result := make(map[string]V, m.size)
+	for i := range m.slots {
+		if m.slots[i].distance != 0 {
+			result[string(m.key(m.slots[i].key))] = m.slots[i].value
+		}
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+const stringMapGCKeys = 1 << 20
+
+// benchmarkGC reports the cost of a full collection while m is live, ns/op is one forced
+// GC cycle and pause-ns/op the stop-the-world part of it
+func benchmarkGC(b *testing.B, m any) {
+	runtime.GC()
+	var before, after debug.GCStats
+	debug.ReadGCStats(&before)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+	}
+	b.StopTimer()
+	debug.ReadGCStats(&after)
+	b.ReportMetric(float64(after.PauseTotal-before.PauseTotal)/float64(b.N), "pause-ns/op")
+	runtime.KeepAlive(m)
+}
+
+func BenchmarkStringMapGC(b *testing.B) {
+	// Keys are built on the fly so the only live strings are the ones each map holds
+	key := func(i int) string { return fmt.Sprintf("user:%d:session", i) }
+
+	b.Run("StringMap", func(b *testing.B) {
+		m := robinhood.NewStringMap[int]()
+		for i := 0; i < stringMapGCKeys; i++ {
+			m.Put(key(i), i)
+		}
+		benchmarkGC(b, m)
+	})
+
+	b.Run("RobinHoodMap", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMap[string, int]()
+		for i := 0; i < stringMapGCKeys; i++ {
+			m.Put(key(i), i)
+		}
+		benchmarkGC(b, m)
+	})
+
+	b.Run("StandardMap", func(b *testing.B) {
+		m := make(map[string]int)
+		for i := 0; i < stringMapGCKeys; i++ {
+			m[key(i)] = i
+		}
+		benchmarkGC(b, m)
+	})
+}
+
+func BenchmarkStringMapGet(b *testing.B) {
+	keys := make([]string, 100000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:%d:session", i)
+	}
+
+	b.Run("StringMap", func(b *testing.B) {
+		m := robinhood.NewStringMap[int]()
+		for i, key := range keys {
+			m.Put(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Get(keys[i%len(keys)])
+		}
+	})
+
+	b.Run("RobinHoodMap", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMap[string, int]()
+		for i, key := range keys {
+			m.Put(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Get(keys[i%len(keys)])
+		}
+	})
+}
+
+func BenchmarkStringMapPut(b *testing.B) {
+	keys := make([]string, 100000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:%d:session", i)
+	}
+
+	b.Run("StringMap", func(b *testing.B) {
+		m := robinhood.NewStringMap[int]()
+		for i := 0; i < b.N; i++ {
+			m.Put(keys[i%len(keys)], i)
+		}
+	})
+
+	b.Run("RobinHoodMap", func(b *testing.B) {
+		m := robinhood.NewRobinHoodMap[string, int]()
+		for i := 0; i < b.N; i++ {
+			m.Put(keys[i%len(keys)], i)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package fastmap_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func TestStringMapBasicOperations(t *testing.T) {
+	m := robinhood.NewStringMap[int]()
+	long := strings.Repeat("x", 3<<20)
+	m.Put("", 1)
+	m.Put("a", 2)
+	m.Put(long, 3)
+	m.Put("a", 4)
+
+	if m.Size() != 3 {
+		t.Errorf("Size = %d, want 3", m.Size())
+	}
+	for key, want := range map[string]int{"": 1, "a": 4, long: 3} {
+		if value, exists := m.Get(key); !exists || value != want {
+			t.Errorf("Get(%.10q) = (%d, %v), want %d", key, value, exists, want)
+		}
+	}
+	if !m.Remove(long) || m.Contains(long) || m.Remove(long) {
+		t.Error("Remove of the long key failed")
+	}
+	if !m.UpdateValue("", 5) || m.UpdateValue("missing", 1) {
+		t.Error("UpdateValue reported the wrong keys")
+	}
+	if value, _ := m.Get(""); value != 5 {
+		t.Errorf("Get(\"\") after UpdateValue = %d, want 5", value)
+	}
+}
+
+func TestStringMapMatchesReference(t *testing.T) {
+	// Hashing by length puts every key of one length on the same home slot
+	byLength := robinhood.HasherFunc[string](func(key string) uint64 { return uint64(len(key)) })
+	for name, m := range map[string]*robinhood.StringMap[int]{
+		"default":   robinhood.NewStringMap[int](),
+		"colliding": robinhood.NewStringMapWithHasher[int](byLength),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(5, 5))
+			reference := make(map[string]int)
+			for i := 0; i < 30000; i++ {
+				key := fmt.Sprintf("k%d", rng.IntN(3000))
+				if rng.IntN(3) == 0 {
+					_, want := reference[key]
+					if m.Remove(key) != want {
+						t.Fatalf("Remove(%s) disagreed with reference at op %d", key, i)
+					}
+					delete(reference, key)
+				} else {
+					m.Put(key, i)
+					reference[key] = i
+				}
+			}
+			if m.Size() != len(reference) {
+				t.Errorf("Size = %d, want %d", m.Size(), len(reference))
+			}
+			for k, v := range m.ToMap() {
+				if reference[k] != v {
+					t.Errorf("ToMap has %s=%d, want %d", k, v, reference[k])
+				}
+			}
+		})
+	}
+}
+
+func TestStringMapCompaction(t *testing.T) {
+	m := robinhood.NewStringMap[int]()
+	for i := 0; i < 200000; i++ {
+		m.Put(fmt.Sprintf("compaction-key-%08d", i), i)
+	}
+	full := m.ArenaBytes()
+	for i := 0; i < 200000; i++ {
+		if i%100 != 0 {
+			m.Remove(fmt.Sprintf("compaction-key-%08d", i))
+		}
+	}
+	if m.ArenaBytes() > full/4 {
+		t.Errorf("ArenaBytes = %d after removing 99%% of %d bytes, want compaction", m.ArenaBytes(), full)
+	}
+	for i := 0; i < 200000; i += 100 {
+		if value, exists := m.Get(fmt.Sprintf("compaction-key-%08d", i)); !exists || value != i {
+			t.Fatalf("key %d lost after compaction", i)
+		}
+	}
+	m.Clear()
+	if m.ArenaBytes() != 0 || !m.IsEmpty() {
+		t.Error("Clear should release every slab")
+	}
+}
+
+func TestStringMapFunctional(t *testing.T) {
+	m := robinhood.NewStringMap[int]()
+	for i := 0; i < 10; i++ {
+		m.Put(fmt.Sprintf("key%d", i), i)
+	}
+	even := m.Filter(func(_ string, v int) bool { return v%2 == 0 })
+	doubled := even.Map(func(_ string, v int) int { return v * 2 })
+	if even.Size() != 5 || doubled.Size() != 5 {
+		t.Errorf("Filter kept %d entries, Map %d, want 5", even.Size(), doubled.Size())
+	}
+	if value, _ := doubled.Get("key4"); value != 8 {
+		t.Errorf("doubled key4 = %d, want 8", value)
+	}
+
+	other := robinhood.NewStringMap[int]()
+	other.PutAll(doubled)
+	other.Put("extra", 1)
+	if other.Size() != 6 || len(other.Keys()) != 6 || len(other.Values()) != 6 {
+		t.Errorf("PutAll produced %v", other.ToMap())
+	}
+
+	stop := errors.New("stop")
+	if err := m.ForEach(func(string, int) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ForEach error = %v, want wrapped stop", err)
+	}
+}
+
+// stringMapGCKeys is large enough that marking dominates a GC cycle
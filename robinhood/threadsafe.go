@@ -0,0 +1,175 @@
+package fastmap
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// ThreadSafeRobinHoodMap provides thread-safe operations for RobinHoodMap through a read-write mutex
+// Example:
+//
+//	safeMap := NewThreadSafeRobinHoodMap[string, User]()
+//	safeMap.Put("user1", User{Name: "John"})
+type ThreadSafeRobinHoodMap[K comparable, V any] struct {
+	mutex sync.RWMutex
+	data  *RobinHoodMap[K, V]
+}
+
+// NewThreadSafeRobinHoodMap creates a new thread-safe RobinHoodMap
+// Example:
+//
+//	safeMap := NewThreadSafeRobinHoodMap[string, User]()
+func NewThreadSafeRobinHoodMap[K comparable, V any]() *ThreadSafeRobinHoodMap[K, V] {
+	return &ThreadSafeRobinHoodMap[K, V]{
+		data: NewRobinHoodMap[K, V](),
+	}
+}
+
+// NewThreadSafeRobinHoodMapWithHasher creates a thread-safe RobinHoodMap that hashes keys with the given hasher
+// Example:
+//
+//	safeMap := NewThreadSafeRobinHoodMapWithHasher[int, User](NewIntegerHasher[int]())
+func NewThreadSafeRobinHoodMapWithHasher[K comparable, V any](hasher Hasher[K]) *ThreadSafeRobinHoodMap[K, V] {
+	return &ThreadSafeRobinHoodMap[K, V]{
+		data: NewRobinHoodMapWithHasher[K, V](hasher),
+	}
+}
+
+// NewThreadSafeRobinHoodMapWithOptions creates a thread-safe RobinHoodMap configured by the given options
+// Example:
+//
+//	safeMap, err := NewThreadSafeRobinHoodMapWithOptions[string, User](WithInitialCapacity(10_000))
+func NewThreadSafeRobinHoodMapWithOptions[K comparable, V any](opts ...Option) (*ThreadSafeRobinHoodMap[K, V], error) {
+	data, err := NewRobinHoodMapWithOptions[K, V](opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ThreadSafeRobinHoodMap[K, V]{data: data}, nil
+}
+
+// Put adds or updates a key-value pair with write lock
+// Example:
+//
+//	safeMap.Put("user123", User{Name: "John"})
+func (t *ThreadSafeRobinHoodMap[K, V]) Put(key K, value V) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.data.Put(key, value)
+}
+
+// Get retrieves a value by key and returns whether it exists with read lock
+// Example:
+//
+//	if user, exists := safeMap.Get("user123"); exists {
+//	    fmt.Printf("Found user: %v\n", user)
+//	}
+func (t *ThreadSafeRobinHoodMap[K, V]) Get(key K) (V, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.Get(key)
+}
+
+// Remove deletes a key-value pair with write lock and returns whether the key existed
+// Example:
+//
+//	if safeMap.Remove("user123") {
+//	    fmt.Println("removed")
+//	}
+func (t *ThreadSafeRobinHoodMap[K, V]) Remove(key K) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.data.Remove(key)
+}
+
+// Contains checks if a key exists with read lock
+func (t *ThreadSafeRobinHoodMap[K, V]) Contains(key K) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.Contains(key)
+}
+
+// IsEmpty returns true if the map has no elements with read lock
+func (t *ThreadSafeRobinHoodMap[K, V]) IsEmpty() bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.IsEmpty()
+}
+
+// Keys returns a slice of all keys with read lock
+func (t *ThreadSafeRobinHoodMap[K, V]) Keys() []K {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.Keys()
+}
+
+// Values returns a slice of all values with read lock
+func (t *ThreadSafeRobinHoodMap[K, V]) Values() []V {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.Values()
+}
+
+// ForEach executes a callback function for each key-value pair with read lock and returns an error if the callback fails.
+// The callback must not modify the map.
+// Example:
+//
+//	err := safeMap.ForEach(func(key string, value User) error {
+//	    fmt.Printf("User %s: %v\n", key, value)
+//	    return nil
+//	})
+func (t *ThreadSafeRobinHoodMap[K, V]) ForEach(callback func(K, V) error) error {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.ForEach(callback)
+}
+
+// Size returns the number of elements with read lock
+func (t *ThreadSafeRobinHoodMap[K, V]) Size() int {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.Size()
+}
+
+// Clear removes all elements with write lock
+func (t *ThreadSafeRobinHoodMap[K, V]) Clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.data.Clear()
+}
+
+// Stats reports occupancy and probe length statistics with read lock
+func (t *ThreadSafeRobinHoodMap[K, V]) Stats() Stats {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.Stats()
+}
+
+// Scan returns a page of keys starting at cursor and the cursor for the next page with read lock,
+// see RobinHoodMap.Scan
+func (t *ThreadSafeRobinHoodMap[K, V]) Scan(cursor uint64, count int) ([]K, uint64) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.Scan(cursor, count)
+}
+
+// RandomKey returns a uniformly random key with read lock, see RobinHoodMap.RandomKey
+func (t *ThreadSafeRobinHoodMap[K, V]) RandomKey(rng *rand.Rand) (K, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.RandomKey(rng)
+}
+
+// Sample returns up to n random distinct keys with read lock, see RobinHoodMap.Sample
+func (t *ThreadSafeRobinHoodMap[K, V]) Sample(n int, rng *rand.Rand) []K {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.Sample(n, rng)
+}
+
+// WeightedChoice returns a key chosen with probability proportional to its weight with read lock,
+// see RobinHoodMap.WeightedChoice
+func (t *ThreadSafeRobinHoodMap[K, V]) WeightedChoice(weight func(K, V) float64, rng *rand.Rand) (K, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.data.WeightedChoice(weight, rng)
+}
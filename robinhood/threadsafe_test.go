@@ -0,0 +1,85 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	robinhood "github.com/billowdev/fastmap/robinhood"
+)
+
+func TestRobinHoodMapConcurrentReaders(t *testing.T) {
+	m := robinhood.NewRobinHoodMap[string, int]()
+	for i := 0; i < 1000; i++ {
+		m.Put(fmt.Sprintf("key%d", i), i)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if value, exists := m.Get(fmt.Sprintf("key%d", i)); !exists || value != i {
+					t.Errorf("Get(key%d) = (%d, %v)", i, value, exists)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestThreadSafeRobinHoodMapConcurrentAccess(t *testing.T) {
+	m := robinhood.NewThreadSafeRobinHoodMap[int, int]()
+	const writers, perWriter = 4, 500
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				key := w*perWriter + i
+				m.Put(key, key)
+				if i%5 == 0 {
+					m.Remove(key)
+				}
+			}
+		}(w)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				key := w*perWriter + i
+				if value, exists := m.Get(key); exists && value != key {
+					t.Errorf("Get(%d) = %d", key, value)
+				}
+				m.Size()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if want := writers * perWriter * 4 / 5; m.Size() != want {
+		t.Errorf("Size should be %d, got %d", want, m.Size())
+	}
+	m.Clear()
+	if m.Size() != 0 {
+		t.Errorf("Size after Clear should be 0, got %d", m.Size())
+	}
+}
+
+func BenchmarkThreadSafeRobinHoodMapParallelGet(b *testing.B) {
+	m := robinhood.NewThreadSafeRobinHoodMapWithHasher[int, int](robinhood.NewIntegerHasher[int]())
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Get(i % 1000)
+			i++
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package fastmap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	hashmap "github.com/billowdev/fastmap/hashmap"
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+// encodingMagic starts every encoded StaticMap, the last byte is the format version
+var encodingMagic = [8]byte{'F', 'M', 'S', 'T', 'A', 'T', 'I', 1}
+
+const (
+	// maxEncodedEntrySize bounds a single encoded key or value when decoding
+	maxEncodedEntrySize = 1 << 30
+	// decodeChunk caps the up-front allocation for counts read from the header, slices grow
+	// as data is actually read so a corrupt header cannot force a huge allocation
+	decodeChunk = 4096
+)
+
+// ErrCorrupt is returned when decoding data that is not a valid encoded StaticMap
+var ErrCorrupt = errors.New("static map: corrupt encoding")
+
+// Encode writes the map in a binary format that DecodeStaticMap reads back without rebuilding
+// the perfect hash. Keys must hash the same in the decoding process, which holds for every key
+// type without pointers.
+// Example:
+//
+//	file, err := os.Create("countries.bin")
+//	if err != nil {
+//	    return err
+//	}
+//	defer file.Close()
+//	err = countries.Encode(file, hashmap.StringCodec{}, hashmap.StringCodec{})
+func (m *StaticMap[K, V]) Encode(w io.Writer, keyCodec hashmap.Codec[K], valueCodec hashmap.Codec[V]) error {
+	out := bufio.NewWriter(w)
+	header := make([]byte, 0, len(encodingMagic)+24)
+	header = append(header, encodingMagic[:]...)
+	header = binary.LittleEndian.AppendUint64(header, m.seed)
+	header = binary.LittleEndian.AppendUint64(header, uint64(len(m.entries)))
+	header = binary.LittleEndian.AppendUint64(header, uint64(len(m.displacements)))
+	if _, err := out.Write(header); err != nil {
+		return fmt.Errorf("encode static map: %w", err)
+	}
+	var word [8]byte
+	for _, d := range m.displacements {
+		binary.LittleEndian.PutUint64(word[:], d)
+		if _, err := out.Write(word[:]); err != nil {
+			return fmt.Errorf("encode static map: %w", err)
+		}
+	}
+
+	for i := range m.entries {
+		k := m.entries[i].key
+		keyData, err := keyCodec.Encode(k)
+		if err != nil {
+			return fmt.Errorf("encode static map key %v: %w", k, err)
+		}
+		valueData, err := valueCodec.Encode(m.entries[i].value)
+		if err != nil {
+			return fmt.Errorf("encode static map value of %v: %w", k, err)
+		}
+		record := binary.AppendUvarint(nil, uint64(len(keyData)))
+		record = append(record, keyData...)
+		record = binary.AppendUvarint(record, uint64(len(valueData)))
+		record = append(record, valueData...)
+		if _, err := out.Write(record); err != nil {
+			return fmt.Errorf("encode static map: %w", err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("encode static map: %w", err)
+	}
+	return nil
+}
+
+// DecodeStaticMap reads a map written by Encode and checks that every key still hashes to its
+// slot, so data from an incompatible build or for pointer keys is rejected
+// Example:
+//
+//	file, err := os.Open("countries.bin")
+//	if err != nil {
+//	    return err
+//	}
+//	defer file.Close()
+//	countries, err := DecodeStaticMap(file, hashmap.StringCodec{}, hashmap.StringCodec{})
+func DecodeStaticMap[K comparable, V any](r io.Reader, keyCodec hashmap.Codec[K], valueCodec hashmap.Codec[V]) (*StaticMap[K, V], error) {
+	in := bufio.NewReader(r)
+	header := make([]byte, len(encodingMagic)+24)
+	if _, err := io.ReadFull(in, header); err != nil {
+		return nil, fmt.Errorf("decode static map header: %w", err)
+	}
+	if [8]byte(header[:8]) != encodingMagic {
+		return nil, fmt.Errorf("%w: bad magic or version", ErrCorrupt)
+	}
+	seed := binary.LittleEndian.Uint64(header[8:])
+	n := binary.LittleEndian.Uint64(header[16:])
+	buckets := binary.LittleEndian.Uint64(header[24:])
+	if n > math.MaxUint32 || buckets != uint64(bucketCount(int(n))) {
+		return nil, fmt.Errorf("%w: %d keys in %d buckets", ErrCorrupt, n, buckets)
+	}
+
+	m := &StaticMap[K, V]{
+		seed:          seed,
+		hash:          hashing.For[K](),
+		modulus:       fastModulus(int(n)),
+		displacements: make([]uint64, 0, min(buckets, decodeChunk)),
+		entries:       make([]staticEntry[K, V], 0, min(n, decodeChunk)),
+	}
+	var word [8]byte
+	for i := uint64(0); i < buckets; i++ {
+		if _, err := io.ReadFull(in, word[:]); err != nil {
+			return nil, fmt.Errorf("decode static map displacements: %w", err)
+		}
+		d := binary.LittleEndian.Uint64(word[:])
+		if d>>32 >= maxD0 || d&math.MaxUint32 >= max(n, 1) {
+			return nil, fmt.Errorf("%w: displacement %d out of range", ErrCorrupt, i)
+		}
+		m.displacements = append(m.displacements, d)
+	}
+
+	for i := uint64(0); i < n; i++ {
+		var entry staticEntry[K, V]
+		keyData, err := readEncodedEntry(in)
+		if err != nil {
+			return nil, fmt.Errorf("decode static map key %d: %w", i, err)
+		}
+		if entry.key, err = keyCodec.Decode(keyData); err != nil {
+			return nil, fmt.Errorf("decode static map key %d: %w", i, err)
+		}
+		valueData, err := readEncodedEntry(in)
+		if err != nil {
+			return nil, fmt.Errorf("decode static map value %d: %w", i, err)
+		}
+		if entry.value, err = valueCodec.Decode(valueData); err != nil {
+			return nil, fmt.Errorf("decode static map value %d: %w", i, err)
+		}
+		if m.slotIn(entry.key, int(n)) != i {
+			return nil, fmt.Errorf("%w: key %v does not hash to its slot", ErrCorrupt, entry.key)
+		}
+		m.entries = append(m.entries, entry)
+	}
+	return m, nil
+}
+
+func readEncodedEntry(in *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(in)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxEncodedEntrySize {
+		return nil, fmt.Errorf("%w: entry of %d bytes", ErrCorrupt, size)
+	}
+	// ReadAll grows the buffer with the data instead of trusting the length prefix
+	data, err := io.ReadAll(io.LimitReader(in, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
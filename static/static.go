@@ -0,0 +1,341 @@
+package fastmap
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+const (
+	// averageBucketSize is the number of keys sharing one displacement on average, larger
+	// buckets save memory and cost build time
+	averageBucketSize = 4
+	// maxD0 bounds the search for the first displacement of a bucket
+	maxD0 = 1 << 16
+	// maxFreeSlotTries is how many free slots a bucket tries for its first key per d0
+	maxFreeSlotTries = 64
+	// maxSeeds is how many seeds BuildStaticMap tries before giving up
+	maxSeeds = 16
+)
+
+// ErrBuildFailed is returned when no seed yields a perfect hash for the key set, it only happens
+// for keys whose hashes do not depend on the seed
+var ErrBuildFailed = errors.New("static map: no perfect hash found")
+
+// ErrTooManyKeys is returned for key sets that do not fit 32-bit slot indexes
+var ErrTooManyKeys = errors.New("static map: too many keys")
+
+// StaticMap is an immutable map built once over a fixed key set with a CHD (compress, hash and
+// displace) minimal perfect hash. Keys are hashed into buckets of about four, every bucket
+// stores a displacement pair (d0, d1) that sends its keys to distinct slots as
+// (scramble(f1 + d0*f2) mod n + d1) mod n, where f1 and f2 come from the same hash. There are exactly as
+// many slots as keys, a lookup hashes once, reads one displacement and compares one key, and it
+// reduces modulo n with multiplications rather than a division.
+// A StaticMap is safe for concurrent use.
+// Example:
+//
+//	countries, err := BuildStaticMap(map[string]string{"TH": "Thailand", "JP": "Japan"})
+//	if err != nil {
+//	    return err
+//	}
+//	name, exists := countries.Get("TH")
+type StaticMap[K comparable, V any] struct {
+	seed uint64
+	hash hashing.Func[K]
+	// modulus is the fastModulus constant for the number of entries
+	modulus uint64
+	// displacements holds d0 in the high and d1 in the low 32 bits for each bucket
+	displacements []uint64
+	// entries holds the entry of each slot, key and value side by side so a hit costs one
+	// cache miss
+	entries []staticEntry[K, V]
+}
+
+type staticEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// BuildStaticMap builds a StaticMap over the given entries. Building takes roughly a second per
+// few million keys.
+// Example:
+//
+//	skus, err := BuildStaticMap(loadProducts())
+func BuildStaticMap[K comparable, V any](entries map[K]V) (*StaticMap[K, V], error) {
+	if uint64(len(entries)) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: %d", ErrTooManyKeys, len(entries))
+	}
+	keys := make([]K, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+
+	hash := hashing.For[K]()
+	for range maxSeeds {
+		seed := hashing.RandomSeed()
+		if slots, displacements, ok := buildCHD(keys, seed, hash); ok {
+			m := &StaticMap[K, V]{
+				seed:          seed,
+				hash:          hash,
+				modulus:       fastModulus(len(keys)),
+				displacements: displacements,
+				entries:       make([]staticEntry[K, V], len(keys)),
+			}
+			for i, k := range keys {
+				m.entries[slots[i]] = staticEntry[K, V]{key: k, value: entries[k]}
+			}
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d keys, %d seeds", ErrBuildFailed, len(keys), maxSeeds)
+}
+
+// chdHash splits a key hash into its bucket and two 32-bit slot hashes
+func chdHash(hash uint64, buckets int) (bucket, f1, f2 uint64) {
+	mixed := (hash ^ hash>>29) * 0xbf58476d1ce4e5b9
+	bucket, _ = bits.Mul64(hash, uint64(buckets))
+	return bucket, uint64(uint32(hash)), mixed >> 32
+}
+
+// fastModulus returns the constant that position uses to reduce modulo n
+func fastModulus(n int) uint64 {
+	return math.MaxUint64/uint64(max(n, 1)) + 1
+}
+
+// position maps f1 + d0*f2 to a slot below n. The sum is scrambled by a multiplication before
+// its high 32 bits are reduced, a plain sum modulo n is linear in d0 and for n a power of two
+// often cannot separate a bucket under any d0. The reduction uses Lemire's multiply-based
+// remainder instead of a division.
+func position(f1, f2, d0, modulus, n uint64) uint64 {
+	x := (f1 + d0*f2) * 0x9e3779b97f4a7c15 >> 32
+	hi, _ := bits.Mul64(modulus*x, n)
+	return hi
+}
+
+// displace adds d1 to a position modulo n
+func displace(p, d1, n uint64) uint64 {
+	if p += d1; p >= n {
+		p -= n
+	}
+	return p
+}
+
+func bucketCount(n int) int {
+	return max(1, (n+averageBucketSize-1)/averageBucketSize)
+}
+
+// buildCHD assigns every key a slot and returns the slots along with the bucket displacements,
+// or false when the seed leaves two keys inseparable
+func buildCHD[K comparable](keys []K, seed uint64, hash hashing.Func[K]) ([]uint32, []uint64, bool) {
+	n := len(keys)
+	buckets := bucketCount(n)
+	f1s := make([]uint32, n)
+	f2s := make([]uint32, n)
+	keyBucket := make([]uint32, n)
+	bucketSizes := make([]uint32, buckets+1)
+	for i, k := range keys {
+		b, f1, f2 := chdHash(hash(seed, k), buckets)
+		keyBucket[i], f1s[i], f2s[i] = uint32(b), uint32(f1), uint32(f2)
+		bucketSizes[b+1]++
+	}
+
+	// Counting sort the keys by bucket, then the buckets by size, largest first
+	starts := bucketSizes
+	largest := uint32(0)
+	for b := 1; b <= buckets; b++ {
+		largest = max(largest, starts[b])
+		starts[b] += starts[b-1]
+	}
+	members := make([]uint32, n)
+	next := append([]uint32(nil), starts[:buckets]...)
+	for i, b := range keyBucket {
+		members[next[b]] = uint32(i)
+		next[b]++
+	}
+	bySize := make([][]uint32, largest+1)
+	for b := 0; b < buckets; b++ {
+		size := starts[b+1] - starts[b]
+		bySize[size] = append(bySize[size], uint32(b))
+	}
+
+	slots := make([]uint32, n)
+	displacements := make([]uint64, buckets)
+	free := newFreeSlots(n)
+	modulus := fastModulus(n)
+	rng := rand.New(rand.NewPCG(seed, uint64(n)))
+	positions := make([]uint64, 0, largest)
+	for size := int(largest); size > 0; size-- {
+		for _, b := range bySize[size] {
+			bucket := members[starts[b]:starts[b+1]]
+			d, ok := displaceBucket(bucket, f1s, f2s, free, rng, modulus, positions)
+			if !ok {
+				return nil, nil, false
+			}
+			displacements[b] = d
+			d0, d1 := d>>32, d&math.MaxUint32
+			for _, i := range bucket {
+				slot := displace(position(uint64(f1s[i]), uint64(f2s[i]), d0, modulus, uint64(n)), d1, uint64(n))
+				slots[i] = uint32(slot)
+				free.take(uint32(slot))
+			}
+		}
+	}
+	return slots, displacements, true
+}
+
+// displaceBucket searches a displacement pair that puts every key of the bucket on a free slot
+func displaceBucket(bucket []uint32, f1s, f2s []uint32, free *freeSlots, rng *rand.Rand, modulus uint64, positions []uint64) (uint64, bool) {
+	n := uint64(len(free.taken))
+	// Keys with equal f1 and f2 collide under every displacement
+	for x, i := range bucket {
+		for _, j := range bucket[x+1:] {
+			if f1s[i] == f1s[j] && f2s[i] == f2s[j] {
+				return 0, false
+			}
+		}
+	}
+
+	for d0 := uint64(0); d0 < maxD0; d0++ {
+		positions = positions[:0]
+		for _, i := range bucket {
+			positions = append(positions, position(uint64(f1s[i]), uint64(f2s[i]), d0, modulus, n))
+		}
+		if hasDuplicate(positions) {
+			continue
+		}
+		// Line the first key up with a free slot, then check the others
+		tries := min(len(free.slots), maxFreeSlotTries)
+		start := rng.IntN(len(free.slots))
+		for t := 0; t < tries; t++ {
+			target := uint64(free.slots[(start+t)%len(free.slots)])
+			d1 := displace(target, n-positions[0], n)
+			fits := true
+			for _, p := range positions[1:] {
+				if free.taken[displace(p, d1, n)] {
+					fits = false
+					break
+				}
+			}
+			if fits {
+				return d0<<32 | d1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func hasDuplicate(positions []uint64) bool {
+	for x, p := range positions {
+		for _, q := range positions[x+1:] {
+			if p == q {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// freeSlots tracks the unassigned slots with O(1) removal
+type freeSlots struct {
+	slots []uint32
+	// index is the position of each free slot in slots
+	index []uint32
+	taken []bool
+}
+
+func newFreeSlots(n int) *freeSlots {
+	f := &freeSlots{slots: make([]uint32, n), index: make([]uint32, n), taken: make([]bool, n)}
+	for i := range f.slots {
+		f.slots[i], f.index[i] = uint32(i), uint32(i)
+	}
+	return f
+}
+
+func (f *freeSlots) take(slot uint32) {
+	last := f.slots[len(f.slots)-1]
+	f.slots[f.index[slot]] = last
+	f.index[last] = f.index[slot]
+	f.slots = f.slots[:len(f.slots)-1]
+	f.taken[slot] = true
+}
+
+// slot returns the only slot key can occupy
+func (m *StaticMap[K, V]) slot(key K) uint64 {
+	return m.slotIn(key, len(m.entries))
+}
+
+// slotIn is slot for a table of n entries, used while the entries are still being decoded
+func (m *StaticMap[K, V]) slotIn(key K, n int) uint64 {
+	bucket, f1, f2 := chdHash(m.hash(m.seed, key), len(m.displacements))
+	d := m.displacements[bucket]
+	return displace(position(f1, f2, d>>32, m.modulus, uint64(n)), d&math.MaxUint32, uint64(n))
+}
+
+// Get retrieves a value by key and returns whether it exists
+// Example:
+//
+//	if name, exists := countries.Get("TH"); exists {
+//	    fmt.Printf("Found country: %s\n", name)
+//	}
+func (m *StaticMap[K, V]) Get(key K) (V, bool) {
+	if len(m.entries) > 0 {
+		if entry := &m.entries[m.slot(key)]; entry.key == key {
+			return entry.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Contains checks if a key exists
+func (m *StaticMap[K, V]) Contains(key K) bool {
+	return len(m.entries) > 0 && m.entries[m.slot(key)].key == key
+}
+
+// Size returns the number of elements
+func (m *StaticMap[K, V]) Size() int {
+	return len(m.entries)
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *StaticMap[K, V]) IsEmpty() bool {
+	return len(m.entries) == 0
+}
+
+// Keys returns a slice of all keys
+func (m *StaticMap[K, V]) Keys() []K {
+	keys := make([]K, len(m.entries))
+	for i := range m.entries {
+		keys[i] = m.entries[i].key
+	}
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *StaticMap[K, V]) Values() []V {
+	values := make([]V, len(m.entries))
+	for i := range m.entries {
+		values[i] = m.entries[i].value
+	}
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails
+// Example:
+//
+//	err := countries.ForEach(func(code string, name string) error {
+//	    fmt.Printf("%s: %s\n", code, name)
+//	    return nil
+//	})
+func (m *StaticMap[K, V]) ForEach(callback func(K, V) error) error {
+	for i := range m.entries {
+		if err := callback(m.entries[i].key, m.entries[i].value); err != nil {
+			return fmt.Errorf("ForEach operation failed at key %v: %w", m.entries[i].key, err)
+		}
+	}
+	return nil
+}
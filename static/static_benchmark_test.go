@@ -0,0 +1,97 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	hashmap "github.com/billowdev/fastmap/hashmap"
+	static "github.com/billowdev/fastmap/static"
+)
+
+const benchmarkKeys = 1 << 20
+
+func benchmarkEntries() (map[string]int, []string) {
+	entries := make(map[string]int, benchmarkKeys)
+	keys := make([]string, benchmarkKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("SKU-%08d", i)
+		entries[keys[i]] = i
+	}
+	return entries, keys
+}
+
+func BenchmarkBuildStaticMap(b *testing.B) {
+	entries, _ := benchmarkEntries()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := static.BuildStaticMap(entries); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*benchmarkKeys), "ns/key")
+}
+
+func BenchmarkStaticMapGet(b *testing.B) {
+	entries, keys := benchmarkEntries()
+	sm, err := static.BuildStaticMap(entries)
+	if err != nil {
+		b.Fatal(err)
+	}
+	hm := hashmap.FromMap(entries)
+
+	b.Run("StaticMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sm.Get(keys[i%benchmarkKeys])
+		}
+	})
+
+	b.Run("HashMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hm.Get(keys[i%benchmarkKeys])
+		}
+	})
+
+	b.Run("StandardMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = entries[keys[i%benchmarkKeys]]
+		}
+	})
+}
+
+// heapBytes returns the live heap after a collection
+func heapBytes() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func BenchmarkStaticMapMemory(b *testing.B) {
+	entries := make(map[uint64]uint64, benchmarkKeys)
+	for i := uint64(0); i < benchmarkKeys; i++ {
+		entries[i*0x9E3779B97F4A7C15] = i
+	}
+	measure := func(b *testing.B, build func() any) {
+		for i := 0; i < b.N; i++ {
+			before := heapBytes()
+			m := build()
+			b.ReportMetric(float64(heapBytes()-before)/benchmarkKeys, "bytes/key")
+			runtime.KeepAlive(m)
+		}
+	}
+
+	b.Run("StaticMap", func(b *testing.B) {
+		measure(b, func() any {
+			m, err := static.BuildStaticMap(entries)
+			if err != nil {
+				b.Fatal(err)
+			}
+			return m
+		})
+	})
+
+	b.Run("HashMap", func(b *testing.B) {
+		measure(b, func() any { return hashmap.FromMap(entries) })
+	})
+}
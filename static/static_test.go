@@ -0,0 +1,211 @@
+package fastmap_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/billowdev/fastmap"
+	"github.com/billowdev/fastmap/fastmaptest"
+	hashmap "github.com/billowdev/fastmap/hashmap"
+	static "github.com/billowdev/fastmap/static"
+)
+
+var _ fastmap.ReadOnlyMap[string, int] = (*static.StaticMap[string, int])(nil)
+
+func TestStaticMapConformance(t *testing.T) {
+	fastmaptest.RunReadOnlyMapSuite(t, func(entries map[string]int) fastmap.ReadOnlyMap[string, int] {
+		m, err := static.BuildStaticMap(entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	})
+}
+
+func TestStaticMapSmallKeySets(t *testing.T) {
+	for n := 0; n <= 40; n++ {
+		entries := make(map[string]int, n)
+		for i := 0; i < n; i++ {
+			entries[fmt.Sprint("key", i)] = i
+		}
+		m, err := static.BuildStaticMap(entries)
+		if err != nil {
+			t.Fatalf("%d keys: %v", n, err)
+		}
+		if m.Size() != n {
+			t.Errorf("%d keys: Size = %d", n, m.Size())
+		}
+		for k, v := range entries {
+			if value, exists := m.Get(k); !exists || value != v {
+				t.Errorf("%d keys: Get(%s) = (%d, %v), want %d", n, k, value, exists, v)
+			}
+		}
+		if m.Contains("missing") {
+			t.Errorf("%d keys: Contains reported a missing key", n)
+		}
+	}
+}
+
+func TestStaticMapTinyKeySets(t *testing.T) {
+	// Buckets of a few keys in a table of a few slots used to be inseparable for most seeds
+	for n := 1; n <= 8; n++ {
+		for set := 0; set < 200; set++ {
+			entries := make(map[uint16]int, n)
+			for i := 0; i < n; i++ {
+				entries[uint16(set*8+i)*257] = i
+			}
+			m, err := static.BuildStaticMap(entries)
+			if err != nil {
+				t.Fatalf("%d keys, set %d: %v", n, set, err)
+			}
+			for k, v := range entries {
+				if value, exists := m.Get(k); !exists || value != v {
+					t.Fatalf("%d keys, set %d: Get(%d) = (%d, %v), want %d", n, set, k, value, exists, v)
+				}
+			}
+		}
+	}
+}
+
+func TestStaticMapMillionsOfKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a map of three million keys")
+	}
+	const n = 3_000_000
+	entries := make(map[uint64]uint32, n)
+	for i := uint64(0); i < n; i++ {
+		entries[i*0x9E3779B97F4A7C15] = uint32(i)
+	}
+	m, err := static.BuildStaticMap(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One slot per key, so every key finding its own value proves the hash is perfect
+	for k, v := range entries {
+		if value, exists := m.Get(k); !exists || value != v {
+			t.Fatalf("Get(%d) = (%d, %v), want %d", k, value, exists, v)
+		}
+	}
+	for i := uint64(0); i < 1000; i++ {
+		if m.Contains(i*0x9E3779B97F4A7C15 + 1) {
+			t.Fatalf("Contains reported the missing key %d", i*0x9E3779B97F4A7C15+1)
+		}
+	}
+}
+
+func TestStaticMapEncoding(t *testing.T) {
+	entries := make(map[string]int)
+	for i := 0; i < 5000; i++ {
+		entries[fmt.Sprintf("SKU-%05d", i)] = i
+	}
+	m, err := static.BuildStaticMap(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := m.Encode(&buf, hashmap.StringCodec{}, hashmap.JSONCodec[int]{}); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	decoded, err := static.DecodeStaticMap(bytes.NewReader(encoded), hashmap.StringCodec{}, hashmap.JSONCodec[int]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Size() != len(entries) {
+		t.Errorf("decoded Size = %d, want %d", decoded.Size(), len(entries))
+	}
+	for k, v := range entries {
+		if value, exists := decoded.Get(k); !exists || value != v {
+			t.Fatalf("decoded Get(%s) = (%d, %v), want %d", k, value, exists, v)
+		}
+	}
+
+	corruptions := map[string]func([]byte) []byte{
+		"magic":     func(b []byte) []byte { b[0] = 'X'; return b },
+		"seed":      func(b []byte) []byte { b[8]++; return b },
+		"truncated": func(b []byte) []byte { return b[:len(b)-3] },
+		"key count": func(b []byte) []byte { b[16]++; return b },
+	}
+	for name, corrupt := range corruptions {
+		data := corrupt(bytes.Clone(encoded))
+		if _, err := static.DecodeStaticMap(bytes.NewReader(data), hashmap.StringCodec{}, hashmap.JSONCodec[int]{}); err == nil {
+			t.Errorf("%s: decoding corrupt data succeeded", name)
+		}
+	}
+	data := bytes.Clone(encoded)
+	data[8]++
+	_, err = static.DecodeStaticMap(bytes.NewReader(data), hashmap.StringCodec{}, hashmap.JSONCodec[int]{})
+	if !errors.Is(err, static.ErrCorrupt) {
+		t.Errorf("changed seed error = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestStaticMapDecodeHugeHeader(t *testing.T) {
+	// A header announcing 4 billion keys followed by no data must fail without allocating for them
+	header := []byte{'F', 'M', 'S', 'T', 'A', 'T', 'I', 1}
+	header = binary.LittleEndian.AppendUint64(header, 0)
+	header = binary.LittleEndian.AppendUint64(header, math.MaxUint32)
+	header = binary.LittleEndian.AppendUint64(header, (math.MaxUint32+3)/4)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := static.DecodeStaticMap(bytes.NewReader(header), hashmap.StringCodec{}, hashmap.JSONCodec[int]{})
+	runtime.ReadMemStats(&after)
+	if err == nil {
+		t.Fatal("decoding a header without data succeeded")
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("decoding allocated %d bytes for a header without data", allocated)
+	}
+}
+
+func FuzzStaticMap(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// A StaticMap is built once, so the operations shape the reference it is built from
+		ops := fastmaptest.DecodeOps(data)
+		reference := make(map[uint16]int)
+		for _, op := range ops {
+			switch op.Kind {
+			case fastmaptest.OpPut:
+				reference[op.Key] = op.Value
+			case fastmaptest.OpRemove:
+				delete(reference, op.Key)
+			case fastmaptest.OpClear:
+				clear(reference)
+			}
+		}
+		m, err := static.BuildStaticMap(reference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := m.Encode(&buf, hashmap.JSONCodec[uint16]{}, hashmap.JSONCodec[int]{}); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := static.DecodeStaticMap(&buf, hashmap.JSONCodec[uint16]{}, hashmap.JSONCodec[int]{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, target := range map[string]*static.StaticMap[uint16, int]{"built": m, "decoded": decoded} {
+			if target.Size() != len(reference) {
+				t.Fatalf("%s: Size = %d, want %d", name, target.Size(), len(reference))
+			}
+			for _, op := range ops {
+				want, wantExists := reference[op.Key]
+				if value, exists := target.Get(op.Key); exists != wantExists || value != want {
+					t.Fatalf("%s: Get(%d) = (%d, %v), want (%d, %v)", name, op.Key, value, exists, want, wantExists)
+				}
+			}
+		}
+	})
+}
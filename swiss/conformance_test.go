@@ -0,0 +1,17 @@
+package fastmap_test
+
+import (
+	"testing"
+
+	"github.com/billowdev/fastmap"
+	"github.com/billowdev/fastmap/fastmaptest"
+	swiss "github.com/billowdev/fastmap/swiss"
+)
+
+var _ fastmap.Map[string, int] = (*swiss.SwissMap[string, int])(nil)
+
+func TestSwissMapConformance(t *testing.T) {
+	fastmaptest.RunMapSuite(t, func() fastmap.Map[string, int] {
+		return swiss.NewSwissMap[string, int]()
+	})
+}
@@ -0,0 +1,19 @@
+package fastmap_test
+
+import (
+	"testing"
+
+	"github.com/billowdev/fastmap/fastmaptest"
+	swiss "github.com/billowdev/fastmap/swiss"
+)
+
+func FuzzSwissMap(f *testing.F) {
+	for _, seed := range fastmaptest.SeedCorpus() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fastmaptest.RunOps(t, fastmaptest.DecodeOps(data), map[string]fastmaptest.Target{
+			"swiss": swiss.NewSwissMap[uint16, int](),
+		})
+	})
+}
@@ -0,0 +1,296 @@
+package fastmap
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/billowdev/fastmap/internal/hashing"
+)
+
+const (
+	groupSize = 8
+
+	// Control bytes: full slots store the 7-bit H2 hash with the top bit clear
+	ctrlEmpty   = 0b1000_0000
+	ctrlDeleted = 0b1111_1110
+
+	lsbs = 0x0101010101010101
+	msbs = 0x8080808080808080
+
+	// maxLoadNumerator / groupSize is the fraction of slots that may hold entries or tombstones
+	maxLoadNumerator = 7
+)
+
+// SwissMap is a SwissTable-style open-addressing hash map. Slots are arranged in groups of
+// eight with one control byte each, the hash is split into H1, which picks the starting group,
+// and H2, a 7-bit tag stored in the control byte. Lookups compare all eight control bytes of a
+// group at once with portable bit tricks and only compare keys on tag matches. Removed slots
+// become tombstones unless their group never filled up.
+// Like a built-in map it is safe for concurrent readers as long as nobody writes.
+// Example:
+//
+//	m := NewSwissMap[string, User]()
+//	m.Put("user1", User{Name: "John"})
+type SwissMap[K comparable, V any] struct {
+	groups     []group[K, V]
+	groupMask  uint64
+	size       int
+	tombstones int
+	// growthLeft is the number of empty slots that may still be filled before rehashing
+	growthLeft int
+	seed       uint64
+	hash       hashing.Func[K]
+}
+
+type group[K comparable, V any] struct {
+	ctrl   uint64
+	keys   [groupSize]K
+	values [groupSize]V
+}
+
+// NewSwissMap creates a new empty SwissMap
+// Example:
+//
+//	m := NewSwissMap[string, User]()
+func NewSwissMap[K comparable, V any]() *SwissMap[K, V] {
+	m := &SwissMap[K, V]{
+		seed: hashing.RandomSeed(),
+		hash: hashing.For[K](),
+	}
+	m.allocate(1)
+	return m
+}
+
+func (m *SwissMap[K, V]) allocate(groups int) {
+	m.groups = make([]group[K, V], groups)
+	for i := range m.groups {
+		m.groups[i].ctrl = lsbs * ctrlEmpty
+	}
+	m.groupMask = uint64(groups - 1)
+	m.size = 0
+	m.tombstones = 0
+	m.growthLeft = groups * maxLoadNumerator
+}
+
+func splitHash(hash uint64) (h1 uint64, h2 uint8) {
+	return hash >> 7, uint8(hash & 0x7f)
+}
+
+// matchH2 returns a bit per byte of ctrl equal to h2. It can report false positives next to a
+// real match, which the key comparison weeds out.
+func matchH2(ctrl uint64, h2 uint8) uint64 {
+	x := ctrl ^ (lsbs * uint64(h2))
+	return (x - lsbs) &^ x & msbs
+}
+
+// matchEmpty returns a bit per empty byte, empty has the top bit set and bit 1 clear
+func matchEmpty(ctrl uint64) uint64 {
+	return ctrl &^ (ctrl << 6) & msbs
+}
+
+// matchEmptyOrDeleted returns a bit per byte with the top bit set
+func matchEmptyOrDeleted(ctrl uint64) uint64 {
+	return ctrl & msbs
+}
+
+// firstSlot returns the slot index of the lowest set match bit
+func firstSlot(match uint64) int {
+	return bits.TrailingZeros64(match) / 8
+}
+
+func setCtrl(ctrl *uint64, slot int, value uint8) {
+	shift := uint(slot * 8)
+	*ctrl = *ctrl&^(0xff<<shift) | uint64(value)<<shift
+}
+
+// Put adds or updates a key-value pair
+// Example:
+//
+//	m.Put("user123", User{Name: "John"})
+func (m *SwissMap[K, V]) Put(key K, value V) {
+	h1, h2 := splitHash(m.hash(m.seed, key))
+	if g, slot := m.find(h1, h2, key); g != nil {
+		g.values[slot] = value
+		return
+	}
+
+	g, slot := m.findInsertSlot(h1)
+	if g.ctrl>>(slot*8)&0xff == ctrlEmpty && m.growthLeft == 0 {
+		m.rehash()
+		g, slot = m.findInsertSlot(h1)
+	}
+	if g.ctrl>>(slot*8)&0xff == ctrlEmpty {
+		m.growthLeft--
+	} else {
+		m.tombstones--
+	}
+	setCtrl(&g.ctrl, slot, h2)
+	g.keys[slot] = key
+	g.values[slot] = value
+	m.size++
+}
+
+// Get retrieves a value by key and returns whether it exists
+// Example:
+//
+//	if user, exists := m.Get("user123"); exists {
+//	    fmt.Printf("Found user: %v\n", user)
+//	}
+func (m *SwissMap[K, V]) Get(key K) (V, bool) {
+	h1, h2 := splitHash(m.hash(m.seed, key))
+	if g, slot := m.find(h1, h2, key); g != nil {
+		return g.values[slot], true
+	}
+	var zero V
+	return zero, false
+}
+
+// Remove deletes a key and returns whether it existed
+// Example:
+//
+//	if m.Remove("user123") {
+//	    fmt.Println("removed")
+//	}
+func (m *SwissMap[K, V]) Remove(key K) bool {
+	h1, h2 := splitHash(m.hash(m.seed, key))
+	g, slot := m.find(h1, h2, key)
+	if g == nil {
+		return false
+	}
+	var zeroKey K
+	var zeroValue V
+	g.keys[slot] = zeroKey
+	g.values[slot] = zeroValue
+	m.size--
+	// A group that still has an empty slot never stopped a probe, so the slot can be reused
+	// as empty. Otherwise later keys may have probed past it and it must stay a tombstone.
+	if matchEmpty(g.ctrl) != 0 {
+		setCtrl(&g.ctrl, slot, ctrlEmpty)
+		m.growthLeft++
+	} else {
+		setCtrl(&g.ctrl, slot, ctrlDeleted)
+		m.tombstones++
+	}
+	return true
+}
+
+// Contains checks if a key exists
+func (m *SwissMap[K, V]) Contains(key K) bool {
+	_, exists := m.Get(key)
+	return exists
+}
+
+// Size returns the number of elements
+func (m *SwissMap[K, V]) Size() int {
+	return m.size
+}
+
+// IsEmpty returns true if the map has no elements
+func (m *SwissMap[K, V]) IsEmpty() bool {
+	return m.size == 0
+}
+
+// Keys returns a slice of all keys
+func (m *SwissMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.size)
+	m.ForEach(func(key K, _ V) error {
+		keys = append(keys, key)
+		return nil
+	})
+	return keys
+}
+
+// Values returns a slice of all values
+func (m *SwissMap[K, V]) Values() []V {
+	values := make([]V, 0, m.size)
+	m.ForEach(func(_ K, value V) error {
+		values = append(values, value)
+		return nil
+	})
+	return values
+}
+
+// ForEach executes a callback function for each key-value pair and returns an error if the callback fails.
+// The callback must not modify the map.
+// Example:
+//
+//	err := m.ForEach(func(key string, user User) error {
+//	    fmt.Printf("User %s: %v\n", key, user)
+//	    return nil
+//	})
+func (m *SwissMap[K, V]) ForEach(callback func(K, V) error) error {
+	for i := range m.groups {
+		g := &m.groups[i]
+		for full := ^g.ctrl & msbs; full != 0; full &= full - 1 {
+			slot := firstSlot(full)
+			if err := callback(g.keys[slot], g.values[slot]); err != nil {
+				return fmt.Errorf("ForEach operation failed at key %v: %w", g.keys[slot], err)
+			}
+		}
+	}
+	return nil
+}
+
+// Clear removes all elements and shrinks back to a single group
+func (m *SwissMap[K, V]) Clear() {
+	m.allocate(1)
+}
+
+// find returns the group and slot holding key, or a nil group if the key is absent
+func (m *SwissMap[K, V]) find(h1 uint64, h2 uint8, key K) (*group[K, V], int) {
+	index := h1 & m.groupMask
+	for step := uint64(1); ; step++ {
+		g := &m.groups[index]
+		for match := matchH2(g.ctrl, h2); match != 0; match &= match - 1 {
+			slot := firstSlot(match)
+			if g.keys[slot] == key {
+				return g, slot
+			}
+		}
+		if matchEmpty(g.ctrl) != 0 {
+			return nil, 0
+		}
+		// Triangular probing visits every group when the group count is a power of two
+		index = (index + step) & m.groupMask
+	}
+}
+
+// findInsertSlot returns the first empty or deleted slot on the probe sequence of h1
+func (m *SwissMap[K, V]) findInsertSlot(h1 uint64) (*group[K, V], int) {
+	index := h1 & m.groupMask
+	for step := uint64(1); ; step++ {
+		g := &m.groups[index]
+		if match := matchEmptyOrDeleted(g.ctrl); match != 0 {
+			return g, firstSlot(match)
+		}
+		index = (index + step) & m.groupMask
+	}
+}
+
+// rehash rebuilds the table, doubling it unless dropping tombstones frees enough room
+func (m *SwissMap[K, V]) rehash() {
+	groups := len(m.groups)
+	if m.size*2 >= groups*maxLoadNumerator {
+		groups *= 2
+	}
+	old := m.groups
+	m.allocate(groups)
+	for i := range old {
+		g := &old[i]
+		for full := ^g.ctrl & msbs; full != 0; full &= full - 1 {
+			slot := firstSlot(full)
+			m.insertNew(g.keys[slot], g.values[slot])
+		}
+	}
+}
+
+// insertNew places a key known to be absent into a table without tombstones
+func (m *SwissMap[K, V]) insertNew(key K, value V) {
+	h1, h2 := splitHash(m.hash(m.seed, key))
+	g, slot := m.findInsertSlot(h1)
+	setCtrl(&g.ctrl, slot, h2)
+	g.keys[slot] = key
+	g.values[slot] = value
+	m.growthLeft--
+	m.size++
+}
@@ -0,0 +1,83 @@
+package fastmap_test
+
+import (
+	"fmt"
+	"testing"
+
+	swiss "github.com/billowdev/fastmap/swiss"
+)
+
+func BenchmarkSwissPut(b *testing.B) {
+	m := swiss.NewSwissMap[string, int]()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("key%d", i)
+		m.Put(key, i)
+	}
+}
+
+func BenchmarkSwissGet(b *testing.B) {
+	m := swiss.NewSwissMap[string, int]()
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		m.Put(key, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("key%d", i%1000)
+		m.Get(key)
+	}
+}
+
+func BenchmarkSwissRemove(b *testing.B) {
+	m := swiss.NewSwissMap[string, int]()
+	keys := make([]string, b.N)
+
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("key%d", i)
+		keys[i] = key
+		m.Put(key, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Remove(keys[i])
+	}
+}
+
+func BenchmarkComparisonWithStandardMap(b *testing.B) {
+	b.Run("Swiss", func(b *testing.B) {
+		m := swiss.NewSwissMap[string, int]()
+		for i := 0; i < b.N; i++ {
+			key := fmt.Sprintf("key%d", i)
+			m.Put(key, i)
+			m.Get(key)
+		}
+	})
+
+	b.Run("StandardMap", func(b *testing.B) {
+		m := make(map[string]int)
+		for i := 0; i < b.N; i++ {
+			key := fmt.Sprintf("key%d", i)
+			m[key] = i
+			_ = m[key]
+		}
+	})
+}
+
+func BenchmarkHighLoadFactor(b *testing.B) {
+	m := swiss.NewSwissMap[string, int]()
+	// Fill to 90% capacity
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("existing%d", i)
+		m.Put(key, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("key%d", i)
+		m.Put(key, i)
+	}
+}
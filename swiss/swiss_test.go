@@ -0,0 +1,146 @@
+package fastmap
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestNewSwissMap(t *testing.T) {
+	m := NewSwissMap[string, int]()
+	if m == nil {
+		t.Fatal("NewSwissMap returned nil")
+	}
+	if m.Size() != 0 {
+		t.Errorf("New map should have size 0, got %d", m.Size())
+	}
+}
+
+func TestGroupMatching(t *testing.T) {
+	var ctrl uint64 = lsbs * ctrlEmpty
+	setCtrl(&ctrl, 1, 0x12)
+	setCtrl(&ctrl, 3, ctrlDeleted)
+	setCtrl(&ctrl, 6, 0x12)
+
+	if match := matchH2(ctrl, 0x12); firstSlot(match) != 1 || firstSlot(match&(match-1)) != 6 {
+		t.Errorf("matchH2 = %016x, want slots 1 and 6", match)
+	}
+	if match := matchEmpty(ctrl); match != msbs&^(0x80<<8|0x80<<24|0x80<<48) {
+		t.Errorf("matchEmpty = %016x", match)
+	}
+	if match := matchEmptyOrDeleted(ctrl); match != msbs&^(0x80<<8|0x80<<48) {
+		t.Errorf("matchEmptyOrDeleted = %016x", match)
+	}
+}
+
+func TestPutGetRemove(t *testing.T) {
+	m := NewSwissMap[string, int]()
+	m.Put("key1", 100)
+	m.Put("key1", 200)
+	if value, exists := m.Get("key1"); !exists || value != 200 {
+		t.Errorf("Get(key1) = (%d, %v), want 200", value, exists)
+	}
+	if !m.Remove("key1") || m.Remove("key1") {
+		t.Error("Remove should report whether the key existed")
+	}
+	if _, exists := m.Get("key1"); exists || m.Size() != 0 {
+		t.Error("key still present after Remove")
+	}
+}
+
+func TestResizingAndClear(t *testing.T) {
+	m := NewSwissMap[string, int]()
+	for i := 0; i < 1000; i++ {
+		m.Put(fmt.Sprintf("key%d", i), i)
+	}
+	for i := 0; i < 1000; i++ {
+		if value, exists := m.Get(fmt.Sprintf("key%d", i)); !exists || value != i {
+			t.Fatalf("Get(key%d) = (%d, %v) after resizing", i, value, exists)
+		}
+	}
+	if m.Size() != 1000 {
+		t.Errorf("Size should be 1000, got %d", m.Size())
+	}
+	m.Clear()
+	if m.Size() != 0 || len(m.groups) != 1 {
+		t.Error("Clear should empty the map and shrink it to one group")
+	}
+}
+
+func TestMatchesReference(t *testing.T) {
+	m := NewSwissMap[int, int]()
+	reference := make(map[int]int)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for i := 0; i < 100000; i++ {
+		key := rng.IntN(2000)
+		if rng.IntN(2) == 0 {
+			_, want := reference[key]
+			if m.Remove(key) != want {
+				t.Fatalf("Remove(%d) disagreed with reference at op %d", key, i)
+			}
+			delete(reference, key)
+		} else {
+			m.Put(key, i)
+			reference[key] = i
+		}
+	}
+	if m.Size() != len(reference) {
+		t.Errorf("Size = %d, want %d", m.Size(), len(reference))
+	}
+	for k, v := range reference {
+		if value, exists := m.Get(k); !exists || value != v {
+			t.Errorf("Get(%d) = (%d, %v), want %d", k, value, exists, v)
+		}
+	}
+}
+
+func TestTombstonesAreReclaimed(t *testing.T) {
+	m := NewSwissMap[int, int]()
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	groups := len(m.groups)
+	// Churn through many keys while the live count stays fixed
+	for i := 100; i < 100000; i++ {
+		m.Put(i, i)
+		m.Remove(i - 100)
+	}
+	if len(m.groups) > groups*2 {
+		t.Errorf("table grew from %d to %d groups with a constant live size", groups, len(m.groups))
+	}
+	if m.Size() != 100 {
+		t.Errorf("Size should be 100, got %d", m.Size())
+	}
+}
+
+func TestCollidingHashes(t *testing.T) {
+	m := NewSwissMap[int, int]()
+	// Only four distinct hashes, so keys share H1 and H2
+	m.hash = func(_ uint64, key int) uint64 { return uint64(key % 4) }
+	for i := 0; i < 500; i++ {
+		m.Put(i, i)
+	}
+	for i := 0; i < 500; i += 2 {
+		m.Remove(i)
+	}
+	for i := 0; i < 500; i++ {
+		value, exists := m.Get(i)
+		if exists != (i%2 == 1) || (exists && value != i) {
+			t.Fatalf("Get(%d) = (%d, %v)", i, value, exists)
+		}
+	}
+}
+
+func TestComplexKeyTypes(t *testing.T) {
+	type ComplexKey struct {
+		ID   int
+		Name string
+	}
+	m := NewSwissMap[ComplexKey, string]()
+	key := ComplexKey{ID: 1, Name: "Test"}
+	m.Put(key, "value1")
+	if value, exists := m.Get(key); !exists || value != "value1" {
+		t.Error("Failed to retrieve complex key")
+	}
+}